@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
@@ -16,24 +18,67 @@ import (
 	"github.com/jmoiron/sqlx"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/blobstore"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/bus"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/config"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/upload"
 
+	_auditDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/audit/delivery/http"
+	_authDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/auth/delivery/http"
 	_barcodeDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/barcode/delivery/http"
 	_binDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/bin/delivery/http"
 	_commodityDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/commodity/delivery/http"
+	_idempotencyDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/idempotency/delivery/http"
+	_inventoryDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/inventory/delivery/http"
+	_labelDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/label/delivery/http"
+	_locationDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/location/delivery/http"
+	_lotDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/lot/delivery/http"
+	_serialDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/serial/delivery/http"
 	_skuDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/sku/delivery/http"
+	_skuBarcodeDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/skubarcode/delivery/http"
 	_warehouseDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/warehouse/delivery/http"
+	_webhookDeliveryBus "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/webhook/delivery/bus"
+	_webhookDeliveryHTTP "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/webhook/delivery/http"
 
+	_auditRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/audit/repository"
+	_authRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/auth/repository"
 	_barcodeRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/barcode/repository"
 	_binRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/bin/repository"
 	_commodityRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/commodity/repository"
+	_idempotencyRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/idempotency/repository"
+	_inventoryRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/inventory/repository"
+	_locationRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/location/repository"
+	_lotRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/lot/repository"
+	_outboxRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/outbox/repository"
+	_scanRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/scan/repository"
+	_serialRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/serial/repository"
 	_skuRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/sku/repository"
+	_skuBarcodeRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/skubarcode/repository"
 	_warehouseRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/warehouse/repository"
+	_webhookRepository "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/webhook/repository"
 
+	_auditUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/audit/usecase"
+	_authUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/auth/usecase"
 	_barcodeUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/barcode/usecase"
 	_binUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/bin/usecase"
 	_commodityUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/commodity/usecase"
+	_idempotencyUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/idempotency/usecase"
+	_inventoryUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/inventory/usecase"
+	_labelUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/label/usecase"
+	_locationUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/location/usecase"
+	_lotUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/lot/usecase"
+	_outboxUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/outbox/usecase"
+	_serialUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/serial/usecase"
 	_skuUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/sku/usecase"
+	_skuBarcodeUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/skubarcode/usecase"
 	_warehouseUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/warehouse/usecase"
+	_webhookUsecase "github.com/alvinradeka/jamblang-hakenton/warehouse/internal/webhook/usecase"
+)
+
+const (
+	// Environment variables overriding the config start with WAREHOUSE_
+	envPrefix = "warehouse"
 )
 
 var (
@@ -47,51 +92,71 @@ var (
 
 type (
 	AppConfig struct {
-		Logger     LoggerConfig
-		HTTP       HTTPConfig
-		SQL        SQLConfig
-		Repository RepositoryConfig
-		Usecase    UsecaseConfig
+		Logger      LoggerConfig
+		HTTP        HTTPConfig
+		Auth        domain.AuthConfig
+		SQL         SQLConfig
+		Repository  RepositoryConfig
+		Usecase     UsecaseConfig
+		Outbox      domain.OutboxConfig
+		Webhook     domain.WebhookConfig
+		Idempotency domain.IdempotencyConfig
 	}
 
 	LoggerConfig struct {
-		Level string
+		Level string `validate:"oneof=panic fatal error warn warning info debug trace"`
 	}
 
 	HTTPConfig struct {
-		Host string
-		Port int64
+		Host   string
+		Port   int64 `validate:"min=1,max=65535"`
+		Upload upload.Config
 	}
 
 	SQLConfig struct {
-		Host     string
-		Port     int64
-		Username string
-		Password string
-		DBName   string
+		Host     string `validate:"required"`
+		Port     int64  `validate:"min=1,max=65535"`
+		Username string `validate:"required"`
+		Password string `validate:"required" secret:"true"`
+		DBName   string `validate:"required"`
 	}
 
 	RepositoryConfig struct {
-		Barcode domain.BarcodeRepositoryConfig
+		Barcode   domain.BarcodeRepositoryConfig
+		ScanImage blobstore.Config
 	}
 
 	UsecaseConfig struct {
+		Barcode domain.BarcodeUsecaseConfig
+		Label   domain.LabelUsecaseConfig
 	}
 )
 
 func main() {
 	// Run Viper (Config Reader)
-	// Config should be loaded in ./configs/config.yaml
+	// Config is loaded from ./configs/config.yaml when present, every field
+	// can be overridden by WAREHOUSE_* environment variables or secret files
 	viperInstance = viper.New()
 	viperInstance.AddConfigPath("./configs")
 	viperInstance.SetConfigType("yaml")
 	viperInstance.SetConfigName("config")
 
-	if err := viperInstance.ReadInConfig(); err != nil {
+	if err := config.Load(viperInstance, envPrefix, &configData); err != nil {
 		panic(err)
 	}
-	if err := viperInstance.Unmarshal(&configData); err != nil {
-		panic(err)
+
+	// Subcommands
+	if len(os.Args) > 1 {
+		if len(os.Args) == 3 && os.Args[1] == "config" && os.Args[2] == "print" {
+			os.Exit(printConfig(configData))
+		}
+		fmt.Fprintln(os.Stderr, "Usage: app [config print]")
+		os.Exit(2)
+	}
+
+	if err := config.Validate(configData); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
 	// Run Logger
@@ -103,6 +168,7 @@ func main() {
 	logrusInstance.SetLevel(_logrusLevel)
 	logrusInstance.SetFormatter(&logrus.TextFormatter{})
 	logrusInstance.SetOutput(os.Stdout)
+	logrusInstance.Debugf("Config %+v", config.Redact(configData))
 
 	// Run DB Instance
 	dbInstance, err = sqlx.Connect("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", configData.SQL.Username, configData.SQL.Password, configData.SQL.Host, configData.SQL.Port, configData.SQL.DBName))
@@ -121,22 +187,93 @@ func main() {
 	binRepository := _binRepository.NewSQL(logrusInstance, dbInstance)
 	commodityRepository := _commodityRepository.NewSQL(logrusInstance, dbInstance)
 	barcodeRepository := _barcodeRepository.New(logrusInstance, configData.Repository.Barcode, httpClient)
+	scanRepository := _scanRepository.NewSQL(logrusInstance, dbInstance)
+	skuBarcodeRepository := _skuBarcodeRepository.NewSQL(logrusInstance, dbInstance)
+	lotRepository := _lotRepository.NewSQL(logrusInstance, dbInstance)
+	stockRepository := _inventoryRepository.NewSQL(logrusInstance, dbInstance)
+	serialRepository := _serialRepository.NewSQL(logrusInstance, dbInstance)
+	locationRepository := _locationRepository.NewSQL(logrusInstance, dbInstance)
+	apiKeyRepository := _authRepository.NewSQL(logrusInstance, dbInstance)
+	auditRepository := _auditRepository.NewSQL(logrusInstance, dbInstance)
+	outboxRepository := _outboxRepository.NewSQL(logrusInstance, dbInstance)
+	webhookRepository := _webhookRepository.NewSQL(logrusInstance, dbInstance)
+
+	// Responses to Idempotency-Key requests, memory only suits one instance
+	idempotencyRepository := _idempotencyRepository.NewSQL(logrusInstance, dbInstance)
+	if configData.Idempotency.Store == domain.IdempotencyStoreMemory {
+		idempotencyRepository = _idempotencyRepository.NewMemory(logrusInstance)
+	}
+
+	// Usecases group repository calls that must succeed together
+	transactor := sqltx.NewTransactor(dbInstance)
+
+	// Scan images are optional, without a store only their hash is kept
+	var scanImageStore blobstore.Store
+	if configData.Repository.ScanImage.Enabled {
+		scanImageStore, err = blobstore.NewLocal(configData.Repository.ScanImage.Dir)
+		if err != nil {
+			logrusInstance.Fatalln(err)
+		}
+	}
 
 	// Build Usecases
-	warehouseUsecase := _warehouseUsecase.NewUsecase(logrusInstance, warehouseRepository, binRepository)
-	skuUsecase := _skuUsecase.NewUsecase(logrusInstance, skuRepository)
-	binUsecase := _binUsecase.NewUsecase(logrusInstance, binRepository, warehouseRepository)
-	commodityUsecase := _commodityUsecase.NewUsecase(logrusInstance, commodityRepository)
-	barcodeUsecase := _barcodeUsecase.NewUsecase(logrusInstance, barcodeRepository, warehouseRepository, skuRepository)
+	auditUsecase := _auditUsecase.NewUsecase(logrusInstance, auditRepository)
+	authUsecase := _authUsecase.NewUsecase(logrusInstance, configData.Auth, apiKeyRepository)
+	warehouseUsecase := _warehouseUsecase.NewUsecase(logrusInstance, warehouseRepository, binRepository, transactor, auditRepository, outboxRepository)
+	skuUsecase := _skuUsecase.NewUsecase(logrusInstance, skuRepository, commodityRepository, warehouseRepository, binRepository, transactor, auditRepository, outboxRepository)
+	binUsecase := _binUsecase.NewUsecase(logrusInstance, binRepository, warehouseRepository, stockRepository, skuRepository, transactor, auditRepository, outboxRepository)
+	commodityUsecase := _commodityUsecase.NewUsecase(logrusInstance, commodityRepository, skuRepository, stockRepository, warehouseRepository, transactor, auditRepository, outboxRepository)
+	skuBarcodeUsecase := _skuBarcodeUsecase.NewUsecase(logrusInstance, skuRepository, skuBarcodeRepository)
+	lotUsecase := _lotUsecase.NewUsecase(logrusInstance, skuRepository, lotRepository)
+	inventoryUsecase := _inventoryUsecase.NewUsecase(logrusInstance, stockRepository, skuRepository, lotRepository, binRepository, warehouseRepository, commodityRepository)
+	locationUsecase := _locationUsecase.NewUsecase(logrusInstance, locationRepository, binRepository, warehouseRepository, transactor, auditRepository, outboxRepository)
+	serialUsecase := _serialUsecase.NewUsecase(logrusInstance, skuRepository, binRepository, serialRepository)
+	labelUsecase := _labelUsecase.NewUsecase(logrusInstance, configData.Usecase.Label, skuRepository, skuBarcodeRepository, binRepository, warehouseRepository)
+	idempotencyUsecase := _idempotencyUsecase.NewUsecase(logrusInstance, configData.Idempotency, idempotencyRepository)
+	webhookUsecase := _webhookUsecase.NewUsecase(logrusInstance, configData.Webhook, webhookRepository, httpClient)
+	barcodeUsecase := _barcodeUsecase.NewUsecase(logrusInstance, configData.Usecase.Barcode, barcodeRepository, warehouseRepository, skuRepository, binRepository, scanRepository, skuBarcodeRepository, serialRepository, scanImageStore, transactor, outboxRepository)
+
+	// Change events reach the in-process bus, then every configured sink
+	eventBus := bus.New()
+	eventBus.Subscribe("*", func(ctx context.Context, msg bus.Message) error {
+		logrusInstance.Debugf("Event %s %s", msg.Topic, msg.Value)
+		return nil
+	})
+	_webhookDeliveryBus.NewBusDelivery(eventBus, logrusInstance, webhookUsecase)
+	var eventSinks []bus.Publisher
+	for _, url := range configData.Outbox.Webhooks {
+		eventSinks = append(eventSinks, bus.NewWebhook(httpClient, url))
+	}
+	outboxUsecase := _outboxUsecase.NewUsecase(logrusInstance, configData.Outbox, outboxRepository, transactor, eventBus, eventSinks...)
+	if configData.Outbox.Enabled {
+		go outboxUsecase.Run(context.Background())
+	}
+	if configData.Webhook.Enabled {
+		go webhookUsecase.Run(context.Background())
+	}
+	if configData.Idempotency.Enabled {
+		go idempotencyUsecase.Run(context.Background())
+	}
 
 	// Build Deliveries for HTTP
 	routerInstance = mux.NewRouter()
-	http.Handle("/", buildRouterHandle(logrusInstance, routerInstance))
+	authMiddleware := _authDeliveryHTTP.NewMiddleware(logrusInstance, configData.Auth, authUsecase)
+	idempotencyMiddleware := _idempotencyDeliveryHTTP.NewMiddleware(logrusInstance, configData.Idempotency, configData.HTTP.Upload.RequestLimit(), idempotencyUsecase)
+	http.Handle("/", buildRouterHandle(logrusInstance, routerInstance, authMiddleware, idempotencyMiddleware))
+	_authDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, authUsecase)
+	_auditDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, auditUsecase)
+	_webhookDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, webhookUsecase)
 	_warehouseDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, warehouseUsecase)
-	_skuDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, skuUsecase)
+	_skuDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, skuUsecase, warehouseUsecase)
 	_binDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, binUsecase)
-	_commodityDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, commodityUsecase)
-	_barcodeDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, skuUsecase, warehouseUsecase, barcodeUsecase)
+	_commodityDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, commodityUsecase, warehouseUsecase)
+	_skuBarcodeDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, skuBarcodeUsecase)
+	_lotDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, lotUsecase)
+	_inventoryDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, inventoryUsecase, binUsecase)
+	_serialDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, serialUsecase, binUsecase)
+	_locationDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, locationUsecase)
+	_labelDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, labelUsecase, binUsecase)
+	_barcodeDeliveryHTTP.NewHTTPDelivery(routerInstance, logrusInstance, configData.HTTP.Upload, skuUsecase, warehouseUsecase, binUsecase, barcodeUsecase)
 
 	// Small Health Check
 	routerInstance.HandleFunc("/sys/_health", func(w http.ResponseWriter, r *http.Request) {
@@ -148,7 +285,24 @@ func main() {
 	logrusInstance.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%d", configData.HTTP.Host, configData.HTTP.Port), nil))
 }
 
-func buildRouterHandle(log *logrus.Logger, h http.Handler) http.Handler {
+// printConfig shows the effective config with its secrets masked, the exit
+// code tells whether it would pass validation
+func printConfig(cfg AppConfig) int {
+	out, err := json.MarshalIndent(config.Redact(cfg), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Println(string(out))
+
+	if err := config.Validate(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func buildRouterHandle(log *logrus.Logger, h http.Handler, authenticate, idempotent func(http.Handler) http.Handler) http.Handler {
 	// Build Recover Function
 	recover := handlers.RecoveryHandler(handlers.RecoveryLogger(log))
 
@@ -156,7 +310,11 @@ func buildRouterHandle(log *logrus.Logger, h http.Handler) http.Handler {
 	return handlers.LoggingHandler(log.Writer(),
 		handlers.ProxyHeaders(
 			handlers.CompressHandler(
-				recover(h),
+				recover(
+					authenticate(
+						idempotent(h),
+					),
+				),
 			),
 		))
 }
@@ -0,0 +1,21 @@
+create table warehouse_db.barcode_scans
+(
+    id           bigint auto_increment
+        primary key,
+    user_id      varchar(255) not null,
+    device_id    varchar(255) not null,
+    warehouse_id bigint       null,
+    image_hash   char(64)     not null,
+    image_key    varchar(255) not null,
+    decoder      varchar(64)  not null,
+    detections   json         not null,
+    results      json         not null,
+    error        text         not null,
+    created_at   timestamp    not null
+);
+
+create index barcode_scans_warehouse_id_created_at_index
+    on warehouse_db.barcode_scans (warehouse_id, created_at);
+
+create index barcode_scans_image_hash_index
+    on warehouse_db.barcode_scans (image_hash);
@@ -0,0 +1,17 @@
+create table warehouse_db.sku_barcodes
+(
+    id         bigint auto_increment
+        primary key,
+    sku_id     bigint       not null,
+    barcode    varchar(255) not null,
+    type       varchar(32)  not null,
+    pack_level varchar(16)  not null,
+    quantity   bigint       not null,
+    created_at timestamp    not null,
+    updated_at timestamp    not null,
+    constraint sku_barcodes_barcode_uindex
+        unique (barcode)
+);
+
+create index sku_barcodes_sku_id_index
+    on warehouse_db.sku_barcodes (sku_id);
@@ -0,0 +1,33 @@
+create table warehouse_db.lots
+(
+    id              bigint auto_increment
+        primary key,
+    sku_id          bigint       not null,
+    lot_number      varchar(255) not null,
+    manufactured_at date         null,
+    expires_at      date         null,
+    created_at      timestamp    not null,
+    updated_at      timestamp    not null,
+    constraint lots_sku_id_lot_number_uindex
+        unique (sku_id, lot_number)
+);
+
+create index lots_expires_at_index
+    on warehouse_db.lots (expires_at);
+
+create table warehouse_db.stock_balances
+(
+    id         bigint auto_increment
+        primary key,
+    sku_id     bigint    not null,
+    lot_id     bigint    not null,
+    bin_id     bigint    not null,
+    quantity   bigint    not null,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    constraint stock_balances_sku_id_lot_id_bin_id_uindex
+        unique (sku_id, lot_id, bin_id)
+);
+
+create index stock_balances_bin_id_index
+    on warehouse_db.stock_balances (bin_id);
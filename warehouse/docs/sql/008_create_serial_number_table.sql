@@ -0,0 +1,33 @@
+alter table warehouse_db.skus
+    add serialized boolean default false not null;
+
+create table warehouse_db.serial_numbers
+(
+    id         bigint auto_increment
+        primary key,
+    sku_id     bigint       not null,
+    serial     varchar(255) not null,
+    status     varchar(16)  not null,
+    bin_id     bigint       null,
+    created_at timestamp    not null,
+    updated_at timestamp    not null,
+    constraint serial_numbers_sku_id_serial_uindex
+        unique (sku_id, serial)
+);
+
+create index serial_numbers_serial_index
+    on warehouse_db.serial_numbers (serial);
+
+create table warehouse_db.serial_number_events
+(
+    id               bigint auto_increment
+        primary key,
+    serial_number_id bigint       not null,
+    status           varchar(16)  not null,
+    bin_id           bigint       null,
+    note             varchar(255) not null,
+    created_at       timestamp    not null
+);
+
+create index serial_number_events_serial_number_id_index
+    on warehouse_db.serial_number_events (serial_number_id);
@@ -0,0 +1,15 @@
+alter table warehouse_db.skus
+    add base_uom varchar(16) default 'pcs' not null;
+
+create table warehouse_db.sku_packs
+(
+    sku_id    bigint      not null,
+    level     varchar(16) not null,
+    quantity  bigint      not null,
+    weight_kg double      not null,
+    length_mm double      not null,
+    width_mm  double      not null,
+    height_mm double      not null,
+    constraint sku_packs_pk
+        primary key (sku_id, level)
+);
@@ -0,0 +1,8 @@
+alter table warehouse_db.bins
+    add zone_id       varchar(255) default ''      not null,
+    add type          varchar(16)  default 'shelf' not null,
+    add length_mm     double       default 0       not null,
+    add width_mm      double       default 0       not null,
+    add height_mm     double       default 0       not null,
+    add max_weight_kg double       default 0       not null,
+    add max_volume_m3 double       default 0       not null;
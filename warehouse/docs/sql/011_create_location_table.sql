@@ -0,0 +1,26 @@
+create table warehouse_db.locations
+(
+    id           bigint auto_increment
+        primary key,
+    warehouse_id bigint       not null,
+    parent_id    bigint       null,
+    type         varchar(16)  not null,
+    code         varchar(16)  not null,
+    name         varchar(255) not null,
+    full_code    varchar(255) not null,
+    path         varchar(255) not null,
+    depth        int          not null,
+    bin_id       bigint       null,
+    created_at   timestamp    not null,
+    updated_at   timestamp    not null
+);
+
+create index locations_parent_id_index
+    on warehouse_db.locations (parent_id);
+
+create index locations_path_index
+    on warehouse_db.locations (path);
+
+alter table warehouse_db.locations
+    add constraint locations_warehouse_id_full_code_uindex
+        unique (warehouse_id, full_code);
@@ -0,0 +1,12 @@
+alter table warehouse_db.commodities
+    add hazmat_class varchar(8) default '' not null,
+    add min_temp_c   double                null,
+    add max_temp_c   double                null,
+    add fragile      tinyint(1) default 0  not null,
+    add stackable    tinyint(1) default 1  not null;
+
+alter table warehouse_db.skus
+    add commodity_id bigint null;
+
+create index skus_commodity_id_index
+    on warehouse_db.skus (commodity_id);
@@ -0,0 +1,20 @@
+create table warehouse_db.api_keys
+(
+    id           bigint auto_increment
+        primary key,
+    name         varchar(255) not null,
+    prefix       varchar(16)  not null,
+    key_hash     char(64)     not null,
+    device_id    varchar(255) not null,
+    admin        tinyint(1)   not null,
+    created_by   varchar(255) not null,
+    last_used_at timestamp    null,
+    revoked_at   timestamp    null,
+    created_at   timestamp    not null,
+    updated_at   timestamp    not null,
+    constraint api_keys_prefix_uindex
+        unique (prefix)
+);
+
+create index api_keys_device_id_index
+    on warehouse_db.api_keys (device_id);
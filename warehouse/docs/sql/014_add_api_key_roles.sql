@@ -0,0 +1,7 @@
+alter table warehouse_db.api_keys
+    add roles varchar(1024) default '' not null after admin;
+
+-- Keys created before roles existed keep scanning in every warehouse
+update warehouse_db.api_keys
+set roles = 'scanner-device'
+where admin = 0;
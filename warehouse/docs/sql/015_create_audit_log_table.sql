@@ -0,0 +1,19 @@
+create table warehouse_db.audit_logs
+(
+    id           bigint auto_increment
+        primary key,
+    actor        varchar(255) not null,
+    entity_type  varchar(32)  not null,
+    entity_id    bigint       not null,
+    warehouse_id bigint       not null,
+    action       varchar(16)  not null,
+    before_data  json         null,
+    after_data   json         null,
+    created_at   timestamp    not null
+);
+
+create index audit_logs_entity_type_entity_id_index
+    on warehouse_db.audit_logs (entity_type, entity_id);
+
+create index audit_logs_warehouse_id_created_at_index
+    on warehouse_db.audit_logs (warehouse_id, created_at);
@@ -0,0 +1,19 @@
+-- SKIP LOCKED needs MySQL 8.0 or later
+create table warehouse_db.outbox_events
+(
+    id            bigint auto_increment
+        primary key,
+    event_type    varchar(64)  not null,
+    entity_id     bigint       not null,
+    warehouse_id  bigint       not null,
+    payload       json         not null,
+    attempts      int          not null default 0,
+    last_error    text         null,
+    -- Set while a dispatcher publishes the event to network sinks
+    claimed_until timestamp    null,
+    published_at  timestamp    null,
+    created_at    timestamp    not null
+);
+
+create index outbox_events_published_at_id_index
+    on warehouse_db.outbox_events (published_at, id);
@@ -0,0 +1,35 @@
+create table warehouse_db.webhook_subscriptions
+(
+    id          bigint auto_increment
+        primary key,
+    url         varchar(2048) not null,
+    event_types varchar(1024) not null,
+    secret      varchar(128)  not null,
+    active      tinyint(1)    not null default 1,
+    created_by  varchar(255)  not null,
+    created_at  timestamp     not null,
+    updated_at  timestamp     not null
+);
+
+create table warehouse_db.webhook_deliveries
+(
+    id              bigint auto_increment
+        primary key,
+    subscription_id bigint       not null,
+    event_id        bigint       not null,
+    event_type      varchar(64)  not null,
+    payload         json         not null,
+    status          varchar(16)  not null,
+    attempts        int          not null default 0,
+    response_code   int          not null default 0,
+    last_error      text         null,
+    next_attempt_at timestamp    not null,
+    delivered_at    timestamp    null,
+    created_at      timestamp    not null,
+    updated_at      timestamp    not null,
+    constraint webhook_deliveries_subscription_id_event_id_uindex
+        unique (subscription_id, event_id)
+);
+
+create index webhook_deliveries_status_next_attempt_at_index
+    on warehouse_db.webhook_deliveries (status, next_attempt_at);
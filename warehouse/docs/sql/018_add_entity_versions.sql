@@ -0,0 +1,32 @@
+-- Bumped by every update, PUT and DELETE must send the current one as If-Match
+alter table warehouse_db.warehouses
+    add version bigint not null default 1;
+
+alter table warehouse_db.bins
+    add version bigint not null default 1;
+
+alter table warehouse_db.skus
+    add version bigint not null default 1;
+
+alter table warehouse_db.commodities
+    add version bigint not null default 1;
+
+alter table warehouse_db.lots
+    add version bigint not null default 1;
+
+alter table warehouse_db.sku_barcodes
+    add version bigint not null default 1;
+
+alter table warehouse_db.locations
+    add version bigint not null default 1;
+
+alter table warehouse_db.serial_numbers
+    add version bigint not null default 1;
+
+-- Updating or deleting a webhook subscription and revoking an API key send
+-- If-Match as well
+alter table warehouse_db.webhook_subscriptions
+    add version bigint not null default 1;
+
+alter table warehouse_db.api_keys
+    add version bigint not null default 1;
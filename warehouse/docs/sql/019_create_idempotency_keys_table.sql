@@ -0,0 +1,18 @@
+create table warehouse_db.idempotency_keys
+(
+    id              bigint auto_increment
+        primary key,
+    idempotency_key char(64)   not null,
+    fingerprint     char(64)   not null,
+    status_code     int        not null default 0,
+    header          json       null,
+    body            mediumblob null,
+    expires_at      timestamp  not null,
+    created_at      timestamp  not null,
+    updated_at      timestamp  not null,
+    constraint idempotency_keys_idempotency_key_uindex
+        unique (idempotency_key)
+);
+
+create index idempotency_keys_expires_at_index
+    on warehouse_db.idempotency_keys (expires_at);
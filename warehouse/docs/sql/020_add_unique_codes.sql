@@ -0,0 +1,15 @@
+-- Duplicates have to be renamed before these apply, find them with
+--   select sku, count(*) from warehouse_db.skus group by sku having count(*) > 1;
+--   select warehouse_id, name, count(*) from warehouse_db.bins group by warehouse_id, name having count(*) > 1;
+--   select name, count(*) from warehouse_db.warehouses group by name having count(*) > 1;
+alter table warehouse_db.skus
+    add constraint skus_sku_uindex
+        unique (sku);
+
+alter table warehouse_db.bins
+    add constraint bins_warehouse_id_name_uindex
+        unique (warehouse_id, name);
+
+alter table warehouse_db.warehouses
+    add constraint warehouses_name_uindex
+        unique (name);
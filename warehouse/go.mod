@@ -14,4 +14,5 @@ require (
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/viper v1.7.1
+	golang.org/x/image v0.0.0-20210220032944-ac19c3e999fb
 )
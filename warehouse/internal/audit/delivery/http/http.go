@@ -0,0 +1,71 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger *logrus.Logger
+	audit  domain.AuditUsecase
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, audit domain.AuditUsecase) {
+	httpInstance := &httpDelivery{
+		logger: logger,
+		audit:  audit,
+	}
+
+	// Bind with given router
+	router.HandleFunc("/audit", httpInstance.Select).Methods("GET")
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.AuditQueryParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list changes in the warehouses the caller may audit, changes to
+	// shared entities need a grant covering every warehouse
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermAuditRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.AuditEntryResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.audit.Select(r.Context(), queryParam)
+	if err != nil {
+		h.responseError(w, err, "Cannot Query Audit Log")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+func (ar *auditRepository) Create(ctx context.Context, data domain.AuditDataParameter) error {
+	before, err := snapshot(data.Before)
+	if err != nil {
+		return err
+	}
+
+	after, err := snapshot(data.After)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := squirrel.Insert("audit_logs").Columns(
+		"actor",
+		"entity_type",
+		"entity_id",
+		"warehouse_id",
+		"action",
+		"before_data",
+		"after_data",
+		"created_at",
+	).Values(
+		data.Actor,
+		data.EntityType,
+		data.EntityID,
+		data.WarehouseID,
+		data.Action,
+		before,
+		after,
+		time.Now(),
+	).ToSql()
+
+	if err != nil {
+		ar.logger.Errorln(err)
+		return err
+	}
+
+	query = ar.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, ar.sql).Exec(query, args...); err != nil {
+		ar.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (ar *auditRepository) Select(ctx context.Context, params domain.AuditQueryParameter) ([]domain.AuditEntry, error) {
+	var (
+		entriesData []domain.AuditEntry
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"actor",
+		"entity_type",
+		"entity_id",
+		"warehouse_id",
+		"action",
+		"before_data",
+		"after_data",
+		"created_at",
+	).From("audit_logs")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return entriesData, err
+	}
+
+	query = ar.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, ar.sql).Query(query, args...)
+	if err != nil {
+		return entriesData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			entryData domain.AuditEntry
+			before    sql.NullString
+			after     sql.NullString
+		)
+
+		if err := rows.Scan(
+			&entryData.ID,
+			&entryData.Actor,
+			&entryData.EntityType,
+			&entryData.EntityID,
+			&entryData.WarehouseID,
+			&entryData.Action,
+			&before,
+			&after,
+			&entryData.CreatedAt,
+		); err != nil {
+			return entriesData, err
+		}
+
+		if before.Valid {
+			entryData.Before = json.RawMessage(before.String)
+		}
+		if after.Valid {
+			entryData.After = json.RawMessage(after.String)
+		}
+
+		entriesData = append(entriesData, entryData)
+	}
+
+	return entriesData, nil
+}
+
+// snapshot stores NULL for the missing side of creates and deletes
+func snapshot(v interface{}) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
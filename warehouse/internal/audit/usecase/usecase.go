@@ -0,0 +1,37 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type auditUsecase struct {
+	logger *logrus.Logger
+	audit  domain.AuditRepository
+}
+
+func NewUsecase(logger *logrus.Logger, audit domain.AuditRepository) domain.AuditUsecase {
+	return &auditUsecase{
+		logger: logger,
+		audit:  audit,
+	}
+}
+
+func (uc *auditUsecase) Select(ctx context.Context, params domain.AuditQueryParameter) ([]domain.AuditEntryResponse, error) {
+	var (
+		entryResponses = []domain.AuditEntryResponse{}
+	)
+
+	entriesData, err := uc.audit.Select(ctx, params)
+	if err != nil {
+		return entryResponses, err
+	}
+
+	for _, entry := range entriesData {
+		entryResponses = append(entryResponses, entry.AuditEntryResponse())
+	}
+
+	return entryResponses, nil
+}
@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	auth      domain.AuthUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, auth domain.AuthUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		auth:      auth,
+		validator: validator.New(),
+	}
+
+	// Bind with given router, only administrators of every warehouse manage keys
+	router.HandleFunc("/admin/apikeys", httpInstance.SelectKeys).Methods("GET")
+	router.HandleFunc("/admin/apikeys", httpInstance.CreateKey).Methods("POST")
+	router.HandleFunc("/admin/apikeys/{id}", httpInstance.RevokeKey).Methods("DELETE")
+}
+
+func (h *httpDelivery) SelectKeys(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.APIKeyQueryParameter
+	)
+
+	if err := domain.AuthorizeAdmin(r.Context()); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	responses, err := h.auth.SelectKeys(r.Context(), queryParam)
+	if err != nil {
+		h.responseError(w, err, "Cannot Query API Keys")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		createData domain.APIKeyDataParameter
+	)
+
+	if err := domain.AuthorizeAdmin(r.Context()); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.auth.CreateKey(r.Context(), createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating API Key")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	var (
+		keyID int64
+	)
+
+	if err := domain.AuthorizeAdmin(r.Context()); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		keyID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.auth.RevokeKey(r.Context(), keyID, version); err != nil {
+		h.responseError(w, err, "Unable to Revoke API Key")
+	} else {
+		httpcommon.SetETag(w, resp.Version)
+		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrInvalidGrant):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
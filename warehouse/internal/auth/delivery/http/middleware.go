@@ -0,0 +1,79 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	headerAPIKey = "X-API-Key"
+)
+
+var (
+	// Holds every permission when authentication is disabled
+	anonymous = domain.Principal{
+		Type:   domain.PrincipalTypeAnonymous,
+		Grants: []domain.Grant{{Role: domain.RoleAdmin}},
+	}
+)
+
+// NewMiddleware rejects requests without valid credentials and stores the
+// principal in the request context. Devices send their key in X-API-Key and
+// users send a JWT as Authorization: Bearer.
+func NewMiddleware(logger *logrus.Logger, cfg domain.AuthConfig, auth domain.AuthUsecase) func(http.Handler) http.Handler {
+	publicPaths := make(map[string]bool)
+	for _, path := range cfg.PublicPaths {
+		publicPaths[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			logger.Warnln("Authentication is disabled, every endpoint is open")
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				next.ServeHTTP(w, r.WithContext(domain.WithPrincipal(r.Context(), anonymous)))
+			})
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if publicPaths[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := auth.Authenticate(r.Context(), credentials(r))
+			if err != nil {
+				switch {
+				case errors.Is(err, domain.ErrUnauthenticated),
+					errors.Is(err, domain.ErrInvalidAPIKey),
+					errors.Is(err, domain.ErrInvalidToken):
+					w.Header().Set("WWW-Authenticate", `Bearer realm="warehouse"`)
+					httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+				default:
+					logger.Errorln(err)
+					httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Authenticate Request")
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(domain.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func credentials(r *http.Request) domain.Credentials {
+	if key := r.Header.Get(headerAPIKey); len(key) > 0 {
+		return domain.Credentials{APIKey: key}
+	}
+
+	authorization := r.Header.Get("Authorization")
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return domain.Credentials{BearerToken: strings.TrimSpace(authorization[7:])}
+	}
+
+	return domain.Credentials{}
+}
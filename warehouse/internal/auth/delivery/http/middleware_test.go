@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/auth/usecase"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testSecret = "0123456789abcdef0123456789abcdef"
+)
+
+// fakeKeys keeps API keys in memory, it is enough for the usecase to create,
+// revoke and look them up
+type fakeKeys struct {
+	keys []domain.APIKey
+}
+
+func (f *fakeKeys) Get(keyID int64) (domain.APIKey, error) {
+	for _, key := range f.keys {
+		if key.ID == keyID {
+			return key, nil
+		}
+	}
+	return domain.APIKey{}, sql.ErrNoRows
+}
+
+func (f *fakeKeys) GetByPrefix(prefix string) (domain.APIKey, error) {
+	for _, key := range f.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return domain.APIKey{}, sql.ErrNoRows
+}
+
+func (f *fakeKeys) Select(params domain.APIKeyQueryParameter) ([]domain.APIKey, error) {
+	return f.keys, nil
+}
+
+func (f *fakeKeys) Create(data domain.APIKeyDataParameter) (domain.APIKey, error) {
+	key := domain.APIKey{
+		ID:       int64(len(f.keys) + 1),
+		Name:     data.Name,
+		Prefix:   data.Prefix,
+		Hash:     data.Hash,
+		DeviceID: data.DeviceID,
+		Admin:    data.Admin,
+		Roles:    data.Roles,
+		Version:  1,
+	}
+	f.keys = append(f.keys, key)
+	return key, nil
+}
+
+func (f *fakeKeys) Revoke(keyID, version int64) (domain.APIKey, error) {
+	for i, key := range f.keys {
+		if key.ID != keyID {
+			continue
+		}
+		if key.Version != version {
+			return domain.APIKey{}, domain.ErrVersionConflict
+		}
+		t := time.Now()
+		f.keys[i].RevokedAt = &t
+		f.keys[i].Version++
+		return f.keys[i], nil
+	}
+	return domain.APIKey{}, sql.ErrNoRows
+}
+
+func (f *fakeKeys) Touch(keyID int64, usedAt time.Time) error {
+	return nil
+}
+
+func signToken(t *testing.T, audience string) string {
+	token, err := jwt.Sign(jwt.Claims{
+		Subject:   "u1",
+		Issuer:    "auth",
+		Audience:  jwt.Audience{audience},
+		ExpiresAt: time.Now().Add(time.Minute).Unix(),
+	}, []byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestMiddleware(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	cfg := domain.AuthConfig{
+		Enabled:     true,
+		JWT:         domain.JWTConfig{Secret: testSecret, Issuer: "auth", Audience: "warehouse"},
+		PublicPaths: []string{"/health"},
+	}
+	auth := usecase.NewUsecase(logger, cfg, &fakeKeys{})
+
+	admin := domain.WithPrincipal(context.Background(), domain.Principal{Grants: []domain.Grant{{Role: domain.RoleAdmin}}})
+	active, err := auth.CreateKey(admin, domain.APIKeyDataParameter{Name: "scanner", DeviceID: "d1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := auth.CreateKey(admin, domain.APIKeyDataParameter{Name: "lost", DeviceID: "d2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := auth.RevokeKey(admin, revoked.ID, revoked.Version); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same prefix, another secret
+	wrongHash := active.Key + "x"
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+		wantType   string
+	}{
+		{"api key", "/sku", headerAPIKey, active.Key, http.StatusOK, domain.PrincipalTypeDevice},
+		{"bearer token", "/sku", "Authorization", "Bearer " + signToken(t, "warehouse"), http.StatusOK, domain.PrincipalTypeUser},
+		{"public path", "/health", "", "", http.StatusOK, ""},
+		{"public path is exact", "/health/db", "", "", http.StatusUnauthorized, ""},
+		{"no credentials", "/sku", "", "", http.StatusUnauthorized, ""},
+		{"revoked key", "/sku", headerAPIKey, revoked.Key, http.StatusUnauthorized, ""},
+		{"wrong hash", "/sku", headerAPIKey, wrongHash, http.StatusUnauthorized, ""},
+		{"unknown prefix", "/sku", headerAPIKey, "whk_00000000_secret", http.StatusUnauthorized, ""},
+		{"token for another audience", "/sku", "Authorization", "Bearer " + signToken(t, "billing"), http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principalType string
+			handler := NewMiddleware(logger, cfg, auth)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if principal, ok := domain.PrincipalFromContext(r.Context()); ok {
+					principalType = principal.Type
+				}
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if len(tt.header) > 0 {
+				r.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if principalType != tt.wantType {
+				t.Errorf("principal type = %q, want %q", principalType, tt.wantType)
+			}
+			if rec.Code == http.StatusUnauthorized && len(rec.Header().Get("WWW-Authenticate")) < 1 {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
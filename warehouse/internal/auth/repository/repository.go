@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+type apiKeyRepository struct {
+	logger *logrus.Logger
+	sql    *sqlx.DB
+}
+
+func NewSQL(logger *logrus.Logger, sql *sqlx.DB) domain.APIKeyRepository {
+	return &apiKeyRepository{
+		logger: logger,
+		sql:    sql,
+	}
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+var (
+	apiKeyColumns = []string{
+		"id",
+		"name",
+		"prefix",
+		"key_hash",
+		"device_id",
+		"admin",
+		"roles",
+		"version",
+		"created_by",
+		"last_used_at",
+		"revoked_at",
+		"created_at",
+		"updated_at",
+	}
+)
+
+func (ar *apiKeyRepository) Get(keyID int64) (domain.APIKey, error) {
+	return ar.getBy(squirrel.Eq{"id": keyID})
+}
+
+func (ar *apiKeyRepository) GetByPrefix(prefix string) (domain.APIKey, error) {
+	return ar.getBy(squirrel.Eq{"prefix": prefix})
+}
+
+func (ar *apiKeyRepository) Select(params domain.APIKeyQueryParameter) ([]domain.APIKey, error) {
+	var (
+		keysData []domain.APIKey
+	)
+
+	selector := squirrel.Select(apiKeyColumns...).From("api_keys")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return keysData, err
+	}
+
+	query = ar.sql.Rebind(query)
+	rows, err := ar.sql.Query(query, args...)
+	if err != nil {
+		return keysData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		keyData, err := scanAPIKey(rows)
+		if err != nil {
+			return keysData, err
+		}
+
+		keysData = append(keysData, keyData)
+	}
+
+	return keysData, nil
+}
+
+func (ar *apiKeyRepository) Create(data domain.APIKeyDataParameter) (domain.APIKey, error) {
+	var (
+		keyData domain.APIKey
+		t       = time.Now()
+	)
+
+	query, args, err := squirrel.Insert("api_keys").Columns(
+		"name",
+		"prefix",
+		"key_hash",
+		"device_id",
+		"admin",
+		"roles",
+		"created_by",
+		"created_at",
+		"updated_at",
+	).Values(
+		data.Name,
+		data.Prefix,
+		data.Hash,
+		data.DeviceID,
+		data.Admin,
+		strings.Join(data.Roles, ","),
+		data.CreatedBy,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		ar.logger.Errorln(err)
+		return keyData, err
+	}
+
+	query = ar.sql.Rebind(query)
+	result, err := ar.sql.Exec(query, args...)
+	if err != nil {
+		ar.logger.Errorln(err)
+		return keyData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		ar.logger.Errorln(err)
+		return keyData, err
+	}
+
+	keyData, err = ar.Get(lastInserted)
+	if err != nil {
+		ar.logger.Errorln(err)
+		return keyData, err
+	}
+
+	return keyData, nil
+}
+
+func (ar *apiKeyRepository) Revoke(keyID, version int64) (domain.APIKey, error) {
+	t := time.Now()
+
+	query, args, err := squirrel.Update("api_keys").
+		Set("revoked_at", squirrel.Expr("coalesce(revoked_at, ?)", t)).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", t).
+		Where(squirrel.Eq{"id": keyID, "version": version}).
+		ToSql()
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	query = ar.sql.Rebind(query)
+	result, err := ar.sql.Exec(query, args...)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	err = domain.CheckVersion(result, func() error {
+		_, err := ar.Get(keyID)
+		return err
+	})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return ar.Get(keyID)
+}
+
+func (ar *apiKeyRepository) Touch(keyID int64, usedAt time.Time) error {
+	query, args, err := squirrel.Update("api_keys").
+		Set("last_used_at", usedAt).
+		Where(squirrel.Eq{"id": keyID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	query = ar.sql.Rebind(query)
+	_, err = ar.sql.Exec(query, args...)
+	return err
+}
+
+func (ar *apiKeyRepository) getBy(where squirrel.Eq) (domain.APIKey, error) {
+	query, args, err := squirrel.Select(apiKeyColumns...).From("api_keys").Where(where).ToSql()
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	query = ar.sql.Rebind(query)
+	row := ar.sql.QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return scanAPIKey(row)
+}
+
+func scanAPIKey(row scanner) (domain.APIKey, error) {
+	var (
+		keyData    domain.APIKey
+		roles      string
+		lastUsedAt sql.NullTime
+		revokedAt  sql.NullTime
+	)
+
+	err := row.Scan(
+		&keyData.ID,
+		&keyData.Name,
+		&keyData.Prefix,
+		&keyData.Hash,
+		&keyData.DeviceID,
+		&keyData.Admin,
+		&roles,
+		&keyData.Version,
+		&keyData.CreatedBy,
+		&lastUsedAt,
+		&revokedAt,
+		&keyData.CreatedAt,
+		&keyData.UpdatedAt,
+	)
+	if err != nil {
+		return keyData, err
+	}
+
+	if len(roles) > 0 {
+		keyData.Roles = strings.Split(roles, ",")
+	}
+	if lastUsedAt.Valid {
+		keyData.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		keyData.RevokedAt = &revokedAt.Time
+	}
+
+	return keyData, nil
+}
@@ -0,0 +1,231 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/jwt"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	prefixBytes = 4
+	secretBytes = 32
+
+	// last_used_at is only written once per interval, not on every request
+	touchInterval = time.Minute
+)
+
+type authUsecase struct {
+	logger    *logrus.Logger
+	validator jwt.Validator
+	apiKey    domain.APIKeyRepository
+}
+
+func NewUsecase(logger *logrus.Logger, cfg domain.AuthConfig, apiKey domain.APIKeyRepository) domain.AuthUsecase {
+	return &authUsecase{
+		logger: logger,
+		validator: jwt.Validator{
+			Secret:   []byte(cfg.JWT.Secret),
+			Issuer:   cfg.JWT.Issuer,
+			Audience: cfg.JWT.Audience,
+			Leeway:   cfg.JWT.Leeway,
+		},
+		apiKey: apiKey,
+	}
+}
+
+func (uc *authUsecase) Authenticate(ctx context.Context, credentials domain.Credentials) (domain.Principal, error) {
+	switch {
+	case len(credentials.APIKey) > 0:
+		return uc.authenticateKey(credentials.APIKey)
+	case len(credentials.BearerToken) > 0:
+		return uc.authenticateToken(credentials.BearerToken)
+	default:
+		return domain.Principal{}, domain.ErrUnauthenticated
+	}
+}
+
+func (uc *authUsecase) SelectKeys(ctx context.Context, params domain.APIKeyQueryParameter) ([]domain.APIKeyResponse, error) {
+	var (
+		keyResponses = []domain.APIKeyResponse{}
+	)
+
+	if _, err := requireAdmin(ctx); err != nil {
+		return keyResponses, err
+	}
+
+	keysData, err := uc.apiKey.Select(params)
+	if err != nil {
+		return keyResponses, err
+	}
+
+	for _, key := range keysData {
+		keyResponses = append(keyResponses, key.APIKeyResponse())
+	}
+
+	return keyResponses, nil
+}
+
+func (uc *authUsecase) CreateKey(ctx context.Context, data domain.APIKeyDataParameter) (domain.APIKeyCreatedResponse, error) {
+	var (
+		keyResponse domain.APIKeyCreatedResponse
+	)
+
+	principal, err := requireAdmin(ctx)
+	if err != nil {
+		return keyResponse, err
+	}
+
+	if len(data.Roles) < 1 && !data.Admin {
+		data.Roles = []string{domain.RoleScannerDevice}
+	}
+	grants, err := domain.ParseGrants(data.Roles)
+	if err != nil {
+		return keyResponse, err
+	}
+	data.Roles = data.Roles[:0]
+	for _, grant := range grants {
+		data.Roles = append(data.Roles, grant.String())
+	}
+
+	prefix, err := randomString(prefixBytes, hex.EncodeToString)
+	if err != nil {
+		return keyResponse, err
+	}
+
+	secret, err := randomString(secretBytes, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return keyResponse, err
+	}
+
+	key := strings.Join([]string{domain.APIKeyScheme, prefix, secret}, "_")
+	data.Prefix = prefix
+	data.Hash = hashKey(key)
+	data.CreatedBy = principal.Type + ":" + principal.ID
+
+	keyData, err := uc.apiKey.Create(data)
+	if err != nil {
+		return keyResponse, err
+	}
+
+	keyResponse.APIKeyResponse = keyData.APIKeyResponse()
+	keyResponse.Key = key
+	return keyResponse, nil
+}
+
+func (uc *authUsecase) RevokeKey(ctx context.Context, keyID, version int64) (domain.APIKeyResponse, error) {
+	var (
+		keyResponse domain.APIKeyResponse
+	)
+
+	if _, err := requireAdmin(ctx); err != nil {
+		return keyResponse, err
+	}
+
+	keyData, err := uc.apiKey.Revoke(keyID, version)
+	if err != nil {
+		return keyResponse, err
+	}
+
+	keyResponse = keyData.APIKeyResponse()
+	return keyResponse, nil
+}
+
+func (uc *authUsecase) authenticateKey(key string) (domain.Principal, error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != domain.APIKeyScheme {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+
+	keyData, err := uc.apiKey.GetByPrefix(parts[1])
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return domain.Principal{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(keyData.Hash), []byte(hashKey(key))) != 1 {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+	if keyData.RevokedAt != nil {
+		return domain.Principal{}, domain.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if keyData.LastUsedAt == nil || now.Sub(*keyData.LastUsedAt) > touchInterval {
+		// A failed touch must not lock the device out
+		if err := uc.apiKey.Touch(keyData.ID, now); err != nil {
+			uc.logger.Errorln(err)
+		}
+	}
+
+	return keyData.Principal(), nil
+}
+
+func (uc *authUsecase) authenticateToken(token string) (domain.Principal, error) {
+	claims, err := uc.validator.Verify(token, time.Now())
+	if err != nil {
+		uc.logger.Debugln(err)
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	if len(claims.Subject) < 1 {
+		return domain.Principal{}, domain.ErrInvalidToken
+	}
+
+	principal := domain.Principal{
+		Type: domain.PrincipalTypeUser,
+		ID:   claims.Subject,
+		Name: claims.Name,
+	}
+
+	if claims.Admin {
+		principal.Grants = append(principal.Grants, domain.Grant{Role: domain.RoleAdmin})
+	}
+	for _, role := range claims.Roles {
+		// Tokens may carry roles of other services, those grant nothing here
+		grant, err := domain.ParseGrant(role)
+		if err != nil {
+			uc.logger.Debugln("Ignoring role", role, "of", claims.Subject)
+			continue
+		}
+		principal.Grants = append(principal.Grants, grant)
+	}
+
+	return principal, nil
+}
+
+// requireAdmin repeats the check of the delivery, keys must never be managed
+// by anyone else whatever calls the usecase
+func requireAdmin(ctx context.Context) (domain.Principal, error) {
+	if err := domain.AuthorizeAdmin(ctx); err != nil {
+		return domain.Principal{}, err
+	}
+
+	principal, _ := domain.PrincipalFromContext(ctx)
+	return principal, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(n int, encode func([]byte) string) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return encode(b), nil
+}
@@ -1,13 +1,14 @@
 package http
 
 import (
-	"io"
-	"io/ioutil"
+	"errors"
 	"net/http"
-	"os"
+	"strconv"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/imaging"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/upload"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -17,47 +18,273 @@ type httpDelivery struct {
 	logger    *logrus.Logger
 	sku       domain.SKUUsecase
 	warehouse domain.WarehouseUsecase
+	bin       domain.BinUsecase
 	barcode   domain.BarcodeUsecase
 	validator *validator.Validate
+	upload    upload.Config
 }
 
-func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, sku domain.SKUUsecase, warehouse domain.WarehouseUsecase, barcode domain.BarcodeUsecase) {
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, uploadCfg upload.Config, sku domain.SKUUsecase, warehouse domain.WarehouseUsecase, bin domain.BinUsecase, barcode domain.BarcodeUsecase) {
 	httpInstance := &httpDelivery{
 		logger:    logger,
+		upload:    uploadCfg,
 		sku:       sku,
 		warehouse: warehouse,
+		bin:       bin,
 		barcode:   barcode,
 		validator: validator.New(),
 	}
 
 	// Bind with given router
 	router.HandleFunc("/barcode/upload", httpInstance.BarcodeUpload).Methods("POST")
+	router.HandleFunc("/barcode/scans", httpInstance.SelectScans).Methods("GET")
+	router.HandleFunc("/barcode/scans/{id}", httpInstance.GetScan).Methods("GET")
+	router.HandleFunc("/barcode/scans/{id}/image", httpInstance.GetScanImage).Methods("GET")
+	router.HandleFunc("/bin/{id}/audit", httpInstance.BinAudit).Methods("POST")
 }
 
 func (h *httpDelivery) BarcodeUpload(w http.ResponseWriter, r *http.Request) {
-	// Read File
-	file, _, err := r.FormFile("barcode_image")
+	uploaded, err := upload.SaveImage(w, r, "barcode_image", h.upload)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Read Barcode Image")
+		httpcommon.ResponseJSONError(w, upload.StatusCode(err), upload.Message(err))
 		return
 	}
+	defer uploaded.Close()
 
-	// Open File temporarily
-	tempFile, err := ioutil.TempFile("/tmp", "uploadedfile")
+	// Optionally answer with the uploaded picture, boxes drawn, instead of JSON
+	annotate := r.FormValue("annotate")
+	if len(annotate) > 0 {
+		if _, err := imaging.ParseFormat(annotate); err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Annotate Format, Use png or jpeg")
+			return
+		}
+		if !uploaded.Type.Decodable() {
+			httpcommon.ResponseJSONError(w, http.StatusUnsupportedMediaType, "Image Type cannot be decoded to annotate")
+			return
+		}
+	}
+
+	meta, err := scanMetadata(r)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Open Temporary Image")
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Warehouse ID Must be a number")
 		return
 	}
-	defer tempFile.Close()
-	defer os.Remove(tempFile.Name())
 
-	io.Copy(tempFile, file)
+	// Scans without a warehouse need a grant covering every warehouse
+	if err := domain.Authorize(r.Context(), domain.PermScanCreate, meta.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
 
-	resp, err := h.barcode.ParseBarcodeFromFileToLambda(tempFile)
+	resp, err := h.barcode.ParseBarcodeFromFileToLambda(r.Context(), uploaded.File, meta)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Process Temporary Image")
 		return
 	}
 
+	if len(annotate) > 0 {
+		annotated, err := h.barcode.AnnotateImage(r.Context(), uploaded.File, resp, annotate)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Render Annotated Image")
+			return
+		}
+
+		httpcommon.ResponseBytes(w, http.StatusOK, annotated.ContentType, annotated.Data)
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, resp)
+}
+
+func (h *httpDelivery) BinAudit(w http.ResponseWriter, r *http.Request) {
+	var (
+		binID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		binID = id
+	}
+
+	binData, err := h.bin.Get(r.Context(), binID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Audit Bin, Make sure you find correct Bin")
+		return
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermScanCreate, binData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	uploaded, err := upload.SaveImage(w, r, "barcode_image", h.upload)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, upload.StatusCode(err), upload.Message(err))
+		return
+	}
+	defer uploaded.Close()
+
+	meta, err := scanMetadata(r)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Warehouse ID Must be a number")
+		return
+	}
+
+	resp, err := h.barcode.AuditBinFromFile(r.Context(), binID, uploaded.File, meta)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Audit Bin, Make sure you find correct Bin")
+		return
+	}
+
 	httpcommon.ResponseJSON(w, http.StatusOK, resp)
 }
+
+func (h *httpDelivery) GetScan(w http.ResponseWriter, r *http.Request) {
+	var (
+		scanID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		scanID = id
+	}
+
+	response, err := h.barcode.GetScan(r.Context(), scanID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Scan, Make sure you find correct Scan")
+		return
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermScanRead, response.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) SelectScans(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.BarcodeScanQueryParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list scans of the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermScanRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.BarcodeScanResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.barcode.SelectScans(r.Context(), queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Scans")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) GetScanImage(w http.ResponseWriter, r *http.Request) {
+	var (
+		scanID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		scanID = id
+	}
+
+	scanData, err := h.barcode.GetScan(r.Context(), scanID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusNotFound, "Scan Image Not Available")
+		return
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermScanRead, scanData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	image, err := h.barcode.GetScanImage(r.Context(), scanID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusNotFound, "Scan Image Not Available")
+		return
+	}
+
+	httpcommon.ResponseBytes(w, http.StatusOK, image.ContentType, image.Data)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
+
+// scanMetadata identifies the requester, handhelds send their user and device.
+// The authenticated principal wins over what the headers claim.
+func scanMetadata(r *http.Request) (domain.BarcodeScanMetadata, error) {
+	meta := domain.BarcodeScanMetadata{
+		UserID:   r.Header.Get("X-User-ID"),
+		DeviceID: r.Header.Get("X-Device-ID"),
+	}
+
+	if principal, ok := domain.PrincipalFromContext(r.Context()); ok {
+		switch principal.Type {
+		case domain.PrincipalTypeUser:
+			meta.UserID = principal.ID
+		case domain.PrincipalTypeDevice:
+			meta.DeviceID = principal.ID
+		}
+	}
+
+	if whID := r.FormValue("warehouse_id"); len(whID) > 0 {
+		i, err := strconv.ParseInt(whID, 10, 64)
+		if err != nil {
+			return meta, err
+		}
+		meta.WarehouseID = i
+	}
+
+	return meta, nil
+}
@@ -25,6 +25,10 @@ func New(logger *logrus.Logger, cfg domain.BarcodeRepositoryConfig, httpClient *
 	}
 }
 
+func (b *barcodeRepository) Decoder() string {
+	return "lambda"
+}
+
 func (b *barcodeRepository) ParseToLambda(file64 string) (domain.BarcodeLambdaResponse, error) {
 	var (
 		lambdaResponse domain.BarcodeLambdaResponse
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/imaging"
+
+	// Register decoders for the formats we accept on upload
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	statusColors = map[string]color.RGBA{
+		domain.BarcodeStatusFound:         {R: 0x2e, G: 0xb8, B: 0x4b, A: 0xff},
+		domain.BarcodeStatusNotFound:      {R: 0xe5, G: 0x39, B: 0x35, A: 0xff},
+		domain.BarcodeStatusError:         {R: 0x8e, G: 0x24, B: 0xaa, A: 0xff},
+		domain.BarcodeStatusLowConfidence: {R: 0xff, G: 0xb3, B: 0x00, A: 0xff},
+	}
+	labelForeground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func (b *barcodeUsecase) AnnotateImage(ctx context.Context, file *os.File, barcodes []domain.WarehouseBarcode, format string) (domain.BarcodeAnnotatedImage, error) {
+	var (
+		annotated domain.BarcodeAnnotatedImage
+	)
+
+	outputFormat, err := imaging.ParseFormat(format)
+	if err != nil {
+		return annotated, err
+	}
+
+	readerFile, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return annotated, err
+	}
+
+	// Decode the same way detection did so the geometry lines up
+	source, err := b.loadImage(readerFile)
+	if err != nil {
+		return annotated, err
+	}
+
+	canvas := imaging.ToRGBA(source)
+	bounds := canvas.Bounds()
+
+	// Scale strokes and text with the photo so they stay readable on big images
+	shortSide := bounds.Dx()
+	if bounds.Dy() < shortSide {
+		shortSide = bounds.Dy()
+	}
+	thickness := shortSide / 300
+	if thickness < 2 {
+		thickness = 2
+	}
+	labelScale := shortSide / 500
+	if labelScale < 1 {
+		labelScale = 1
+	}
+
+	for _, barcode := range barcodes {
+		c, ok := statusColors[barcode.Status]
+		if !ok {
+			c = statusColors[domain.BarcodeStatusError]
+		}
+
+		box := barcode.Geometry.BoundingBox
+		rect := image.Rect(
+			int(box.Left*float64(bounds.Dx())),
+			int(box.Top*float64(bounds.Dy())),
+			int((box.Left+box.Width)*float64(bounds.Dx())),
+			int((box.Top+box.Height)*float64(bounds.Dy())),
+		)
+
+		// Prefer the polygon as it follows rotated labels, fall back to the box
+		if len(barcode.Geometry.Polygon) > 2 {
+			points := make([]image.Point, 0, len(barcode.Geometry.Polygon))
+			for _, p := range barcode.Geometry.Polygon {
+				points = append(points, image.Pt(int(p.X*float64(bounds.Dx())), int(p.Y*float64(bounds.Dy()))))
+			}
+			imaging.DrawPolygon(canvas, points, c, thickness)
+		} else {
+			imaging.DrawRect(canvas, rect, c, thickness)
+		}
+
+		labelHeight := 17 * labelScale
+		imaging.DrawLabel(canvas, image.Pt(rect.Min.X, rect.Min.Y-labelHeight), annotationText(barcode), labelForeground, c, labelScale)
+	}
+
+	data, err := imaging.EncodeToBytes(canvas, outputFormat)
+	if err != nil {
+		return annotated, err
+	}
+
+	annotated.Data = data
+	annotated.ContentType = outputFormat.ContentType()
+	return annotated, nil
+}
+
+func annotationText(barcode domain.WarehouseBarcode) string {
+	switch {
+	case barcode.Status == domain.BarcodeStatusNotFound:
+		return barcode.SKU + " (not found)"
+	case barcode.Status == domain.BarcodeStatusError:
+		return barcode.SKU + " (error)"
+	case barcode.BinCode != "":
+		return barcode.SKU + " @ " + barcode.BinCode
+	}
+
+	return barcode.SKU
+}
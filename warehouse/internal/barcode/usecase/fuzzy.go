@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/ocrmatch"
+)
+
+const (
+	defaultFuzzyMaxDistance   = 2
+	defaultFuzzyMaxCandidates = 3
+)
+
+// warehouseSKUs loads the SKUs of a warehouse once per scan, a single photo
+// may hold several unmatched detections.
+type warehouseSKUs struct {
+	loaded bool
+	skus   []domain.SKU
+	codes  []string
+}
+
+func (b *barcodeUsecase) fuzzyEnabled(meta domain.BarcodeScanMetadata) bool {
+	return b.config.Fuzzy.Enabled && meta.WarehouseID > 0
+}
+
+// fuzzyCandidates proposes the SKUs of the warehouse closest to the text,
+// it only suggests and leaves the detection not found.
+func (b *barcodeUsecase) fuzzyCandidates(ctx context.Context, text string, warehouseID int64, cache *warehouseSKUs) ([]domain.BarcodeCandidate, error) {
+	var (
+		candidates    []domain.BarcodeCandidate
+		maxDistance   = b.config.Fuzzy.MaxDistance
+		maxCandidates = b.config.Fuzzy.MaxCandidates
+	)
+
+	if maxDistance <= 0 {
+		maxDistance = defaultFuzzyMaxDistance
+	}
+	if maxCandidates < 1 {
+		maxCandidates = defaultFuzzyMaxCandidates
+	}
+
+	if !cache.loaded {
+		skusData, err := b.selectSKUsByWarehouse(ctx, warehouseID)
+		if err != nil {
+			return candidates, err
+		}
+
+		cache.loaded = true
+		cache.skus = skusData
+		for _, sku := range skusData {
+			cache.codes = append(cache.codes, sku.SKU)
+		}
+	}
+
+	for _, match := range ocrmatch.Closest(text, cache.codes, maxDistance, maxCandidates) {
+		for _, sku := range cache.skus {
+			if sku.SKU != match.Code {
+				continue
+			}
+
+			candidates = append(candidates, domain.BarcodeCandidate{
+				SKU:      sku.SKU,
+				Name:     sku.Name,
+				BinCode:  sku.BinCode,
+				Distance: match.Distance,
+				Score:    match.Score,
+			})
+			break
+		}
+	}
+
+	return candidates, nil
+}
+
+// selectSKUsByWarehouse loads the SKUs assigned to the bins of the warehouse
+// in one query, SKUs name their warehouse and bin rather than their IDs
+func (b *barcodeUsecase) selectSKUsByWarehouse(ctx context.Context, warehouseID int64) ([]domain.SKU, error) {
+	var (
+		skusData []domain.SKU
+		binCodes []string
+	)
+
+	warehouseData, err := b.warehouse.Get(ctx, warehouseID)
+	if err != nil {
+		return skusData, err
+	}
+
+	binsData, err := b.bin.GetByWarehouseID(ctx, warehouseID)
+	if err != nil || len(binsData) < 1 {
+		return skusData, err
+	}
+
+	for _, bin := range binsData {
+		binCodes = append(binCodes, bin.Name)
+	}
+
+	return b.selectSKUs(ctx, domain.SKUQueryParameter{
+		WHCode:  []string{warehouseData.Name},
+		BinCode: binCodes,
+	})
+}
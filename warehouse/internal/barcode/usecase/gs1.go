@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+const (
+	expiryLayout = "2006-01-02"
+)
+
+// gtinCandidates returns the GTIN-14 together with the shorter GTIN-13,
+// GTIN-12 and GTIN-8 it was padded from, SKUs may be stored as any of them.
+func gtinCandidates(gtin string) []string {
+	var candidates []string
+
+	if len(gtin) < 1 {
+		return candidates
+	}
+
+	candidates = append(candidates, gtin)
+	for _, length := range []int{13, 12, 8} {
+		padding := len(gtin) - length
+		if padding > 0 && strings.Trim(gtin[:padding], "0") == "" {
+			candidates = append(candidates, gtin[padding:])
+		}
+	}
+
+	return candidates
+}
+
+// withGS1 copies the GS1 elements of a scanned code onto the response
+func withGS1(whBarcode domain.WarehouseBarcode, code scannedCode) domain.WarehouseBarcode {
+	if code.gs1 == nil {
+		return whBarcode
+	}
+
+	whBarcode.GTIN = code.gs1.GTIN()
+	whBarcode.Lot = code.gs1.Lot()
+	whBarcode.Serial = code.gs1.Serial()
+	if expiry, ok := code.gs1.Expiry(); ok {
+		whBarcode.Expiry = expiry.Format(expiryLayout)
+	}
+
+	for _, e := range code.gs1.Elements {
+		whBarcode.ApplicationIdentifiers = append(whBarcode.ApplicationIdentifiers, domain.BarcodeApplicationIdentifier{
+			AI:    e.AI,
+			Title: e.Title,
+			Value: e.Value,
+		})
+	}
+
+	return whBarcode
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"sort"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/imaging"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/upload"
+)
+
+const (
+	// Two detections of the same text covering this much of the smaller box
+	// are treated as one label seen from two overlapping tiles
+	tileDuplicateOverlap = 0.5
+)
+
+func (b *barcodeUsecase) detectBarcodes(readerFile []byte) (domain.BarcodeLambdaResponse, error) {
+	var (
+		lambdaResponse domain.BarcodeLambdaResponse
+		config         = b.config.Preprocess
+	)
+
+	// Types without a decoder here, such as HEIC, go to the Lambda untouched
+	imageType, err := upload.DetectImageType(readerFile)
+	if !config.Enabled || (err == nil && !imageType.Decodable()) {
+		return b.barcode.ParseToLambda(base64.StdEncoding.EncodeToString(readerFile))
+	}
+
+	img, err := b.loadImage(readerFile)
+	if err != nil {
+		// Formats we cannot decode are still worth a try on the Lambda side
+		b.logger.Warnln("skipping barcode preprocessing:", err)
+		return b.barcode.ParseToLambda(base64.StdEncoding.EncodeToString(readerFile))
+	}
+
+	bounds := img.Bounds()
+	tiles := []image.Rectangle{bounds}
+	if config.Tiling.Enabled && longestSide(bounds) > config.Tiling.MinDimension {
+		tiles = imaging.Tiles(bounds, config.Tiling.TileSize, config.Tiling.Overlap)
+	}
+
+	for _, tile := range tiles {
+		piece, err := b.preparePiece(imaging.Crop(img, tile))
+		if err != nil {
+			return lambdaResponse, err
+		}
+
+		tileResponse, err := b.barcode.ParseToLambda(base64.StdEncoding.EncodeToString(piece))
+		if err != nil {
+			return lambdaResponse, err
+		}
+
+		for _, barcode := range tileResponse.Data {
+			lambdaResponse.Data = append(lambdaResponse.Data, remapToImage(barcode, tile, bounds))
+		}
+	}
+
+	if len(tiles) > 1 {
+		lambdaResponse.Data = mergeTileDuplicates(lambdaResponse.Data)
+	}
+
+	return lambdaResponse, nil
+}
+
+// loadImage decodes an upload, turning it upright when auto orientation is on
+func (b *barcodeUsecase) loadImage(data []byte) (image.Image, error) {
+	img, _, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if b.config.Preprocess.Enabled && b.config.Preprocess.AutoOrient {
+		img = imaging.ApplyOrientation(img, imaging.ReadOrientation(data))
+	}
+
+	return img, nil
+}
+
+func (b *barcodeUsecase) preparePiece(img image.Image) ([]byte, error) {
+	config := b.config.Preprocess
+
+	img = imaging.Resize(img, config.MaxDimension)
+
+	if config.Grayscale || config.NormalizeContrast {
+		gray := imaging.Grayscale(img)
+		if config.NormalizeContrast {
+			gray = imaging.NormalizeContrast(gray)
+		}
+		img = gray
+	}
+
+	return imaging.EncodeJPEG(img, config.JPEGQuality)
+}
+
+// remapToImage converts geometry relative to a tile into geometry relative to
+// the whole image, detections are always reported in image ratios.
+func remapToImage(barcode domain.BarcodeLambda, tile, bounds image.Rectangle) domain.BarcodeLambda {
+	var (
+		imageWidth  = float64(bounds.Dx())
+		imageHeight = float64(bounds.Dy())
+		tileWidth   = float64(tile.Dx())
+		tileHeight  = float64(tile.Dy())
+		offsetX     = float64(tile.Min.X - bounds.Min.X)
+		offsetY     = float64(tile.Min.Y - bounds.Min.Y)
+	)
+
+	if tile == bounds {
+		return barcode
+	}
+
+	box := barcode.Geometry.BoundingBox
+	barcode.Geometry.BoundingBox = domain.BarcodeGeometryBoundingBox{
+		Left:   (offsetX + box.Left*tileWidth) / imageWidth,
+		Top:    (offsetY + box.Top*tileHeight) / imageHeight,
+		Width:  box.Width * tileWidth / imageWidth,
+		Height: box.Height * tileHeight / imageHeight,
+	}
+
+	polygon := make([]domain.BarcodeGeometryPolygon, 0, len(barcode.Geometry.Polygon))
+	for _, p := range barcode.Geometry.Polygon {
+		polygon = append(polygon, domain.BarcodeGeometryPolygon{
+			X: (offsetX + p.X*tileWidth) / imageWidth,
+			Y: (offsetY + p.Y*tileHeight) / imageHeight,
+		})
+	}
+	barcode.Geometry.Polygon = polygon
+
+	return barcode
+}
+
+// mergeTileDuplicates drops labels reported twice because they sit inside an
+// overlap, keeping the most confident reading.
+func mergeTileDuplicates(barcodes []domain.BarcodeLambda) []domain.BarcodeLambda {
+	var (
+		merged []domain.BarcodeLambda
+	)
+
+	sort.SliceStable(barcodes, func(i, j int) bool {
+		return barcodes[i].Confidence > barcodes[j].Confidence
+	})
+
+	for _, barcode := range barcodes {
+		duplicate := false
+		for _, kept := range merged {
+			if kept.DetectedText == barcode.DetectedText && boxOverlap(kept.Geometry.BoundingBox, barcode.Geometry.BoundingBox) > tileDuplicateOverlap {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			merged = append(merged, barcode)
+		}
+	}
+
+	return merged
+}
+
+// boxOverlap returns the intersection area as a fraction of the smaller box
+func boxOverlap(a, b domain.BarcodeGeometryBoundingBox) float64 {
+	left := maxFloat(a.Left, b.Left)
+	top := maxFloat(a.Top, b.Top)
+	right := minFloat(a.Left+a.Width, b.Left+b.Width)
+	bottom := minFloat(a.Top+a.Height, b.Top+b.Height)
+
+	if right <= left || bottom <= top {
+		return 0
+	}
+
+	smaller := minFloat(a.Width*a.Height, b.Width*b.Height)
+	if smaller <= 0 {
+		return 0
+	}
+
+	return (right - left) * (bottom - top) / smaller
+}
+
+func longestSide(r image.Rectangle) int {
+	if r.Dx() > r.Dy() {
+		return r.Dx()
+	}
+	return r.Dy()
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
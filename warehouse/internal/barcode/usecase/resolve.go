@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/gs1"
+)
+
+// scannedCode is what a detected barcode is looked up by, GS1 barcodes are
+// looked up by their GTIN instead of the whole element string.
+type scannedCode struct {
+	codes []string
+	gs1   *gs1.Data
+	// Serial number of the unit, when the barcode may carry one
+	serial string
+}
+
+// skuMatch is a resolved SKU together with how many eaches the scanned
+// barcode stands for, a case barcode counts for the whole case.
+type skuMatch struct {
+	sku       domain.SKU
+	packLevel string
+	quantity  int64
+	serial    *domain.SerialNumber
+}
+
+var (
+	// Serial number labels such as "S/N: 4HX8812" or "SERIAL NO. 4HX8812"
+	serialLabels = []*regexp.Regexp{
+		regexp.MustCompile(`^(?i)(?:S/N|SN|SERIAL(?:\s*NO\.?)?)\s*[:#]\s*(\S+)$`),
+		regexp.MustCompile(`^(?i)(?:S/N|SERIAL(?:\s*NO\.?)?)\s+(\S+)$`),
+	}
+)
+
+func parseScannedCode(barcode domain.BarcodeLambda) (scannedCode, error) {
+	for _, label := range serialLabels {
+		if m := label.FindStringSubmatch(barcode.DetectedText); m != nil {
+			return scannedCode{serial: m[1]}, nil
+		}
+	}
+
+	if !gs1.IsGS1(barcode.Type, barcode.DetectedText) {
+		// Serial numbers are often printed without a label, they are tried last
+		return scannedCode{
+			codes:  []string{barcode.DetectedText},
+			serial: barcode.DetectedText,
+		}, nil
+	}
+
+	data, err := gs1.Parse(barcode.DetectedText)
+	if err != nil {
+		return scannedCode{}, err
+	}
+
+	return scannedCode{
+		codes:  gtinCandidates(data.GTIN()),
+		gs1:    &data,
+		serial: data.Serial(),
+	}, nil
+}
+
+// resolveSKU looks the code up in skus first, then in the barcode aliases and
+// finally in the serial numbers, it returns nil when none of them knows it.
+func (b *barcodeUsecase) resolveSKU(ctx context.Context, code scannedCode) (*skuMatch, error) {
+	match, err := b.resolveByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if match == nil {
+		return b.resolveBySerial(ctx, code.serial)
+	}
+
+	// A GS1 label on a serialized SKU identifies a single unit
+	if code.gs1 != nil && match.sku.Serialized && len(code.serial) > 0 {
+		serialsFound, err := b.serial.Select(domain.SerialNumberQueryParameter{
+			SKUID:  []int64{match.sku.ID},
+			Serial: []string{code.serial},
+			PaginationQuery: domain.PaginationQuery{
+				Limit: 1,
+				Page:  1,
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(serialsFound) > 0 {
+			match.serial = &serialsFound[0]
+		}
+	}
+
+	return match, nil
+}
+
+func (b *barcodeUsecase) resolveByCode(ctx context.Context, code scannedCode) (*skuMatch, error) {
+	if len(code.codes) < 1 {
+		return nil, nil
+	}
+
+	skuFound, err := b.sku.Select(ctx, domain.SKUQueryParameter{
+		SKU: code.codes,
+		PaginationQuery: domain.PaginationQuery{
+			Limit: 1,
+			Page:  1,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(skuFound) > 0 {
+		return code.withCount(&skuMatch{
+			sku:       skuFound[0],
+			packLevel: domain.PackLevelEach,
+			quantity:  1,
+		}), nil
+	}
+
+	aliasFound, err := b.skuBarcode.Select(domain.SKUBarcodeQueryParameter{
+		Barcode: code.codes,
+		PaginationQuery: domain.PaginationQuery{
+			Limit: 1,
+			Page:  1,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(aliasFound) < 1 {
+		return nil, nil
+	}
+
+	skuData, err := b.sku.Get(ctx, aliasFound[0].SKUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.withCount(&skuMatch{
+		sku:       skuData,
+		packLevel: aliasFound[0].PackLevel,
+		quantity:  aliasFound[0].Quantity,
+	}), nil
+}
+
+// resolveBySerial finds the unit by its serial alone, a serial shared by
+// units of different SKUs is ambiguous and left unresolved.
+func (b *barcodeUsecase) resolveBySerial(ctx context.Context, serial string) (*skuMatch, error) {
+	if len(serial) < 1 {
+		return nil, nil
+	}
+
+	serialsFound, err := b.serial.Select(domain.SerialNumberQueryParameter{
+		Serial: []string{serial},
+		PaginationQuery: domain.PaginationQuery{
+			Limit: 2,
+			Page:  1,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serialsFound) != 1 {
+		return nil, nil
+	}
+
+	skuData, err := b.sku.Get(ctx, serialsFound[0].SKUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &skuMatch{
+		sku:       skuData,
+		packLevel: domain.PackLevelEach,
+		quantity:  1,
+		serial:    &serialsFound[0],
+	}, nil
+}
+
+// serialTrail returns the unit with every status it went through
+func (b *barcodeUsecase) serialTrail(serial domain.SerialNumber) (*domain.SerialNumberResponse, error) {
+	eventsData, err := b.serial.History(serial.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	serialResponse := serial.SerialNumberResponse()
+	for _, event := range eventsData {
+		serialResponse.History = append(serialResponse.History, event.SerialNumberEventResponse())
+	}
+
+	return &serialResponse, nil
+}
+
+// withCount multiplies the quantity by the GS1 count of trade items, if any
+func (code scannedCode) withCount(match *skuMatch) *skuMatch {
+	if code.gs1 == nil {
+		return match
+	}
+
+	if v, ok := code.gs1.Get(gs1.AICount); ok {
+		if count, err := strconv.ParseInt(v, 10, 64); err == nil && count > 0 {
+			match.quantity *= count
+		}
+	}
+
+	return match
+}
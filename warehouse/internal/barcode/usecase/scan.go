@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/blobstore"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/upload"
+)
+
+var (
+	ErrScanImageNotStored = errors.New("scan image was not stored")
+)
+
+// recordScan keeps a trail of every upload and publishes barcode.scanned,
+// failing to store it must never fail the scan itself so errors are only
+// logged.
+func (b *barcodeUsecase) recordScan(ctx context.Context, image []byte, meta domain.BarcodeScanMetadata, detections domain.BarcodeLambdaResponse, results []domain.WarehouseBarcode, scanErr error) {
+	sum := sha256.Sum256(image)
+	hash := hex.EncodeToString(sum[:])
+
+	data := domain.BarcodeScanDataParameter{
+		BarcodeScanMetadata: meta,
+		ImageHash:           hash,
+		Decoder:             b.barcode.Decoder(),
+		Detections:          detections.Data,
+		Results:             results,
+	}
+	if data.Detections == nil {
+		data.Detections = []domain.BarcodeLambda{}
+	}
+	if scanErr != nil {
+		data.Error = scanErr.Error()
+	}
+
+	// Images are content addressed, the same photo uploaded twice is kept once
+	if b.images != nil {
+		key := "scans/" + hash[:2] + "/" + hash
+		if err := b.images.Put(key, image); err != nil {
+			b.logger.Errorln(err)
+		} else {
+			data.ImageKey = key
+		}
+	}
+
+	err := b.tx.WithinTx(ctx, func(ctx context.Context) error {
+		scanData, err := b.scan.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		return b.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBarcodeScanned, scanData.ID, scanData.WarehouseID, scanData.BarcodeScanResponse()))
+	})
+	if err != nil {
+		b.logger.Errorln(err)
+	}
+}
+
+func (b *barcodeUsecase) GetScan(ctx context.Context, scanID int64) (domain.BarcodeScanResponse, error) {
+	var (
+		scanResponse domain.BarcodeScanResponse
+	)
+
+	scanData, err := b.scan.Get(ctx, scanID)
+	if err != nil {
+		return scanResponse, err
+	}
+
+	scanResponse = scanData.BarcodeScanResponse()
+	return scanResponse, nil
+}
+
+func (b *barcodeUsecase) SelectScans(ctx context.Context, params domain.BarcodeScanQueryParameter) ([]domain.BarcodeScanResponse, error) {
+	var (
+		scanResponses = []domain.BarcodeScanResponse{}
+	)
+
+	scansData, err := b.scan.Select(ctx, params)
+	if err != nil {
+		return scanResponses, err
+	}
+
+	for _, scan := range scansData {
+		scanResponses = append(scanResponses, scan.BarcodeScanResponse())
+	}
+
+	return scanResponses, nil
+}
+
+func (b *barcodeUsecase) GetScanImage(ctx context.Context, scanID int64) (domain.BarcodeScanImage, error) {
+	var (
+		scanImage domain.BarcodeScanImage
+	)
+
+	scanData, err := b.scan.Get(ctx, scanID)
+	if err != nil {
+		return scanImage, err
+	}
+
+	if len(scanData.ImageKey) < 1 || b.images == nil {
+		return scanImage, ErrScanImageNotStored
+	}
+
+	data, err := b.images.Get(scanData.ImageKey)
+	if err != nil {
+		if err == blobstore.ErrNotFound {
+			return scanImage, ErrScanImageNotStored
+		}
+		return scanImage, err
+	}
+
+	scanImage.Data = data
+	scanImage.ContentType = "application/octet-stream"
+	if imageType, err := upload.DetectImageType(data); err == nil {
+		scanImage.ContentType = imageType.ContentType()
+	}
+
+	return scanImage, nil
+}
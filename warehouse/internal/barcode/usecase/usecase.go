@@ -1,26 +1,39 @@
 package usecase
 
 import (
-	"encoding/base64"
+	"context"
 	"io/ioutil"
 	"os"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/blobstore"
 	"github.com/sirupsen/logrus"
 )
 
 type barcodeUsecase struct {
-	logger    *logrus.Logger
-	barcode   domain.BarcodeRepository
-	warehouse domain.WarehouseRepository
-	sku       domain.SKURepository
+	logger     *logrus.Logger
+	config     domain.BarcodeUsecaseConfig
+	barcode    domain.BarcodeRepository
+	warehouse  domain.WarehouseRepository
+	sku        domain.SKURepository
+	bin        domain.BinRepository
+	scan       domain.BarcodeScanRepository
+	skuBarcode domain.SKUBarcodeRepository
+	serial     domain.SerialNumberRepository
+	images     blobstore.Store
+	tx         domain.Transactor
+	outbox     domain.OutboxRepository
 }
 
+const (
+	auditPageSize = 100
+)
+
 var (
 	zoneMap = make(map[string]string)
 )
 
-func NewUsecase(logger *logrus.Logger, barcode domain.BarcodeRepository, warehouse domain.WarehouseRepository, sku domain.SKURepository) domain.BarcodeUsecase {
+func NewUsecase(logger *logrus.Logger, cfg domain.BarcodeUsecaseConfig, barcode domain.BarcodeRepository, warehouse domain.WarehouseRepository, sku domain.SKURepository, bin domain.BinRepository, scan domain.BarcodeScanRepository, skuBarcode domain.SKUBarcodeRepository, serial domain.SerialNumberRepository, images blobstore.Store, tx domain.Transactor, outbox domain.OutboxRepository) domain.BarcodeUsecase {
 	zoneMap = map[string]string{
 		"1":  "https://ocr-zone.s3.amazonaws.com/warehouse/Floor_Plan+1.jpg",
 		"2":  "https://ocr-zone.s3.amazonaws.com/warehouse/Floor_Plan+2.jpg",
@@ -35,70 +48,285 @@ func NewUsecase(logger *logrus.Logger, barcode domain.BarcodeRepository, warehou
 	}
 
 	return &barcodeUsecase{
-		logger:    logger,
-		barcode:   barcode,
-		warehouse: warehouse,
-		sku:       sku,
+		logger:     logger,
+		config:     cfg,
+		barcode:    barcode,
+		warehouse:  warehouse,
+		sku:        sku,
+		bin:        bin,
+		scan:       scan,
+		skuBarcode: skuBarcode,
+		serial:     serial,
+		images:     images,
+		tx:         tx,
+		outbox:     outbox,
 	}
 }
 
-func (b *barcodeUsecase) ParseBarcodeFromFileToLambda(file *os.File) ([]domain.WarehouseBarcode, error) {
+func (b *barcodeUsecase) ParseBarcodeFromFileToLambda(ctx context.Context, file *os.File, meta domain.BarcodeScanMetadata) ([]domain.WarehouseBarcode, error) {
 	var (
 		whBarcode = []domain.WarehouseBarcode{}
+		fuzzySKUs warehouseSKUs
 	)
 
-	// Encode to base64
 	readerFile, err := ioutil.ReadFile(file.Name())
 	if err != nil {
 		return whBarcode, err
 	}
-	encodedFile := base64.StdEncoding.EncodeToString(readerFile)
 
-	// Fetch data from Lambda
-	barcodes, err := b.barcode.ParseToLambda(encodedFile)
+	barcodes, err := b.detectBarcodes(readerFile)
 	if err != nil {
+		b.recordScan(ctx, readerFile, meta, barcodes, whBarcode, err)
 		return whBarcode, err
 	}
 
 	// Iterate each Barcodes, and find the correct barcode
 	for _, barcode := range barcodes.Data {
-		skuFound, err := b.sku.Select(domain.SKUQueryParameter{
-			SKU: []string{barcode.DetectedText},
-			PaginationQuery: domain.PaginationQuery{
-				Limit: 1,
-				Page:  1,
-			},
-		})
+		code, err := parseScannedCode(barcode)
 		if err != nil {
 			whBarcode = append(whBarcode, domain.WarehouseBarcode{
-				SKU:      barcode.DetectedText,
-				Geometry: barcode.Geometry,
-				Error:    "An error occured when trying to find SKU " + barcode.DetectedText,
+				SKU:        barcode.DetectedText,
+				Geometry:   barcode.Geometry,
+				Confidence: barcode.Confidence,
+				Status:     domain.BarcodeStatusError,
+				Error:      "Cannot read GS1 data " + barcode.DetectedText + ": " + err.Error(),
 			})
 			continue
 		}
 
-		if len(skuFound) < 1 {
-			whBarcode = append(whBarcode, domain.WarehouseBarcode{
-				SKU:      barcode.DetectedText,
-				Geometry: barcode.Geometry,
-				Error:    barcode.DetectedText + " not found",
-			})
+		match, err := b.resolveSKU(ctx, code)
+		if err != nil {
+			whBarcode = append(whBarcode, withGS1(domain.WarehouseBarcode{
+				SKU:        barcode.DetectedText,
+				Geometry:   barcode.Geometry,
+				Confidence: barcode.Confidence,
+				Status:     domain.BarcodeStatusError,
+				Error:      "An error occured when trying to find SKU " + barcode.DetectedText,
+			}, code))
+			continue
+		}
+
+		if match == nil {
+			notFound := domain.WarehouseBarcode{
+				SKU:        barcode.DetectedText,
+				Geometry:   barcode.Geometry,
+				Confidence: barcode.Confidence,
+				Status:     b.statusFor(barcode, domain.BarcodeStatusNotFound),
+				Error:      barcode.DetectedText + " not found",
+			}
+
+			// GS1 data and serial labels are structured, a miss there is not an OCR mistake
+			if code.gs1 == nil && len(code.codes) > 0 && b.fuzzyEnabled(meta) {
+				candidates, err := b.fuzzyCandidates(ctx, barcode.DetectedText, meta.WarehouseID, &fuzzySKUs)
+				if err != nil {
+					b.logger.Errorln(err)
+				}
+				notFound.Candidates = candidates
+			}
+
+			whBarcode = append(whBarcode, withGS1(notFound, code))
 			continue
 		}
 
 		tempZoneMap := ""
-		if v, ok := zoneMap[skuFound[0].ZoneID]; ok {
+		if v, ok := zoneMap[match.sku.ZoneID]; ok {
 			tempZoneMap = v
 		}
 
-		whBarcode = append(whBarcode, domain.WarehouseBarcode{
-			SKU:      skuFound[0].SKU,
-			Geometry: barcode.Geometry,
-			BinCode:  skuFound[0].BinCode,
-			Zone:     tempZoneMap,
-		})
+		found := domain.WarehouseBarcode{
+			SKU:        match.sku.SKU,
+			Geometry:   barcode.Geometry,
+			Confidence: barcode.Confidence,
+			Status:     b.statusFor(barcode, domain.BarcodeStatusFound),
+			BinCode:    match.sku.BinCode,
+			Zone:       tempZoneMap,
+			PackLevel:  match.packLevel,
+			Quantity:   match.quantity,
+		}
+
+		if match.serial != nil {
+			trail, err := b.serialTrail(*match.serial)
+			if err != nil {
+				b.logger.Errorln(err)
+			}
+			found.SerialNumber = trail
+		}
+
+		whBarcode = append(whBarcode, withGS1(found, code))
 	}
 
+	b.recordScan(ctx, readerFile, meta, barcodes, whBarcode, nil)
 	return whBarcode, nil
 }
+
+// AuditBinFromFile compares the SKUs on the photo of a bin with the ones
+// assigned to it. The photo is kept in the scan history like any other scan.
+func (b *barcodeUsecase) AuditBinFromFile(ctx context.Context, binID int64, file *os.File, meta domain.BarcodeScanMetadata) (domain.BinAuditResponse, error) {
+	var (
+		auditResponse = domain.BinAuditResponse{
+			Matched:   []domain.BinAuditItem{},
+			Misplaced: []domain.BinAuditItem{},
+			Missing:   []domain.BinAuditItem{},
+			Unknown:   []domain.BinAuditItem{},
+		}
+		whBarcode = []domain.WarehouseBarcode{}
+		detected  = make(map[string]bool)
+	)
+
+	binData, err := b.bin.Get(ctx, binID)
+	if err != nil {
+		return auditResponse, err
+	}
+	auditResponse.BinID = binData.ID
+	auditResponse.BinCode = binData.Name
+	auditResponse.WarehouseID = binData.WarehouseID
+
+	// SKUs refer to their warehouse by name, bin names repeat across warehouses
+	warehouseData, err := b.warehouse.Get(ctx, binData.WarehouseID)
+	if err != nil {
+		return auditResponse, err
+	}
+
+	// SKUs assigned to this bin are the ones we expect to see on the photo
+	expectedSKUs, err := b.selectSKUs(ctx, domain.SKUQueryParameter{
+		WHCode:  []string{warehouseData.Name},
+		BinCode: []string{binData.Name},
+	})
+	if err != nil {
+		return auditResponse, err
+	}
+
+	readerFile, err := ioutil.ReadFile(file.Name())
+	if err != nil {
+		return auditResponse, err
+	}
+
+	// The scan belongs to the audited warehouse whatever the form said
+	meta.WarehouseID = binData.WarehouseID
+
+	barcodes, err := b.detectBarcodes(readerFile)
+	if err != nil {
+		b.recordScan(ctx, readerFile, meta, barcodes, whBarcode, err)
+		return auditResponse, err
+	}
+
+	for _, barcode := range barcodes.Data {
+		geometry := barcode.Geometry
+		unknown := domain.WarehouseBarcode{
+			SKU:        barcode.DetectedText,
+			Geometry:   barcode.Geometry,
+			Confidence: barcode.Confidence,
+			Status:     b.statusFor(barcode, domain.BarcodeStatusNotFound),
+			Error:      barcode.DetectedText + " not found",
+		}
+
+		code, err := parseScannedCode(barcode)
+		if err != nil {
+			auditResponse.Unknown = append(auditResponse.Unknown, domain.BinAuditItem{
+				DetectedText: barcode.DetectedText,
+				Geometry:     &geometry,
+			})
+			unknown.Status = domain.BarcodeStatusError
+			unknown.Error = "Cannot read GS1 data " + barcode.DetectedText + ": " + err.Error()
+			whBarcode = append(whBarcode, unknown)
+			continue
+		}
+
+		match, err := b.resolveSKU(ctx, code)
+		if err != nil {
+			b.recordScan(ctx, readerFile, meta, barcodes, whBarcode, err)
+			return auditResponse, err
+		}
+
+		if match == nil {
+			auditResponse.Unknown = append(auditResponse.Unknown, domain.BinAuditItem{
+				DetectedText: barcode.DetectedText,
+				Geometry:     &geometry,
+			})
+			whBarcode = append(whBarcode, withGS1(unknown, code))
+			continue
+		}
+
+		sku := match.sku
+		detected[sku.SKU] = true
+
+		item := domain.BinAuditItem{
+			SKU:          sku.SKU,
+			Name:         sku.Name,
+			DetectedText: barcode.DetectedText,
+			Geometry:     &geometry,
+		}
+		found := domain.WarehouseBarcode{
+			SKU:        sku.SKU,
+			Geometry:   barcode.Geometry,
+			Confidence: barcode.Confidence,
+			Status:     b.statusFor(barcode, domain.BarcodeStatusFound),
+			BinCode:    sku.BinCode,
+			PackLevel:  match.packLevel,
+			Quantity:   match.quantity,
+		}
+
+		if v, ok := zoneMap[sku.ZoneID]; ok {
+			found.Zone = v
+		}
+		whBarcode = append(whBarcode, withGS1(found, code))
+
+		if sku.WHCode == warehouseData.Name && sku.BinCode == binData.Name {
+			auditResponse.Matched = append(auditResponse.Matched, item)
+			continue
+		}
+
+		item.CorrectBin = sku.BinCode
+		item.Zone = found.Zone
+		auditResponse.Misplaced = append(auditResponse.Misplaced, item)
+	}
+
+	for _, sku := range expectedSKUs {
+		if detected[sku.SKU] {
+			continue
+		}
+
+		auditResponse.Missing = append(auditResponse.Missing, domain.BinAuditItem{
+			SKU:  sku.SKU,
+			Name: sku.Name,
+		})
+	}
+
+	b.recordScan(ctx, readerFile, meta, barcodes, whBarcode, nil)
+	return auditResponse, nil
+}
+
+// statusFor downgrades a lookup outcome to low confidence when the decoder was unsure
+func (b *barcodeUsecase) statusFor(barcode domain.BarcodeLambda, status string) string {
+	if b.config.LowConfidenceThreshold > 0 && barcode.Confidence < b.config.LowConfidenceThreshold {
+		return domain.BarcodeStatusLowConfidence
+	}
+
+	return status
+}
+
+// selectSKUs returns every SKU matching params, whatever its pagination
+func (b *barcodeUsecase) selectSKUs(ctx context.Context, params domain.SKUQueryParameter) ([]domain.SKU, error) {
+	var (
+		skusData []domain.SKU
+	)
+
+	params.Limit = auditPageSize
+	params.Page = 1
+
+	// Select is always paginated, so walk the pages until we run out
+	for {
+		page, err := b.sku.Select(ctx, params)
+		if err != nil {
+			return skusData, err
+		}
+
+		skusData = append(skusData, page...)
+		if int64(len(page)) < params.Limit {
+			break
+		}
+		params.Page++
+	}
+
+	return skusData, nil
+}
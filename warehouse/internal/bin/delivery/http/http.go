@@ -2,12 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/mergepatch"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -31,7 +33,10 @@ func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, bin domain.BinUs
 	router.HandleFunc("/bin", httpInstance.Create).Methods("POST")
 	router.HandleFunc("/bin/{id}", httpInstance.Get).Methods("GET")
 	router.HandleFunc("/bin/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/bin/{id}", httpInstance.Patch).Methods("PATCH")
 	router.HandleFunc("/bin/{id}", httpInstance.Delete).Methods("DELETE")
+	router.HandleFunc("/bin/{id}/occupancy", httpInstance.Occupancy).Methods("GET")
+	router.HandleFunc("/warehouse/{id}/utilization", httpInstance.Utilization).Methods("GET")
 }
 
 func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
@@ -52,12 +57,18 @@ func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
 		binID = id
 	}
 
-	response, err := h.bin.Get(binID)
+	response, err := h.bin.Get(r.Context(), binID)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Bin, Make sure you find correct Bin")
 		return
 	}
 
+	if err := domain.Authorize(r.Context(), domain.PermBinRead, response.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusOK, response)
 }
 
@@ -72,7 +83,19 @@ func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responses, err := h.bin.Select(queryParam)
+	// Only list bins of the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermBinRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.BinResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.bin.Select(r.Context(), queryParam)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Bin")
 		return
@@ -102,12 +125,18 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.bin.Create(createData)
+	if err := domain.Authorize(r.Context(), domain.PermBinWrite, createData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.bin.Create(r.Context(), createData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Creating Bin")
+		h.responseError(w, err, "An Error Occured When Creating Bin")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -130,6 +159,17 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		binID = id
 	}
 
+	if err := h.authorizeBin(r, domain.PermBinWrite, binID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -146,12 +186,95 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.bin.Update(binID, updateData)
+	// Moving a bin needs the permission in the target warehouse too
+	if err := domain.Authorize(r.Context(), domain.PermBinWrite, updateData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.bin.Update(r.Context(), binID, version, updateData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Updating Bin")
+		h.responseError(w, err, "An Error Occured When Updating Bin")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+// Patch applies a JSON merge patch to the bin, only the fields it names
+// are validated and only the ones it changes are written
+func (h *httpDelivery) Patch(w http.ResponseWriter, r *http.Request) {
+	var (
+		binID     int64
+		patchData domain.BinDataParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		binID = id
+	}
+
+	if err := h.authorizeBin(r, domain.PermBinWrite, binID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if !mergepatch.Accepts(r.Header.Get("Content-Type")) {
+		httpcommon.ResponseJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergepatch.ContentType)
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	current, err := h.bin.Get(r.Context(), binID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	result, err := mergepatch.Merge(current.BinDataParameter(), bodyData, &patchData)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Apply Merge Patch")
+		return
+	}
+
+	if err := mergepatch.Validate(h.validator, &patchData, result.Supplied); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	// Moving a bin needs the permission in the target warehouse too
+	if err := domain.Authorize(r.Context(), domain.PermBinWrite, patchData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.bin.Patch(r.Context(), binID, version, patchData, result.Changed)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating Bin")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -173,9 +296,119 @@ func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
 		binID = id
 	}
 
-	if resp, err := h.bin.Delete(binID); err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Delete Bin")
+	if err := h.authorizeBin(r, domain.PermBinDelete, binID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.bin.Delete(r.Context(), binID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Bin")
 	} else {
 		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
 	}
 }
+
+func (h *httpDelivery) Occupancy(w http.ResponseWriter, r *http.Request) {
+	var (
+		binID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		binID = id
+	}
+
+	if err := h.authorizeBin(r, domain.PermBinRead, binID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	response, err := h.bin.Occupancy(r.Context(), binID)
+	if err != nil {
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot compute Bin occupancy, Make sure you find correct Bin")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Utilization(w http.ResponseWriter, r *http.Request) {
+	var (
+		warehouseID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		warehouseID = id
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermBinRead, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.bin.Utilization(r.Context(), warehouseID)
+	if err != nil {
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot compute Warehouse utilization, Make sure you find correct Warehouse")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+// authorizeBin checks perm in the warehouse the bin is stored in
+func (h *httpDelivery) authorizeBin(r *http.Request, perm domain.Permission, binID int64) error {
+	binData, err := h.bin.Get(r.Context(), binID)
+	if err != nil {
+		return err
+	}
+
+	return domain.Authorize(r.Context(), perm, binData.WarehouseID)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	var (
+		conflict *domain.ConflictError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.As(err, &conflict):
+		httpcommon.ResponseJSONErrorDetail(w, http.StatusConflict, conflict.Error(), conflict.ConflictResponse())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
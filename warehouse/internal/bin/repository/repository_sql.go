@@ -1,23 +1,36 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqlerr"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
 )
 
-func (wr *binRepository) Get(binID int64) (domain.Bin, error) {
+func (wr *binRepository) Get(ctx context.Context, binID int64) (domain.Bin, error) {
 	var (
 		binData domain.Bin
 	)
 
 	query, args, err := squirrel.Select(
 		"id",
-		"wh_code",
+		"warehouse_id",
 		"name",
 		"latitude",
 		"longitude",
+		"zone_id",
+		"type",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+		"max_weight_kg",
+		"max_volume_m3",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("bins").Where(
@@ -29,7 +42,7 @@ func (wr *binRepository) Get(binID int64) (domain.Bin, error) {
 	}
 
 	query = wr.sql.Rebind(query)
-	row := wr.sql.QueryRow(query, args...)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
 	if err := row.Err(); err != nil {
 		return binData, err
 	}
@@ -40,6 +53,14 @@ func (wr *binRepository) Get(binID int64) (domain.Bin, error) {
 		&binData.Name,
 		&binData.Latitude,
 		&binData.Longitude,
+		&binData.ZoneID,
+		&binData.Type,
+		&binData.LengthMM,
+		&binData.WidthMM,
+		&binData.HeightMM,
+		&binData.MaxWeightKg,
+		&binData.MaxVolumeM3,
+		&binData.Version,
 		&binData.CreatedAt,
 		&binData.UpdatedAt,
 	)
@@ -50,17 +71,25 @@ func (wr *binRepository) Get(binID int64) (domain.Bin, error) {
 	return binData, nil
 }
 
-func (wr *binRepository) GetByWarehouseID(warehouseID int64) ([]domain.Bin, error) {
+func (wr *binRepository) GetByWarehouseID(ctx context.Context, warehouseID int64) ([]domain.Bin, error) {
 	var (
 		binsData []domain.Bin
 	)
 
 	query, args, err := squirrel.Select(
 		"id",
-		"wh_code",
+		"warehouse_id",
 		"name",
 		"latitude",
 		"longitude",
+		"zone_id",
+		"type",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+		"max_weight_kg",
+		"max_volume_m3",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("bins").Where(
@@ -72,7 +101,7 @@ func (wr *binRepository) GetByWarehouseID(warehouseID int64) ([]domain.Bin, erro
 	}
 
 	query = wr.sql.Rebind(query)
-	row, err := wr.sql.Query(query, args...)
+	row, err := sqltx.From(ctx, wr.sql).Query(query, args...)
 	if err != nil {
 		return binsData, err
 	}
@@ -86,6 +115,14 @@ func (wr *binRepository) GetByWarehouseID(warehouseID int64) ([]domain.Bin, erro
 			&binData.Name,
 			&binData.Latitude,
 			&binData.Longitude,
+			&binData.ZoneID,
+			&binData.Type,
+			&binData.LengthMM,
+			&binData.WidthMM,
+			&binData.HeightMM,
+			&binData.MaxWeightKg,
+			&binData.MaxVolumeM3,
+			&binData.Version,
 			&binData.CreatedAt,
 			&binData.UpdatedAt,
 		); err != nil {
@@ -98,7 +135,7 @@ func (wr *binRepository) GetByWarehouseID(warehouseID int64) ([]domain.Bin, erro
 	return binsData, nil
 }
 
-func (wr *binRepository) Select(params domain.BinQueryParameter) ([]domain.Bin, error) {
+func (wr *binRepository) Select(ctx context.Context, params domain.BinQueryParameter) ([]domain.Bin, error) {
 	var (
 		binsData []domain.Bin
 	)
@@ -109,6 +146,14 @@ func (wr *binRepository) Select(params domain.BinQueryParameter) ([]domain.Bin,
 		"name",
 		"latitude",
 		"longitude",
+		"zone_id",
+		"type",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+		"max_weight_kg",
+		"max_volume_m3",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("bins")
@@ -120,7 +165,7 @@ func (wr *binRepository) Select(params domain.BinQueryParameter) ([]domain.Bin,
 	}
 
 	query = wr.sql.Rebind(query)
-	rows, err := wr.sql.Query(query, args...)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
 	if err != nil {
 		return binsData, err
 	}
@@ -133,6 +178,14 @@ func (wr *binRepository) Select(params domain.BinQueryParameter) ([]domain.Bin,
 			&binData.Name,
 			&binData.Latitude,
 			&binData.Longitude,
+			&binData.ZoneID,
+			&binData.Type,
+			&binData.LengthMM,
+			&binData.WidthMM,
+			&binData.HeightMM,
+			&binData.MaxWeightKg,
+			&binData.MaxVolumeM3,
+			&binData.Version,
 			&binData.CreatedAt,
 			&binData.UpdatedAt,
 		); err != nil {
@@ -145,7 +198,7 @@ func (wr *binRepository) Select(params domain.BinQueryParameter) ([]domain.Bin,
 	return binsData, nil
 }
 
-func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error) {
+func (wr *binRepository) Create(ctx context.Context, data domain.BinDataParameter) (domain.Bin, error) {
 	var (
 		binData domain.Bin
 		t       = time.Now()
@@ -156,6 +209,14 @@ func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error
 		"name",
 		"latitude",
 		"longitude",
+		"zone_id",
+		"type",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+		"max_weight_kg",
+		"max_volume_m3",
+		"version",
 		"created_at",
 		"updated_at",
 	).Values(
@@ -163,6 +224,14 @@ func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error
 		data.Name,
 		data.Latitude,
 		data.Longitude,
+		data.ZoneID,
+		data.Type,
+		data.LengthMM,
+		data.WidthMM,
+		data.HeightMM,
+		data.MaxWeightKg,
+		data.MaxVolumeM3,
+		1,
 		t, t,
 	).ToSql()
 
@@ -172,10 +241,10 @@ func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error
 	}
 
 	query = wr.sql.Rebind(query)
-	result, err := wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		wr.logger.Errorln(err)
-		return binData, err
+		return binData, wr.conflict(ctx, err, data.WarehouseID, data.Name)
 	}
 
 	lastInserted, err := result.LastInsertId()
@@ -184,7 +253,7 @@ func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error
 		return binData, err
 	}
 
-	binData, err = wr.Get(lastInserted)
+	binData, err = wr.Get(ctx, lastInserted)
 	if err != nil {
 		wr.logger.Errorln(err)
 		return binData, err
@@ -193,7 +262,7 @@ func (wr *binRepository) Create(data domain.BinDataParameter) (domain.Bin, error
 	return binData, nil
 }
 
-func (wr *binRepository) Update(binID int64, data domain.BinDataParameter) (domain.Bin, error) {
+func (wr *binRepository) Update(ctx context.Context, binID, version int64, data domain.BinDataParameter) (domain.Bin, error) {
 	var (
 		binData domain.Bin
 	)
@@ -203,20 +272,88 @@ func (wr *binRepository) Update(binID int64, data domain.BinDataParameter) (doma
 		Set("name", data.Name).
 		Set("latitude", data.Latitude).
 		Set("longitude", data.Longitude).
+		Set("zone_id", data.ZoneID).
+		Set("type", data.Type).
+		Set("length_mm", data.LengthMM).
+		Set("width_mm", data.WidthMM).
+		Set("height_mm", data.HeightMM).
+		Set("max_weight_kg", data.MaxWeightKg).
+		Set("max_volume_m3", data.MaxVolumeM3).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": binID, "version": version}).
+		ToSql()
+	if err != nil {
+		return binData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		return binData, wr.conflict(ctx, err, data.WarehouseID, data.Name)
+	}
+
+	if err := wr.checkVersion(ctx, result, binID); err != nil {
+		return binData, err
+	}
+
+	binData, err = wr.Get(ctx, binID)
+	if err != nil {
+		return binData, err
+	}
+
+	return binData, nil
+}
+
+// Patch writes only the given fields of data, named by their JSON field
+func (wr *binRepository) Patch(ctx context.Context, binID, version int64, data domain.BinDataParameter, fields []string) (domain.Bin, error) {
+	var (
+		binData domain.Bin
+	)
+
+	columns := map[string]interface{}{
+		"warehouse_id":  data.WarehouseID,
+		"name":          data.Name,
+		"latitude":      data.Latitude,
+		"longitude":     data.Longitude,
+		"zone_id":       data.ZoneID,
+		"type":          data.Type,
+		"length_mm":     data.LengthMM,
+		"width_mm":      data.WidthMM,
+		"height_mm":     data.HeightMM,
+		"max_weight_kg": data.MaxWeightKg,
+		"max_volume_m3": data.MaxVolumeM3,
+	}
+
+	updater := squirrel.Update("bins")
+	for _, field := range fields {
+		value, ok := columns[field]
+		if !ok {
+			return binData, fmt.Errorf("bin field %q cannot be patched", field)
+		}
+		updater = updater.Set(field, value)
+	}
+
+	query, args, err := updater.
+		Set("version", squirrel.Expr("version + 1")).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": binID}).
+		Where(squirrel.Eq{"id": binID, "version": version}).
 		ToSql()
 	if err != nil {
 		return binData, err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
+		return binData, wr.conflict(ctx, err, data.WarehouseID, data.Name)
+	}
+
+	if err := wr.checkVersion(ctx, result, binID); err != nil {
 		return binData, err
 	}
 
-	binData, err = wr.Get(binID)
+	binData, err = wr.Get(ctx, binID)
 	if err != nil {
 		return binData, err
 	}
@@ -224,17 +361,54 @@ func (wr *binRepository) Update(binID int64, data domain.BinDataParameter) (doma
 	return binData, nil
 }
 
-func (wr *binRepository) Delete(binID int64) error {
-	query, args, err := squirrel.Delete("bins").Where(squirrel.Eq{"id": binID}).ToSql()
+func (wr *binRepository) Delete(ctx context.Context, binID, version int64) error {
+	query, args, err := squirrel.Delete("bins").Where(squirrel.Eq{"id": binID, "version": version}).ToSql()
 	if err != nil {
 		return err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return wr.checkVersion(ctx, result, binID)
+}
+
+func (wr *binRepository) checkVersion(ctx context.Context, result sql.Result, binID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(ctx, binID)
+		return err
+	})
+}
+
+// conflict turns a duplicate key error into a ConflictError naming the bin
+// that already holds the name in its warehouse, other errors pass through
+func (wr *binRepository) conflict(ctx context.Context, err error, warehouseID int64, name string) error {
+	if !sqlerr.IsDuplicateKey(err) {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"warehouse_id": warehouseID,
+		"name":         name,
+	}
+
+	query, args, qErr := squirrel.Select("id").From("bins").Where(squirrel.Eq(fields)).Limit(1).ToSql()
+	if qErr != nil {
+		return err
+	}
+
+	conflict := &domain.ConflictError{
+		Entity: "bin",
+		Fields: fields,
+	}
+
+	query = wr.sql.Rebind(query)
+	if qErr := sqltx.From(ctx, wr.sql).QueryRow(query, args...).Scan(&conflict.ExistingID); qErr != nil {
+		wr.logger.Errorln(qErr)
+	}
+
+	return conflict
 }
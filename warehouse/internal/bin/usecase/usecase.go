@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/sirupsen/logrus"
 )
@@ -9,22 +11,32 @@ type binUsecase struct {
 	logger    *logrus.Logger
 	bin       domain.BinRepository
 	warehouse domain.WarehouseRepository
+	stock     domain.StockRepository
+	sku       domain.SKURepository
+	tx        domain.Transactor
+	audit     domain.AuditRepository
+	outbox    domain.OutboxRepository
 }
 
-func NewUsecase(logger *logrus.Logger, bin domain.BinRepository, warehouse domain.WarehouseRepository) domain.BinUsecase {
+func NewUsecase(logger *logrus.Logger, bin domain.BinRepository, warehouse domain.WarehouseRepository, stock domain.StockRepository, sku domain.SKURepository, tx domain.Transactor, audit domain.AuditRepository, outbox domain.OutboxRepository) domain.BinUsecase {
 	return &binUsecase{
 		logger:    logger,
 		bin:       bin,
 		warehouse: warehouse,
+		stock:     stock,
+		sku:       sku,
+		tx:        tx,
+		audit:     audit,
+		outbox:    outbox,
 	}
 }
 
-func (uc *binUsecase) Get(binID int64) (domain.BinResponse, error) {
+func (uc *binUsecase) Get(ctx context.Context, binID int64) (domain.BinResponse, error) {
 	var (
 		binResponse domain.BinResponse
 	)
 
-	binData, err := uc.bin.Get(binID)
+	binData, err := uc.bin.Get(ctx, binID)
 	if err != nil {
 		return binResponse, err
 	}
@@ -33,12 +45,12 @@ func (uc *binUsecase) Get(binID int64) (domain.BinResponse, error) {
 	return binResponse, nil
 }
 
-func (uc *binUsecase) Select(params domain.BinQueryParameter) ([]domain.BinResponse, error) {
+func (uc *binUsecase) Select(ctx context.Context, params domain.BinQueryParameter) ([]domain.BinResponse, error) {
 	var (
 		binResponses = []domain.BinResponse{}
 	)
 
-	binsData, err := uc.bin.Select(params)
+	binsData, err := uc.bin.Select(ctx, params)
 	if err != nil {
 		return binResponses, err
 	}
@@ -50,48 +62,127 @@ func (uc *binUsecase) Select(params domain.BinQueryParameter) ([]domain.BinRespo
 	return binResponses, nil
 }
 
-func (uc *binUsecase) Create(data domain.BinDataParameter) (domain.BinResponse, error) {
+func (uc *binUsecase) Create(ctx context.Context, data domain.BinDataParameter) (domain.BinResponse, error) {
 	var (
 		binResponse domain.BinResponse
 	)
 
+	if len(data.Type) < 1 {
+		data.Type = domain.BinTypeShelf
+	}
+
 	// Check if warehouse exists
-	_, err := uc.warehouse.Get(data.WarehouseID)
+	_, err := uc.warehouse.Get(ctx, data.WarehouseID)
 	if err != nil {
 		return binResponse, err
 	}
 
-	binData, err := uc.bin.Create(data)
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		binData, err := uc.bin.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		binResponse = binData.BinResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityBin, binData.ID, binData.WarehouseID, domain.AuditActionCreate, nil, binResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBinCreated, binData.ID, binData.WarehouseID, binResponse))
+	})
 	if err != nil {
-		return binResponse, err
+		return domain.BinResponse{}, err
 	}
 
-	binResponse = binData.BinResponse()
 	return binResponse, nil
 }
 
-func (uc *binUsecase) Update(binID int64, data domain.BinDataParameter) (domain.BinResponse, error) {
+func (uc *binUsecase) Update(ctx context.Context, binID, version int64, data domain.BinDataParameter) (domain.BinResponse, error) {
+	return uc.update(ctx, binID, version, data, nil)
+}
+
+// Patch writes only the given fields of data, with no fields it only checks
+// the version and returns the bin as it is
+func (uc *binUsecase) Patch(ctx context.Context, binID, version int64, data domain.BinDataParameter, fields []string) (domain.BinResponse, error) {
+	if len(fields) > 0 {
+		return uc.update(ctx, binID, version, data, fields)
+	}
+
+	response, err := uc.Get(ctx, binID)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Version != version {
+		return domain.BinResponse{}, domain.ErrVersionConflict
+	}
+
+	return response, nil
+}
+
+// update writes every field of data when fields is nil
+func (uc *binUsecase) update(ctx context.Context, binID, version int64, data domain.BinDataParameter, fields []string) (domain.BinResponse, error) {
 	var (
 		binResponse domain.BinResponse
 	)
 
+	if len(data.Type) < 1 {
+		data.Type = domain.BinTypeShelf
+	}
+
 	// Check if warehouse exists
-	_, err := uc.warehouse.Get(data.WarehouseID)
+	_, err := uc.warehouse.Get(ctx, data.WarehouseID)
 	if err != nil {
 		return binResponse, err
 	}
 
-	binData, err := uc.bin.Update(binID, data)
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.bin.Get(ctx, binID)
+		if err != nil {
+			return err
+		}
+
+		var binData domain.Bin
+		if fields == nil {
+			binData, err = uc.bin.Update(ctx, binID, version, data)
+		} else {
+			binData, err = uc.bin.Patch(ctx, binID, version, data, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		binResponse = binData.BinResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityBin, binID, binData.WarehouseID, domain.AuditActionUpdate, before.BinResponse(), binResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBinUpdated, binID, binData.WarehouseID, binResponse))
+	})
 	if err != nil {
-		return binResponse, err
+		return domain.BinResponse{}, err
 	}
 
-	binResponse = binData.BinResponse()
 	return binResponse, nil
 }
 
-func (uc *binUsecase) Delete(binID int64) (domain.GenericResponse, error) {
-	err := uc.bin.Delete(binID)
+func (uc *binUsecase) Delete(ctx context.Context, binID, version int64) (domain.GenericResponse, error) {
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.bin.Get(ctx, binID)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.bin.Delete(ctx, binID, version); err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityBin, binID, before.WarehouseID, domain.AuditActionDelete, before.BinResponse(), nil)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBinDeleted, binID, before.WarehouseID, before.BinResponse()))
+	})
 	if err != nil {
 		return domain.GenericResponse{}, err
 	}
@@ -100,3 +191,121 @@ func (uc *binUsecase) Delete(binID int64) (domain.GenericResponse, error) {
 		Success: true,
 	}, nil
 }
+
+func (uc *binUsecase) Occupancy(ctx context.Context, binID int64) (domain.BinOccupancyResponse, error) {
+	var (
+		occupancyResponse domain.BinOccupancyResponse
+	)
+
+	binData, err := uc.bin.Get(ctx, binID)
+	if err != nil {
+		return occupancyResponse, err
+	}
+
+	occupancies, err := uc.occupancies(ctx, []domain.Bin{binData})
+	if err != nil {
+		return occupancyResponse, err
+	}
+
+	occupancyResponse = occupancies[0]
+	return occupancyResponse, nil
+}
+
+// Utilization returns every bin of a warehouse as a heatmap point, together
+// with the totals of each zone. Bins without a zone are grouped under "".
+func (uc *binUsecase) Utilization(ctx context.Context, warehouseID int64) (domain.WarehouseUtilizationResponse, error) {
+	var (
+		utilizationResponse = domain.WarehouseUtilizationResponse{
+			WarehouseID: warehouseID,
+			Zones:       []domain.ZoneUtilizationResponse{},
+			Bins:        []domain.BinOccupancyResponse{},
+		}
+	)
+
+	if _, err := uc.warehouse.Get(ctx, warehouseID); err != nil {
+		return utilizationResponse, err
+	}
+
+	binsData, err := uc.bin.GetByWarehouseID(ctx, warehouseID)
+	if err != nil {
+		return utilizationResponse, err
+	}
+
+	occupancies, err := uc.occupancies(ctx, binsData)
+	if err != nil {
+		return utilizationResponse, err
+	}
+
+	zoneIndex := make(map[string]int)
+	for _, occupancy := range occupancies {
+		i, ok := zoneIndex[occupancy.ZoneID]
+		if !ok {
+			i = len(utilizationResponse.Zones)
+			zoneIndex[occupancy.ZoneID] = i
+			utilizationResponse.Zones = append(utilizationResponse.Zones, domain.ZoneUtilizationResponse{
+				ZoneID: occupancy.ZoneID,
+			})
+		}
+
+		utilizationResponse.Zones[i].AddBin(occupancy)
+	}
+
+	utilizationResponse.Bins = occupancies
+	return utilizationResponse, nil
+}
+
+// occupancies computes the occupancy of every given bin, SKUs are only
+// fetched once even when they sit in several bins.
+func (uc *binUsecase) occupancies(ctx context.Context, binsData []domain.Bin) ([]domain.BinOccupancyResponse, error) {
+	var (
+		occupancyResponses = []domain.BinOccupancyResponse{}
+		skusData           = make(map[int64]domain.SKU)
+	)
+
+	if len(binsData) < 1 {
+		return occupancyResponses, nil
+	}
+
+	binIDs := make([]int64, 0, len(binsData))
+	for _, binData := range binsData {
+		binIDs = append(binIDs, binData.ID)
+	}
+
+	balancesData, err := domain.SelectAllBalances(uc.stock, domain.StockBalanceQueryParameter{
+		BinID:   binIDs,
+		InStock: true,
+	})
+	if err != nil {
+		return occupancyResponses, err
+	}
+
+	balancesByBin := make(map[int64][]domain.StockBalance)
+	var skuIDs []int64
+	for _, balance := range balancesData {
+		balancesByBin[balance.BinID] = append(balancesByBin[balance.BinID], balance)
+		if _, ok := skusData[balance.SKUID]; !ok {
+			skusData[balance.SKUID] = domain.SKU{}
+			skuIDs = append(skuIDs, balance.SKUID)
+		}
+	}
+
+	if len(skuIDs) > 0 {
+		skuList, err := uc.sku.Select(ctx, domain.SKUQueryParameter{
+			PaginationQuery: domain.PaginationQuery{Limit: int64(len(skuIDs))},
+			ID:              skuIDs,
+		})
+		if err != nil {
+			return occupancyResponses, err
+		}
+
+		for _, skuData := range skuList {
+			skusData[skuData.ID] = skuData
+		}
+	}
+
+	for _, binData := range binsData {
+		occupancyResponses = append(occupancyResponses, domain.ComputeOccupancy(binData, balancesByBin[binData.ID], skusData))
+	}
+
+	return occupancyResponses, nil
+}
@@ -2,12 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/mergepatch"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -16,22 +18,28 @@ import (
 type httpDelivery struct {
 	logger    *logrus.Logger
 	commodity domain.CommodityUsecase
+	warehouse domain.WarehouseUsecase
 	validator *validator.Validate
 }
 
-func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, commodity domain.CommodityUsecase) {
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, commodity domain.CommodityUsecase, warehouse domain.WarehouseUsecase) {
 	httpInstance := &httpDelivery{
 		logger:    logger,
 		commodity: commodity,
+		warehouse: warehouse,
 		validator: validator.New(),
 	}
 
-	// Bind with given router
+	// Bind with given router. Commodities are shared by every warehouse, their
+	// SKUs and the stock report are scoped to the warehouses they belong to
 	router.HandleFunc("/commodity", httpInstance.Select).Methods("GET")
 	router.HandleFunc("/commodity", httpInstance.Create).Methods("POST")
 	router.HandleFunc("/commodity/{id}", httpInstance.Get).Methods("GET")
 	router.HandleFunc("/commodity/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/commodity/{id}", httpInstance.Patch).Methods("PATCH")
 	router.HandleFunc("/commodity/{id}", httpInstance.Delete).Methods("DELETE")
+	router.HandleFunc("/commodity/{id}/skus", httpInstance.SelectSKUs).Methods("GET")
+	router.HandleFunc("/warehouse/{id}/commodities", httpInstance.Report).Methods("GET")
 }
 
 func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +47,11 @@ func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
 		commodityID int64
 	)
 
+	if err := domain.AuthorizeAny(r.Context(), domain.PermCommodityRead); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	vars := mux.Vars(r)
 	if id, ok := vars["id"]; !ok {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
@@ -52,12 +65,13 @@ func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
 		commodityID = id
 	}
 
-	response, err := h.commodity.Get(commodityID)
+	response, err := h.commodity.Get(r.Context(), commodityID)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Commodity, Make sure you find correct Commodity")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusOK, response)
 }
 
@@ -66,13 +80,18 @@ func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
 		queryParam domain.CommodityQueryParameter
 	)
 
+	if err := domain.AuthorizeAny(r.Context(), domain.PermCommodityRead); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	// Parse Query Parameter
 	if err := queryParam.Parse(r.URL.Query()); err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
 		return
 	}
 
-	responses, err := h.commodity.Select(queryParam)
+	responses, err := h.commodity.Select(r.Context(), queryParam)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Commodity")
 		return
@@ -86,6 +105,11 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		createData domain.CommodityDataParameter
 	)
 
+	if err := domain.Authorize(r.Context(), domain.PermCommodityWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -102,12 +126,13 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.commodity.Create(createData)
+	response, err := h.commodity.Create(r.Context(), createData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Creating Commodity")
+		h.responseError(w, err, "An Error Occured When Creating Commodity")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -117,6 +142,11 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		updateData  domain.CommodityDataParameter
 	)
 
+	if err := domain.Authorize(r.Context(), domain.PermCommodityWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	vars := mux.Vars(r)
 	if id, ok := vars["id"]; !ok {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
@@ -130,6 +160,12 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		commodityID = id
 	}
 
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -146,12 +182,83 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.commodity.Update(commodityID, updateData)
+	response, err := h.commodity.Update(r.Context(), commodityID, version, updateData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Updating Commodity")
+		h.responseError(w, err, "An Error Occured When Updating Commodity")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+// Patch applies a JSON merge patch to the commodity, only the fields it names
+// are validated and only the ones it changes are written
+func (h *httpDelivery) Patch(w http.ResponseWriter, r *http.Request) {
+	var (
+		commodityID int64
+		patchData   domain.CommodityDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermCommodityWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		commodityID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if !mergepatch.Accepts(r.Header.Get("Content-Type")) {
+		httpcommon.ResponseJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergepatch.ContentType)
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	current, err := h.commodity.Get(r.Context(), commodityID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Commodity, Make sure you find correct Commodity")
+		return
+	}
+
+	result, err := mergepatch.Merge(current.CommodityDataParameter(), bodyData, &patchData)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Apply Merge Patch")
+		return
+	}
+
+	if err := mergepatch.Validate(h.validator, &patchData, result.Supplied); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.commodity.Patch(r.Context(), commodityID, version, patchData, result.Changed)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating Commodity")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -160,6 +267,11 @@ func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
 		commodityID int64
 	)
 
+	if err := domain.Authorize(r.Context(), domain.PermCommodityDelete, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	vars := mux.Vars(r)
 	if id, ok := vars["id"]; !ok {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
@@ -173,9 +285,114 @@ func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
 		commodityID = id
 	}
 
-	if resp, err := h.commodity.Delete(commodityID); err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Delete Commodity")
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.commodity.Delete(r.Context(), commodityID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Commodity")
 	} else {
 		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
 	}
 }
+
+func (h *httpDelivery) SelectSKUs(w http.ResponseWriter, r *http.Request) {
+	var (
+		commodityID int64
+		queryParam  domain.SKUQueryParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		commodityID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list SKUs of the warehouses the caller may see
+	whCodes, ok, err := domain.ScopeWarehouseNames(r.Context(), domain.PermSKURead, queryParam.WHCode, h.warehouse.Names)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.SKUResponse{})
+		return
+	}
+	queryParam.WHCode = whCodes
+
+	responses, err := h.commodity.SelectSKUs(r.Context(), commodityID, queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Commodity, Make sure you find correct Commodity")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Report(w http.ResponseWriter, r *http.Request) {
+	var (
+		warehouseID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		warehouseID = id
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermCommodityRead, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.commodity.Report(r.Context(), warehouseID)
+	if err != nil {
+		h.responseError(w, err, "Cannot report Commodities, Make sure you find correct Warehouse")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrInvalidTempRange):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrCommodityInUse):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
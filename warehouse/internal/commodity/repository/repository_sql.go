@@ -1,21 +1,27 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
 )
 
-func (wr *commodityRepository) Get(commodityID int64) (domain.Commodity, error) {
-	var (
-		commodityData domain.Commodity
-	)
-
+func (wr *commodityRepository) Get(ctx context.Context, commodityID int64) (domain.Commodity, error) {
 	query, args, err := squirrel.Select(
 		"id",
 		"name",
 		"description",
+		"hazmat_class",
+		"min_temp_c",
+		"max_temp_c",
+		"fragile",
+		"stackable",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("commodities").Where(
@@ -23,30 +29,19 @@ func (wr *commodityRepository) Get(commodityID int64) (domain.Commodity, error)
 	).ToSql()
 
 	if err != nil {
-		return commodityData, err
+		return domain.Commodity{}, err
 	}
 
 	query = wr.sql.Rebind(query)
-	row := wr.sql.QueryRow(query, args...)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
 	if err := row.Err(); err != nil {
-		return commodityData, err
-	}
-
-	err = row.Scan(
-		&commodityData.ID,
-		&commodityData.Name,
-		&commodityData.Description,
-		&commodityData.CreatedAt,
-		&commodityData.UpdatedAt,
-	)
-	if err != nil {
-		return commodityData, err
+		return domain.Commodity{}, err
 	}
 
-	return commodityData, nil
+	return scanCommodity(row)
 }
 
-func (wr *commodityRepository) Select(params domain.CommodityQueryParameter) ([]domain.Commodity, error) {
+func (wr *commodityRepository) Select(ctx context.Context, params domain.CommodityQueryParameter) ([]domain.Commodity, error) {
 	var (
 		commoditiesData []domain.Commodity
 	)
@@ -55,6 +50,12 @@ func (wr *commodityRepository) Select(params domain.CommodityQueryParameter) ([]
 		"id",
 		"name",
 		"description",
+		"hazmat_class",
+		"min_temp_c",
+		"max_temp_c",
+		"fragile",
+		"stackable",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("commodities")
@@ -66,20 +67,16 @@ func (wr *commodityRepository) Select(params domain.CommodityQueryParameter) ([]
 	}
 
 	query = wr.sql.Rebind(query)
-	rows, err := wr.sql.Query(query, args...)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
 	if err != nil {
 		return commoditiesData, err
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
-		var commodityData domain.Commodity
-		if err := rows.Scan(
-			&commodityData.ID,
-			&commodityData.Name,
-			&commodityData.Description,
-			&commodityData.CreatedAt,
-			&commodityData.UpdatedAt,
-		); err != nil {
+		commodityData, err := scanCommodity(rows)
+		if err != nil {
 			return commoditiesData, err
 		}
 
@@ -89,7 +86,7 @@ func (wr *commodityRepository) Select(params domain.CommodityQueryParameter) ([]
 	return commoditiesData, nil
 }
 
-func (wr *commodityRepository) Create(data domain.CommodityDataParameter) (domain.Commodity, error) {
+func (wr *commodityRepository) Create(ctx context.Context, data domain.CommodityDataParameter) (domain.Commodity, error) {
 	var (
 		commodityData domain.Commodity
 		t             = time.Now()
@@ -98,11 +95,23 @@ func (wr *commodityRepository) Create(data domain.CommodityDataParameter) (domai
 	query, args, err := squirrel.Insert("commodities").Columns(
 		"name",
 		"description",
+		"hazmat_class",
+		"min_temp_c",
+		"max_temp_c",
+		"fragile",
+		"stackable",
+		"version",
 		"created_at",
 		"updated_at",
 	).Values(
 		data.Name,
 		data.Description,
+		data.HazmatClass,
+		nullableTemp(data.MinTempC),
+		nullableTemp(data.MaxTempC),
+		data.Fragile,
+		data.IsStackable(),
+		1,
 		t, t,
 	).ToSql()
 
@@ -112,7 +121,7 @@ func (wr *commodityRepository) Create(data domain.CommodityDataParameter) (domai
 	}
 
 	query = wr.sql.Rebind(query)
-	result, err := wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		wr.logger.Errorln(err)
 		return commodityData, err
@@ -124,7 +133,7 @@ func (wr *commodityRepository) Create(data domain.CommodityDataParameter) (domai
 		return commodityData, err
 	}
 
-	commodityData, err = wr.Get(lastInserted)
+	commodityData, err = wr.Get(ctx, lastInserted)
 	if err != nil {
 		wr.logger.Errorln(err)
 		return commodityData, err
@@ -133,7 +142,7 @@ func (wr *commodityRepository) Create(data domain.CommodityDataParameter) (domai
 	return commodityData, nil
 }
 
-func (wr *commodityRepository) Update(commodityID int64, data domain.CommodityDataParameter) (domain.Commodity, error) {
+func (wr *commodityRepository) Update(ctx context.Context, commodityID, version int64, data domain.CommodityDataParameter) (domain.Commodity, error) {
 	var (
 		commodityData domain.Commodity
 	)
@@ -141,20 +150,30 @@ func (wr *commodityRepository) Update(commodityID int64, data domain.CommodityDa
 	query, args, err := squirrel.Update("commodities").
 		Set("name", data.Name).
 		Set("description", data.Description).
+		Set("hazmat_class", data.HazmatClass).
+		Set("min_temp_c", nullableTemp(data.MinTempC)).
+		Set("max_temp_c", nullableTemp(data.MaxTempC)).
+		Set("fragile", data.Fragile).
+		Set("stackable", data.IsStackable()).
+		Set("version", squirrel.Expr("version + 1")).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": commodityID}).
+		Where(squirrel.Eq{"id": commodityID, "version": version}).
 		ToSql()
 	if err != nil {
 		return commodityData, err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		return commodityData, err
 	}
 
-	commodityData, err = wr.Get(commodityID)
+	if err := wr.checkVersion(ctx, result, commodityID); err != nil {
+		return commodityData, err
+	}
+
+	commodityData, err = wr.Get(ctx, commodityID)
 	if err != nil {
 		return commodityData, err
 	}
@@ -162,17 +181,122 @@ func (wr *commodityRepository) Update(commodityID int64, data domain.CommodityDa
 	return commodityData, nil
 }
 
-func (wr *commodityRepository) Delete(commodityID int64) error {
-	query, args, err := squirrel.Delete("commodities").Where(squirrel.Eq{"id": commodityID}).ToSql()
+// Patch writes only the given fields of data, named by their JSON field
+func (wr *commodityRepository) Patch(ctx context.Context, commodityID, version int64, data domain.CommodityDataParameter, fields []string) (domain.Commodity, error) {
+	var (
+		commodityData domain.Commodity
+	)
+
+	columns := map[string]interface{}{
+		"name":         data.Name,
+		"description":  data.Description,
+		"hazmat_class": data.HazmatClass,
+		"min_temp_c":   nullableTemp(data.MinTempC),
+		"max_temp_c":   nullableTemp(data.MaxTempC),
+		"fragile":      data.Fragile,
+		"stackable":    data.IsStackable(),
+	}
+
+	updater := squirrel.Update("commodities")
+	for _, field := range fields {
+		value, ok := columns[field]
+		if !ok {
+			return commodityData, fmt.Errorf("commodity field %q cannot be patched", field)
+		}
+		updater = updater.Set(field, value)
+	}
+
+	query, args, err := updater.
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": commodityID, "version": version}).
+		ToSql()
+	if err != nil {
+		return commodityData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		return commodityData, err
+	}
+
+	if err := wr.checkVersion(ctx, result, commodityID); err != nil {
+		return commodityData, err
+	}
+
+	commodityData, err = wr.Get(ctx, commodityID)
+	if err != nil {
+		return commodityData, err
+	}
+
+	return commodityData, nil
+}
+
+func (wr *commodityRepository) Delete(ctx context.Context, commodityID, version int64) error {
+	query, args, err := squirrel.Delete("commodities").Where(squirrel.Eq{"id": commodityID, "version": version}).ToSql()
 	if err != nil {
 		return err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return wr.checkVersion(ctx, result, commodityID)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanCommodity(row scanner) (domain.Commodity, error) {
+	var (
+		commodityData domain.Commodity
+		minTempC      sql.NullFloat64
+		maxTempC      sql.NullFloat64
+	)
+
+	err := row.Scan(
+		&commodityData.ID,
+		&commodityData.Name,
+		&commodityData.Description,
+		&commodityData.HazmatClass,
+		&minTempC,
+		&maxTempC,
+		&commodityData.Fragile,
+		&commodityData.Stackable,
+		&commodityData.Version,
+		&commodityData.CreatedAt,
+		&commodityData.UpdatedAt,
+	)
+	if err != nil {
+		return commodityData, err
+	}
+
+	if minTempC.Valid {
+		commodityData.MinTempC = &minTempC.Float64
+	}
+	if maxTempC.Valid {
+		commodityData.MaxTempC = &maxTempC.Float64
+	}
+
+	return commodityData, nil
+}
+
+// Commodities without a bound store NULL
+func nullableTemp(temp *float64) sql.NullFloat64 {
+	if temp == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *temp, Valid: true}
+}
+
+func (wr *commodityRepository) checkVersion(ctx context.Context, result sql.Result, commodityID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(ctx, commodityID)
+		return err
+	})
 }
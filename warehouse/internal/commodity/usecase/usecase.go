@@ -1,6 +1,9 @@
 package usecase
 
 import (
+	"context"
+	"sort"
+
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/sirupsen/logrus"
 )
@@ -8,21 +11,33 @@ import (
 type commodityUsecase struct {
 	logger    *logrus.Logger
 	commodity domain.CommodityRepository
+	sku       domain.SKURepository
+	stock     domain.StockRepository
+	warehouse domain.WarehouseRepository
+	tx        domain.Transactor
+	audit     domain.AuditRepository
+	outbox    domain.OutboxRepository
 }
 
-func NewUsecase(logger *logrus.Logger, commodity domain.CommodityRepository) domain.CommodityUsecase {
+func NewUsecase(logger *logrus.Logger, commodity domain.CommodityRepository, sku domain.SKURepository, stock domain.StockRepository, warehouse domain.WarehouseRepository, tx domain.Transactor, audit domain.AuditRepository, outbox domain.OutboxRepository) domain.CommodityUsecase {
 	return &commodityUsecase{
 		logger:    logger,
 		commodity: commodity,
+		sku:       sku,
+		stock:     stock,
+		warehouse: warehouse,
+		tx:        tx,
+		audit:     audit,
+		outbox:    outbox,
 	}
 }
 
-func (uc *commodityUsecase) Get(commodityID int64) (domain.CommodityResponse, error) {
+func (uc *commodityUsecase) Get(ctx context.Context, commodityID int64) (domain.CommodityResponse, error) {
 	var (
 		commodityResponse domain.CommodityResponse
 	)
 
-	warehouseData, err := uc.commodity.Get(commodityID)
+	warehouseData, err := uc.commodity.Get(ctx, commodityID)
 	if err != nil {
 		return commodityResponse, err
 	}
@@ -31,12 +46,12 @@ func (uc *commodityUsecase) Get(commodityID int64) (domain.CommodityResponse, er
 	return commodityResponse, nil
 }
 
-func (uc *commodityUsecase) Select(params domain.CommodityQueryParameter) ([]domain.CommodityResponse, error) {
+func (uc *commodityUsecase) Select(ctx context.Context, params domain.CommodityQueryParameter) ([]domain.CommodityResponse, error) {
 	var (
 		commodityResponses = []domain.CommodityResponse{}
 	)
 
-	commoditysData, err := uc.commodity.Select(params)
+	commoditysData, err := uc.commodity.Select(ctx, params)
 	if err != nil {
 		return commodityResponses, err
 	}
@@ -48,36 +63,126 @@ func (uc *commodityUsecase) Select(params domain.CommodityQueryParameter) ([]dom
 	return commodityResponses, nil
 }
 
-func (uc *commodityUsecase) Create(data domain.CommodityDataParameter) (domain.CommodityResponse, error) {
+func (uc *commodityUsecase) Create(ctx context.Context, data domain.CommodityDataParameter) (domain.CommodityResponse, error) {
 	var (
 		commodityResponse domain.CommodityResponse
 	)
 
-	commodityData, err := uc.commodity.Create(data)
-	if err != nil {
+	if err := data.ValidateTempRange(); err != nil {
 		return commodityResponse, err
 	}
 
-	commodityResponse = commodityData.CommodityResponse()
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		commodityData, err := uc.commodity.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		commodityResponse = commodityData.CommodityResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityCommodity, commodityData.ID, 0, domain.AuditActionCreate, nil, commodityResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventCommodityCreated, commodityData.ID, 0, commodityResponse))
+	})
+	if err != nil {
+		return domain.CommodityResponse{}, err
+	}
+
 	return commodityResponse, nil
 }
 
-func (uc *commodityUsecase) Update(commodityID int64, data domain.CommodityDataParameter) (domain.CommodityResponse, error) {
+func (uc *commodityUsecase) Update(ctx context.Context, commodityID, version int64, data domain.CommodityDataParameter) (domain.CommodityResponse, error) {
+	return uc.update(ctx, commodityID, version, data, nil)
+}
+
+// Patch writes only the given fields of data, with no fields it only checks
+// the version and returns the commodity as it is
+func (uc *commodityUsecase) Patch(ctx context.Context, commodityID, version int64, data domain.CommodityDataParameter, fields []string) (domain.CommodityResponse, error) {
+	if len(fields) > 0 {
+		return uc.update(ctx, commodityID, version, data, fields)
+	}
+
+	response, err := uc.Get(ctx, commodityID)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Version != version {
+		return domain.CommodityResponse{}, domain.ErrVersionConflict
+	}
+
+	return response, nil
+}
+
+// update writes every field of data when fields is nil
+func (uc *commodityUsecase) update(ctx context.Context, commodityID, version int64, data domain.CommodityDataParameter, fields []string) (domain.CommodityResponse, error) {
 	var (
 		commodityResponse domain.CommodityResponse
 	)
 
-	commodityData, err := uc.commodity.Update(commodityID, data)
-	if err != nil {
+	if err := data.ValidateTempRange(); err != nil {
 		return commodityResponse, err
 	}
 
-	commodityResponse = commodityData.CommodityResponse()
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.commodity.Get(ctx, commodityID)
+		if err != nil {
+			return err
+		}
+
+		var commodityData domain.Commodity
+		if fields == nil {
+			commodityData, err = uc.commodity.Update(ctx, commodityID, version, data)
+		} else {
+			commodityData, err = uc.commodity.Patch(ctx, commodityID, version, data, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		commodityResponse = commodityData.CommodityResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityCommodity, commodityID, 0, domain.AuditActionUpdate, before.CommodityResponse(), commodityResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventCommodityUpdated, commodityID, 0, commodityResponse))
+	})
+	if err != nil {
+		return domain.CommodityResponse{}, err
+	}
+
 	return commodityResponse, nil
 }
 
-func (uc *commodityUsecase) Delete(commodityID int64) (domain.GenericResponse, error) {
-	err := uc.commodity.Delete(commodityID)
+func (uc *commodityUsecase) Delete(ctx context.Context, commodityID, version int64) (domain.GenericResponse, error) {
+	skusData, err := uc.sku.Select(ctx, domain.SKUQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: 1},
+		CommodityID:     []int64{commodityID},
+	})
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+	if len(skusData) > 0 {
+		return domain.GenericResponse{}, domain.ErrCommodityInUse
+	}
+
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.commodity.Get(ctx, commodityID)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.commodity.Delete(ctx, commodityID, version); err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityCommodity, commodityID, 0, domain.AuditActionDelete, before.CommodityResponse(), nil)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventCommodityDeleted, commodityID, 0, before.CommodityResponse()))
+	})
 	if err != nil {
 		return domain.GenericResponse{}, err
 	}
@@ -86,3 +191,116 @@ func (uc *commodityUsecase) Delete(commodityID int64) (domain.GenericResponse, e
 		Success: true,
 	}, nil
 }
+
+func (uc *commodityUsecase) SelectSKUs(ctx context.Context, commodityID int64, params domain.SKUQueryParameter) ([]domain.SKUResponse, error) {
+	var (
+		skuResponses = []domain.SKUResponse{}
+	)
+
+	// Check if commodity exists
+	if _, err := uc.commodity.Get(ctx, commodityID); err != nil {
+		return skuResponses, err
+	}
+
+	params.CommodityID = []int64{commodityID}
+	skusData, err := uc.sku.Select(ctx, params)
+	if err != nil {
+		return skuResponses, err
+	}
+
+	for _, sku := range skusData {
+		skuResponses = append(skuResponses, sku.SKUResponse())
+	}
+
+	return skuResponses, nil
+}
+
+// Report sums up the stock held in a warehouse per commodity
+func (uc *commodityUsecase) Report(ctx context.Context, warehouseID int64) (domain.CommodityReportResponse, error) {
+	var (
+		reportResponse = domain.CommodityReportResponse{
+			WarehouseID: warehouseID,
+			Commodities: []domain.CommodityStockResponse{},
+		}
+	)
+
+	if _, err := uc.warehouse.Get(ctx, warehouseID); err != nil {
+		return reportResponse, err
+	}
+
+	balancesData, err := domain.SelectAllBalances(uc.stock, domain.StockBalanceQueryParameter{
+		WarehouseID: []int64{warehouseID},
+		InStock:     true,
+	})
+	if err != nil {
+		return reportResponse, err
+	}
+
+	skusData := make(map[int64]domain.SKU)
+	commoditiesData := map[int64]domain.Commodity{0: {}}
+	for _, balance := range balancesData {
+		if _, found := skusData[balance.SKUID]; found {
+			continue
+		}
+
+		skuData, err := uc.sku.Get(ctx, balance.SKUID)
+		if err != nil {
+			return reportResponse, err
+		}
+		skusData[balance.SKUID] = skuData
+
+		if _, found := commoditiesData[skuData.CommodityID]; found {
+			continue
+		}
+
+		commodityData, err := uc.commodity.Get(ctx, skuData.CommodityID)
+		if err != nil {
+			return reportResponse, err
+		}
+		commoditiesData[skuData.CommodityID] = commodityData
+	}
+
+	var (
+		lines    = make(map[int64]*domain.CommodityStockResponse)
+		seenSKUs = make(map[int64]bool)
+		seenBins = make(map[[2]int64]bool)
+	)
+	for _, balance := range balancesData {
+		skuData := skusData[balance.SKUID]
+		commodityData := commoditiesData[skuData.CommodityID]
+
+		line, ok := lines[commodityData.ID]
+		if !ok {
+			line = &domain.CommodityStockResponse{
+				CommodityID:   commodityData.ID,
+				CommodityName: commodityData.Name,
+				HazmatClass:   commodityData.HazmatClass,
+			}
+			lines[commodityData.ID] = line
+		}
+
+		if !seenSKUs[skuData.ID] {
+			seenSKUs[skuData.ID] = true
+			line.SKUs++
+		}
+		if binKey := [2]int64{commodityData.ID, balance.BinID}; !seenBins[binKey] {
+			seenBins[binKey] = true
+			line.Bins++
+		}
+
+		line.Units += balance.Quantity
+		if volumeM3, weightKg, ok := skuData.UnitMeasure(); ok {
+			line.VolumeM3 += volumeM3 * float64(balance.Quantity)
+			line.WeightKg += weightKg * float64(balance.Quantity)
+		}
+	}
+
+	for _, line := range lines {
+		reportResponse.Commodities = append(reportResponse.Commodities, *line)
+	}
+	sort.Slice(reportResponse.Commodities, func(i, j int) bool {
+		return reportResponse.Commodities[i].CommodityID < reportResponse.Commodities[j].CommodityID
+	})
+
+	return reportResponse, nil
+}
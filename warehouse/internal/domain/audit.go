@@ -0,0 +1,225 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	AuditEntityWarehouse = "warehouse"
+	AuditEntityBin       = "bin"
+	AuditEntitySKU       = "sku"
+	AuditEntityCommodity = "commodity"
+
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+
+	// Actor of changes made outside of a request
+	AuditActorSystem = "system"
+)
+
+// AuditEntry records one change, Before is empty for creates and After for
+// deletes. WarehouseID is zero for entities shared by every warehouse.
+type AuditEntry struct {
+	ID          int64
+	Actor       string
+	EntityType  string
+	EntityID    int64
+	WarehouseID int64
+	Action      string
+	Before      json.RawMessage
+	After       json.RawMessage
+	CreatedAt   time.Time
+}
+
+func (ae AuditEntry) AuditEntryResponse() AuditEntryResponse {
+	return AuditEntryResponse{
+		ID:          ae.ID,
+		Actor:       ae.Actor,
+		EntityType:  ae.EntityType,
+		EntityID:    ae.EntityID,
+		WarehouseID: ae.WarehouseID,
+		Action:      ae.Action,
+		Before:      ae.Before,
+		After:       ae.After,
+		CreatedAt:   ae.CreatedAt,
+	}
+}
+
+type AuditEntryResponse struct {
+	ID          int64           `json:"id"`
+	Actor       string          `json:"actor"`
+	EntityType  string          `json:"entity"`
+	EntityID    int64           `json:"entity_id"`
+	WarehouseID int64           `json:"warehouse_id,omitempty"`
+	Action      string          `json:"action"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// AuditDataParameter takes the snapshots as they are, the repository stores
+// them as JSON
+type AuditDataParameter struct {
+	Actor       string
+	EntityType  string
+	EntityID    int64
+	WarehouseID int64
+	Action      string
+	Before      interface{}
+	After       interface{}
+}
+
+// NewAuditDataParameter records a change made by the principal of ctx
+func NewAuditDataParameter(ctx context.Context, entityType string, entityID, warehouseID int64, action string, before, after interface{}) AuditDataParameter {
+	return AuditDataParameter{
+		Actor:       ActorFromContext(ctx),
+		EntityType:  entityType,
+		EntityID:    entityID,
+		WarehouseID: warehouseID,
+		Action:      action,
+		Before:      before,
+		After:       after,
+	}
+}
+
+// ActorFromContext names the principal of ctx as type:id
+func ActorFromContext(ctx context.Context) string {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return AuditActorSystem
+	}
+	return principal.Type + ":" + principal.ID
+}
+
+type AuditQueryParameter struct {
+	PaginationQuery
+	EntityType  []string
+	EntityID    []int64
+	WarehouseID []int64
+	Actor       []string
+	Action      []string
+	From        time.Time
+	To          time.Time
+}
+
+func (aq *AuditQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		aq.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		aq.Limit = i
+	}
+
+	if entities := uv["entity"]; len(entities) > 0 {
+		aq.EntityType = append(aq.EntityType, entities...)
+	}
+
+	// id is the ID of the audited entity, together with entity
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			aq.EntityID = append(aq.EntityID, i)
+		}
+	}
+
+	if whID := uv["warehouse_id"]; len(whID) > 0 {
+		for _, whID := range whID {
+			i, err := strconv.ParseInt(whID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Warehouse ID Parameter")
+			}
+
+			aq.WarehouseID = append(aq.WarehouseID, i)
+		}
+	}
+
+	if actors := uv["actor"]; len(actors) > 0 {
+		aq.Actor = append(aq.Actor, actors...)
+	}
+
+	if actions := uv["action"]; len(actions) > 0 {
+		aq.Action = append(aq.Action, actions...)
+	}
+
+	if from := uv.Get("from"); len(from) > 0 {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return errors.New("Invalid From Parameter, Use RFC3339")
+		}
+		aq.From = t
+	}
+
+	if to := uv.Get("to"); len(to) > 0 {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return errors.New("Invalid To Parameter, Use RFC3339")
+		}
+		aq.To = t
+	}
+
+	return nil
+}
+
+func (aq AuditQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = aq.generatePaginationQuery(sb)
+
+	if len(aq.EntityType) > 0 {
+		sb = sb.Where(squirrel.Eq{"entity_type": aq.EntityType})
+	}
+
+	if len(aq.EntityID) > 0 {
+		sb = sb.Where(squirrel.Eq{"entity_id": aq.EntityID})
+	}
+
+	if len(aq.WarehouseID) > 0 {
+		sb = sb.Where(squirrel.Eq{"warehouse_id": aq.WarehouseID})
+	}
+
+	if len(aq.Actor) > 0 {
+		sb = sb.Where(squirrel.Eq{"actor": aq.Actor})
+	}
+
+	if len(aq.Action) > 0 {
+		sb = sb.Where(squirrel.Eq{"action": aq.Action})
+	}
+
+	if !aq.From.IsZero() {
+		sb = sb.Where(squirrel.GtOrEq{"created_at": aq.From})
+	}
+
+	if !aq.To.IsZero() {
+		sb = sb.Where(squirrel.Lt{"created_at": aq.To})
+	}
+
+	return sb.OrderBy("id DESC")
+}
+
+type AuditRepository interface {
+	Create(ctx context.Context, data AuditDataParameter) error
+	Select(ctx context.Context, params AuditQueryParameter) ([]AuditEntry, error)
+}
+
+type AuditUsecase interface {
+	Select(ctx context.Context, params AuditQueryParameter) ([]AuditEntryResponse, error)
+}
@@ -0,0 +1,266 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	PrincipalTypeUser      = "user"
+	PrincipalTypeDevice    = "device"
+	PrincipalTypeAnonymous = "anonymous"
+
+	// API keys look like whk_<prefix>_<secret>, the prefix finds the key and
+	// only a hash of the whole key is stored
+	APIKeyScheme = "whk"
+
+	// HS256 secrets shorter than the hash are open to brute force
+	MinJWTSecretLength = 32
+)
+
+var (
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrInvalidAPIKey   = errors.New("invalid api key")
+	ErrInvalidToken    = errors.New("invalid bearer token")
+	ErrForbidden       = errors.New("not allowed")
+)
+
+type principalKey struct{}
+
+// Principal is who a request is made by, a user holding a JWT or a device
+// holding an API key.
+type Principal struct {
+	Type   string
+	ID     string
+	Name   string
+	Grants []Grant
+	// API key the device authenticated with, zero for users
+	APIKeyID int64
+}
+
+// WithPrincipal stores the principal of a request in its context
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal of a request, ok is false for
+// calls that did not pass authentication such as background jobs.
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+type AuthConfig struct {
+	Enabled bool
+	JWT     JWTConfig
+	// Paths served without authentication
+	PublicPaths []string
+}
+
+type JWTConfig struct {
+	// Required once Enabled, see Validate
+	Secret   string `secret:"true"`
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// Validate refuses to start authentication without a strong secret, an empty
+// one would reject every token and a short one can be guessed
+func (ac AuthConfig) Validate() error {
+	if ac.Enabled && len(ac.JWT.Secret) < MinJWTSecretLength {
+		return fmt.Errorf("JWT.Secret must be at least %d bytes when Enabled", MinJWTSecretLength)
+	}
+
+	return nil
+}
+
+type APIKey struct {
+	ID         int64
+	Name       string
+	Prefix     string
+	Hash       string
+	DeviceID   string
+	Admin      bool
+	Roles      []string
+	Version    int64
+	CreatedBy  string
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (ak APIKey) APIKeyResponse() APIKeyResponse {
+	return APIKeyResponse{
+		ID:         ak.ID,
+		Name:       ak.Name,
+		Prefix:     ak.Prefix,
+		DeviceID:   ak.DeviceID,
+		Admin:      ak.Admin,
+		Roles:      ak.Roles,
+		Version:    ak.Version,
+		CreatedBy:  ak.CreatedBy,
+		LastUsedAt: ak.LastUsedAt,
+		RevokedAt:  ak.RevokedAt,
+		CreatedAt:  ak.CreatedAt,
+		UpdatedAt:  ak.UpdatedAt,
+	}
+}
+
+// Principal is the device an API key authenticates, roles were validated
+// when the key was created so unknown ones are skipped
+func (ak APIKey) Principal() Principal {
+	principal := Principal{
+		Type:     PrincipalTypeDevice,
+		ID:       ak.DeviceID,
+		Name:     ak.Name,
+		APIKeyID: ak.ID,
+	}
+
+	if ak.Admin {
+		principal.Grants = append(principal.Grants, Grant{Role: RoleAdmin})
+	}
+	for _, role := range ak.Roles {
+		if grant, err := ParseGrant(role); err == nil {
+			principal.Grants = append(principal.Grants, grant)
+		}
+	}
+
+	return principal
+}
+
+type APIKeyResponse struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	DeviceID   string     `json:"device_id"`
+	Admin      bool       `json:"admin"`
+	Roles      []string   `json:"roles"`
+	Version    int64      `json:"version"`
+	CreatedBy  string     `json:"created_by"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// APIKeyCreatedResponse carries the key itself, it is only shown once
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type APIKeyDataParameter struct {
+	Name     string `json:"name" validate:"required"`
+	DeviceID string `json:"device_id" validate:"required"`
+	Admin    bool   `json:"admin"`
+	// Grants such as "operator:12", scanner-device everywhere when empty
+	Roles []string `json:"roles" validate:"dive,required"`
+
+	// Filled in by the usecase
+	Prefix    string `json:"-"`
+	Hash      string `json:"-"`
+	CreatedBy string `json:"-"`
+}
+
+// Credentials are what a request presents, at most one of them is set
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+}
+
+type APIKeyQueryParameter struct {
+	PaginationQuery
+	ID       []int64
+	DeviceID []string
+	Revoked  *bool
+}
+
+func (ak *APIKeyQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		ak.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		ak.Limit = i
+	}
+
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			ak.ID = append(ak.ID, i)
+		}
+	}
+
+	if deviceIDs := uv["device_id"]; len(deviceIDs) > 0 {
+		ak.DeviceID = append(ak.DeviceID, deviceIDs...)
+	}
+
+	if revoked := uv.Get("revoked"); len(revoked) > 0 {
+		b, err := strconv.ParseBool(revoked)
+		if err != nil {
+			return errors.New("Invalid Revoked Parameter")
+		}
+		ak.Revoked = &b
+	}
+
+	return nil
+}
+
+func (ak APIKeyQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = ak.generatePaginationQuery(sb)
+
+	if len(ak.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": ak.ID})
+	}
+
+	if len(ak.DeviceID) > 0 {
+		sb = sb.Where(squirrel.Eq{"device_id": ak.DeviceID})
+	}
+
+	if ak.Revoked != nil {
+		if *ak.Revoked {
+			sb = sb.Where(squirrel.NotEq{"revoked_at": nil})
+		} else {
+			sb = sb.Where(squirrel.Eq{"revoked_at": nil})
+		}
+	}
+
+	return sb.OrderBy("id DESC")
+}
+
+type APIKeyRepository interface {
+	Get(keyID int64) (APIKey, error)
+	GetByPrefix(prefix string) (APIKey, error)
+	Select(params APIKeyQueryParameter) ([]APIKey, error)
+	Create(data APIKeyDataParameter) (APIKey, error)
+	// Revoke keeps the time of an earlier revocation
+	Revoke(keyID, version int64) (APIKey, error)
+	Touch(keyID int64, usedAt time.Time) error
+}
+
+type AuthUsecase interface {
+	Authenticate(ctx context.Context, credentials Credentials) (Principal, error)
+	SelectKeys(ctx context.Context, params APIKeyQueryParameter) ([]APIKeyResponse, error)
+	CreateKey(ctx context.Context, data APIKeyDataParameter) (APIKeyCreatedResponse, error)
+	RevokeKey(ctx context.Context, keyID, version int64) (APIKeyResponse, error)
+}
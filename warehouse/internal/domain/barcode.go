@@ -1,6 +1,9 @@
 package domain
 
-import "os"
+import (
+	"context"
+	"os"
+)
 
 type BarcodeLambdaResponse struct {
 	Data []BarcodeLambda `json:"data"`
@@ -35,22 +38,125 @@ type WarehouseBarcodeResponse struct {
 	Barcodes []WarehouseBarcode `json:"Barcodes"`
 }
 
+const (
+	BarcodeStatusFound         = "found"
+	BarcodeStatusNotFound      = "not_found"
+	BarcodeStatusError         = "error"
+	BarcodeStatusLowConfidence = "low_confidence"
+)
+
 type WarehouseBarcode struct {
-	SKU      string          `json:"SKU"`
-	Geometry BarcodeGeometry `json:"Geometry"`
-	BinCode  string          `json:"BinCode,omitempty"`
-	Zone     string          `json:"Zone,omitempty"`
-	Error    string          `json:"Error,omitempty"`
+	SKU        string          `json:"SKU"`
+	Geometry   BarcodeGeometry `json:"Geometry"`
+	Confidence float64         `json:"Confidence"`
+	Status     string          `json:"Status"`
+	BinCode    string          `json:"BinCode,omitempty"`
+	Zone       string          `json:"Zone,omitempty"`
+	Error      string          `json:"Error,omitempty"`
+
+	// How many eaches the barcode stands for, a case barcode counts the whole case
+	PackLevel string `json:"PackLevel,omitempty"`
+	Quantity  int64  `json:"Quantity,omitempty"`
+
+	// Closest SKUs of the warehouse when the text was not found, never applied automatically
+	Candidates []BarcodeCandidate `json:"Candidates,omitempty"`
+
+	// The unit and its trail when the barcode identifies a serial number
+	SerialNumber *SerialNumberResponse `json:"SerialNumber,omitempty"`
+
+	// Filled when the barcode carries GS1 application identifiers
+	GTIN                   string                         `json:"GTIN,omitempty"`
+	Lot                    string                         `json:"Lot,omitempty"`
+	Expiry                 string                         `json:"Expiry,omitempty"`
+	Serial                 string                         `json:"Serial,omitempty"`
+	ApplicationIdentifiers []BarcodeApplicationIdentifier `json:"ApplicationIdentifiers,omitempty"`
+}
+
+type BarcodeCandidate struct {
+	SKU      string  `json:"SKU"`
+	Name     string  `json:"Name"`
+	BinCode  string  `json:"BinCode"`
+	Distance float64 `json:"Distance"`
+	Score    float64 `json:"Score"`
+}
+
+type BarcodeApplicationIdentifier struct {
+	AI    string `json:"AI"`
+	Title string `json:"Title"`
+	Value string `json:"Value"`
+}
+
+type BarcodeAnnotatedImage struct {
+	Data        []byte
+	ContentType string
+}
+
+type BinAuditResponse struct {
+	BinID       int64          `json:"BinID"`
+	BinCode     string         `json:"BinCode"`
+	WarehouseID int64          `json:"WarehouseID"`
+	Matched     []BinAuditItem `json:"Matched"`
+	Misplaced   []BinAuditItem `json:"Misplaced"`
+	Missing     []BinAuditItem `json:"Missing"`
+	Unknown     []BinAuditItem `json:"Unknown"`
+}
+
+type BinAuditItem struct {
+	SKU          string           `json:"SKU,omitempty"`
+	Name         string           `json:"Name,omitempty"`
+	DetectedText string           `json:"DetectedText,omitempty"`
+	Geometry     *BarcodeGeometry `json:"Geometry,omitempty"`
+	CorrectBin   string           `json:"CorrectBin,omitempty"`
+	Zone         string           `json:"Zone,omitempty"`
 }
 
 type BarcodeRepository interface {
+	// Decoder names the backend, it is stored with every scan
+	Decoder() string
 	ParseToLambda(file64 string) (BarcodeLambdaResponse, error)
 }
 
 type BarcodeRepositoryConfig struct {
-	LambdaURL string
+	LambdaURL string `validate:"required,url" secret:"true"`
+}
+
+type BarcodeUsecaseConfig struct {
+	LowConfidenceThreshold float64
+	Preprocess             BarcodePreprocessConfig
+	Fuzzy                  BarcodeFuzzyConfig
+}
+
+// BarcodeFuzzyConfig proposes SKUs for OCR text that matched nothing, only
+// SKUs stored in bins of the scanned warehouse are considered.
+type BarcodeFuzzyConfig struct {
+	Enabled       bool
+	MaxDistance   float64
+	MaxCandidates int
+}
+
+type BarcodePreprocessConfig struct {
+	Enabled           bool
+	AutoOrient        bool
+	MaxDimension      int
+	Grayscale         bool
+	NormalizeContrast bool
+	JPEGQuality       int
+	Tiling            BarcodeTilingConfig
+}
+
+type BarcodeTilingConfig struct {
+	Enabled bool
+	// Only images whose longest side exceeds MinDimension are tiled
+	MinDimension int
+	TileSize     int
+	Overlap      int
 }
 
 type BarcodeUsecase interface {
-	ParseBarcodeFromFileToLambda(file *os.File) ([]WarehouseBarcode, error)
+	ParseBarcodeFromFileToLambda(ctx context.Context, file *os.File, meta BarcodeScanMetadata) ([]WarehouseBarcode, error)
+	AuditBinFromFile(ctx context.Context, binID int64, file *os.File, meta BarcodeScanMetadata) (BinAuditResponse, error)
+	AnnotateImage(ctx context.Context, file *os.File, barcodes []WarehouseBarcode, format string) (BarcodeAnnotatedImage, error)
+	GetScan(ctx context.Context, scanID int64) (BarcodeScanResponse, error)
+	SelectScans(ctx context.Context, params BarcodeScanQueryParameter) ([]BarcodeScanResponse, error)
+	GetScanImage(ctx context.Context, scanID int64) (BarcodeScanImage, error)
 }
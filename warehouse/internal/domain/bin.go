@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -15,6 +16,15 @@ type Bin struct {
 	Name        string
 	Latitude    float64
 	Longitude   float64
+	ZoneID      string
+	Type        string
+	// Inner dimensions in millimetres, zero when unknown
+	LengthMM    float64
+	WidthMM     float64
+	HeightMM    float64
+	MaxWeightKg float64
+	MaxVolumeM3 float64
+	Version     int64
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
@@ -26,6 +36,14 @@ func (b Bin) BinResponse() BinResponse {
 		Name:        b.Name,
 		Latitude:    b.Latitude,
 		Longitude:   b.Longitude,
+		ZoneID:      b.ZoneID,
+		Type:        b.Type,
+		LengthMM:    b.LengthMM,
+		WidthMM:     b.WidthMM,
+		HeightMM:    b.HeightMM,
+		MaxWeightKg: b.MaxWeightKg,
+		MaxVolumeM3: b.VolumeCapacity(),
+		Version:     b.Version,
 		CreatedAt:   b.CreatedAt,
 		UpdatedAt:   b.UpdatedAt,
 	}
@@ -37,6 +55,14 @@ type BinResponse struct {
 	Name        string    `json:"name"`
 	Latitude    float64   `json:"latitude"`
 	Longitude   float64   `json:"longitude"`
+	ZoneID      string    `json:"zone_id"`
+	Type        string    `json:"type"`
+	LengthMM    float64   `json:"length_mm"`
+	WidthMM     float64   `json:"width_mm"`
+	HeightMM    float64   `json:"height_mm"`
+	MaxWeightKg float64   `json:"max_weight_kg"`
+	MaxVolumeM3 float64   `json:"max_volume_m3"`
+	Version     int64     `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -46,12 +72,42 @@ type BinDataParameter struct {
 	Name        string  `json:"name" validate:"required"`
 	Latitude    float64 `json:"latitude" validate:"required,latitude"`
 	Longitude   float64 `json:"longitude" validate:"required,longitude"`
+	ZoneID      string  `json:"zone_id"`
+	// Optional, defaults to shelf
+	Type        string  `json:"type" validate:"omitempty,oneof=shelf pallet floor cold"`
+	LengthMM    float64 `json:"length_mm" validate:"min=0"`
+	WidthMM     float64 `json:"width_mm" validate:"min=0"`
+	HeightMM    float64 `json:"height_mm" validate:"min=0"`
+	MaxWeightKg float64 `json:"max_weight_kg" validate:"min=0"`
+	// Optional, computed from the dimensions when left out
+	MaxVolumeM3 float64 `json:"max_volume_m3" validate:"min=0"`
+}
+
+// BinDataParameter is the write form of the bin, the document a merge patch
+// applies to
+func (br BinResponse) BinDataParameter() BinDataParameter {
+	return BinDataParameter{
+		WarehouseID: br.WarehouseID,
+		Name:        br.Name,
+		Latitude:    br.Latitude,
+		Longitude:   br.Longitude,
+		ZoneID:      br.ZoneID,
+		Type:        br.Type,
+		LengthMM:    br.LengthMM,
+		WidthMM:     br.WidthMM,
+		HeightMM:    br.HeightMM,
+		MaxWeightKg: br.MaxWeightKg,
+		MaxVolumeM3: br.MaxVolumeM3,
+	}
 }
 
 type BinQueryParameter struct {
 	PaginationQuery
 	ID          []int64
 	WarehouseID []int64
+	Name        []string
+	ZoneID      []string
+	Type        []string
 }
 
 func (wh *BinQueryParameter) Parse(uv url.Values) error {
@@ -93,6 +149,18 @@ func (wh *BinQueryParameter) Parse(uv url.Values) error {
 		}
 	}
 
+	if names := uv["name"]; len(names) > 0 {
+		wh.Name = append(wh.Name, names...)
+	}
+
+	if zoneIDs := uv["zone_id"]; len(zoneIDs) > 0 {
+		wh.ZoneID = append(wh.ZoneID, zoneIDs...)
+	}
+
+	if types := uv["type"]; len(types) > 0 {
+		wh.Type = append(wh.Type, types...)
+	}
+
 	return nil
 }
 
@@ -107,22 +175,38 @@ func (wh BinQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.Se
 		sb = sb.Where(squirrel.Eq{"warehouse_id": wh.WarehouseID})
 	}
 
+	if len(wh.Name) > 0 {
+		sb = sb.Where(squirrel.Eq{"name": wh.Name})
+	}
+
+	if len(wh.ZoneID) > 0 {
+		sb = sb.Where(squirrel.Eq{"zone_id": wh.ZoneID})
+	}
+
+	if len(wh.Type) > 0 {
+		sb = sb.Where(squirrel.Eq{"type": wh.Type})
+	}
+
 	return sb
 }
 
 type BinRepository interface {
-	Get(binID int64) (Bin, error)
-	GetByWarehouseID(warehouseID int64) ([]Bin, error)
-	Select(params BinQueryParameter) ([]Bin, error)
-	Create(data BinDataParameter) (Bin, error)
-	Update(binID int64, data BinDataParameter) (Bin, error)
-	Delete(binID int64) error
+	Get(ctx context.Context, binID int64) (Bin, error)
+	GetByWarehouseID(ctx context.Context, warehouseID int64) ([]Bin, error)
+	Select(ctx context.Context, params BinQueryParameter) ([]Bin, error)
+	Create(ctx context.Context, data BinDataParameter) (Bin, error)
+	Update(ctx context.Context, binID, version int64, data BinDataParameter) (Bin, error)
+	Patch(ctx context.Context, binID, version int64, data BinDataParameter, fields []string) (Bin, error)
+	Delete(ctx context.Context, binID, version int64) error
 }
 
 type BinUsecase interface {
-	Get(binID int64) (BinResponse, error)
-	Select(params BinQueryParameter) ([]BinResponse, error)
-	Create(data BinDataParameter) (BinResponse, error)
-	Update(binID int64, data BinDataParameter) (BinResponse, error)
-	Delete(binID int64) (GenericResponse, error)
+	Get(ctx context.Context, binID int64) (BinResponse, error)
+	Select(ctx context.Context, params BinQueryParameter) ([]BinResponse, error)
+	Create(ctx context.Context, data BinDataParameter) (BinResponse, error)
+	Update(ctx context.Context, binID, version int64, data BinDataParameter) (BinResponse, error)
+	Patch(ctx context.Context, binID, version int64, data BinDataParameter, fields []string) (BinResponse, error)
+	Delete(ctx context.Context, binID, version int64) (GenericResponse, error)
+	Occupancy(ctx context.Context, binID int64) (BinOccupancyResponse, error)
+	Utilization(ctx context.Context, warehouseID int64) (WarehouseUtilizationResponse, error)
 }
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -13,8 +14,16 @@ type Commodity struct {
 	ID          int64
 	Name        string
 	Description string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// UN hazard class 1 to 9, empty for goods that are not dangerous
+	HazmatClass string
+	// Storage temperature range in celsius, nil when unbounded
+	MinTempC  *float64
+	MaxTempC  *float64
+	Fragile   bool
+	Stackable bool
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 func (c Commodity) CommodityResponse() CommodityResponse {
@@ -22,6 +31,12 @@ func (c Commodity) CommodityResponse() CommodityResponse {
 		ID:          c.ID,
 		Name:        c.Name,
 		Description: c.Description,
+		HazmatClass: c.HazmatClass,
+		MinTempC:    c.MinTempC,
+		MaxTempC:    c.MaxTempC,
+		Fragile:     c.Fragile,
+		Stackable:   c.Stackable,
+		Version:     c.Version,
 		CreatedAt:   c.CreatedAt,
 		UpdatedAt:   c.UpdatedAt,
 	}
@@ -31,13 +46,46 @@ type CommodityResponse struct {
 	ID          int64     `json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
+	HazmatClass string    `json:"hazmat_class"`
+	MinTempC    *float64  `json:"min_temp_c"`
+	MaxTempC    *float64  `json:"max_temp_c"`
+	Fragile     bool      `json:"fragile"`
+	Stackable   bool      `json:"stackable"`
+	Version     int64     `json:"version"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type CommodityDataParameter struct {
-	Name        string `json:"name" validate:"required"`
-	Description string `json:"description" validate:"required"`
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description" validate:"required"`
+	HazmatClass string   `json:"hazmat_class" validate:"omitempty,oneof=1 2 3 4 5 6 7 8 9"`
+	MinTempC    *float64 `json:"min_temp_c"`
+	MaxTempC    *float64 `json:"max_temp_c"`
+	Fragile     bool     `json:"fragile"`
+	// Optional, goods are stackable unless told otherwise
+	Stackable *bool `json:"stackable"`
+}
+
+// CommodityDataParameter is the write form of the commodity, the document a
+// merge patch applies to
+func (cr CommodityResponse) CommodityDataParameter() CommodityDataParameter {
+	stackable := cr.Stackable
+
+	return CommodityDataParameter{
+		Name:        cr.Name,
+		Description: cr.Description,
+		HazmatClass: cr.HazmatClass,
+		MinTempC:    cr.MinTempC,
+		MaxTempC:    cr.MaxTempC,
+		Fragile:     cr.Fragile,
+		Stackable:   &stackable,
+	}
+}
+
+// IsStackable reads Stackable with its default
+func (cd CommodityDataParameter) IsStackable() bool {
+	return cd.Stackable == nil || *cd.Stackable
 }
 
 type CommodityQueryParameter struct {
@@ -87,17 +135,21 @@ func (wh CommodityQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squir
 }
 
 type CommodityRepository interface {
-	Get(commodityID int64) (Commodity, error)
-	Select(params CommodityQueryParameter) ([]Commodity, error)
-	Create(data CommodityDataParameter) (Commodity, error)
-	Update(commodityID int64, data CommodityDataParameter) (Commodity, error)
-	Delete(commodityID int64) error
+	Get(ctx context.Context, commodityID int64) (Commodity, error)
+	Select(ctx context.Context, params CommodityQueryParameter) ([]Commodity, error)
+	Create(ctx context.Context, data CommodityDataParameter) (Commodity, error)
+	Update(ctx context.Context, commodityID, version int64, data CommodityDataParameter) (Commodity, error)
+	Patch(ctx context.Context, commodityID, version int64, data CommodityDataParameter, fields []string) (Commodity, error)
+	Delete(ctx context.Context, commodityID, version int64) error
 }
 
 type CommodityUsecase interface {
-	Get(commodityID int64) (CommodityResponse, error)
-	Select(params CommodityQueryParameter) ([]CommodityResponse, error)
-	Create(data CommodityDataParameter) (CommodityResponse, error)
-	Update(commodityID int64, data CommodityDataParameter) (CommodityResponse, error)
-	Delete(commodityID int64) (GenericResponse, error)
+	Get(ctx context.Context, commodityID int64) (CommodityResponse, error)
+	Select(ctx context.Context, params CommodityQueryParameter) ([]CommodityResponse, error)
+	Create(ctx context.Context, data CommodityDataParameter) (CommodityResponse, error)
+	Update(ctx context.Context, commodityID, version int64, data CommodityDataParameter) (CommodityResponse, error)
+	Patch(ctx context.Context, commodityID, version int64, data CommodityDataParameter, fields []string) (CommodityResponse, error)
+	Delete(ctx context.Context, commodityID, version int64) (GenericResponse, error)
+	SelectSKUs(ctx context.Context, commodityID int64, params SKUQueryParameter) ([]SKUResponse, error)
+	Report(ctx context.Context, warehouseID int64) (CommodityReportResponse, error)
 }
@@ -1,6 +1,78 @@
 package domain
 
-import "github.com/Masterminds/squirrel"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var (
+	// ErrVersionConflict means the entity changed since the version the
+	// caller last read
+	ErrVersionConflict = errors.New("version has changed")
+	// ErrConflict means a unique field is already taken by another record,
+	// repositories return it as a *ConflictError
+	ErrConflict = errors.New("already exists")
+)
+
+// CheckVersion tells a stale version from a missing entity when a versioned
+// write matched no row, exists returns the not found error of the entity
+func CheckVersion(result sql.Result, exists func() error) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	if err := exists(); err != nil {
+		return err
+	}
+
+	return ErrVersionConflict
+}
+
+// ConflictError names the record already holding the unique fields a write
+// tried to take
+type ConflictError struct {
+	Entity     string
+	Fields     map[string]interface{}
+	ExistingID int64
+}
+
+func (ce *ConflictError) Error() string {
+	fields := make([]string, 0, len(ce.Fields))
+	for field := range ce.Fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	return fmt.Sprintf("%s %d already has the same %s", ce.Entity, ce.ExistingID, strings.Join(fields, ", "))
+}
+
+func (ce *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+func (ce *ConflictError) ConflictResponse() ConflictResponse {
+	return ConflictResponse{
+		Entity:     ce.Entity,
+		Fields:     ce.Fields,
+		ExistingID: ce.ExistingID,
+	}
+}
+
+type ConflictResponse struct {
+	Entity     string                 `json:"entity"`
+	Fields     map[string]interface{} `json:"fields"`
+	ExistingID int64                  `json:"existing_id"`
+}
 
 type PaginationQuery struct {
 	Page  int64
@@ -25,3 +97,9 @@ func (pg PaginationQuery) generatePaginationQuery(sb squirrel.SelectBuilder) squ
 	sb = sb.Limit(uint64(pg.Limit)).Offset(uint64(offset))
 	return sb
 }
+
+// Transactor runs fn in a database transaction carried by the context it is
+// given, repositories called with that context join it
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
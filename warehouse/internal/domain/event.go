@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventWarehouseCreated = "warehouse.created"
+	EventWarehouseUpdated = "warehouse.updated"
+	EventWarehouseDeleted = "warehouse.deleted"
+	EventBinCreated       = "bin.created"
+	EventBinUpdated       = "bin.updated"
+	EventBinDeleted       = "bin.deleted"
+	EventSKUCreated       = "sku.created"
+	EventSKUUpdated       = "sku.updated"
+	EventSKUDeleted       = "sku.deleted"
+	EventSKURelocated     = "sku.relocated"
+	EventCommodityCreated = "commodity.created"
+	EventCommodityUpdated = "commodity.updated"
+	EventCommodityDeleted = "commodity.deleted"
+	EventBarcodeScanned   = "barcode.scanned"
+)
+
+var (
+	// EventTypes lists every event the outbox publishes
+	EventTypes = []string{
+		EventWarehouseCreated,
+		EventWarehouseUpdated,
+		EventWarehouseDeleted,
+		EventBinCreated,
+		EventBinUpdated,
+		EventBinDeleted,
+		EventSKUCreated,
+		EventSKUUpdated,
+		EventSKUDeleted,
+		EventSKURelocated,
+		EventCommodityCreated,
+		EventCommodityUpdated,
+		EventCommodityDeleted,
+		EventBarcodeScanned,
+	}
+)
+
+// Event is a change written to the outbox together with the change itself,
+// the dispatcher publishes it afterwards. Consumers get every event at least
+// once and should drop IDs they have already seen.
+type Event struct {
+	ID          int64
+	Type        string
+	EntityID    int64
+	WarehouseID int64
+	Payload     json.RawMessage
+	Attempts    int
+	LastError   string
+	PublishedAt *time.Time
+	CreatedAt   time.Time
+}
+
+// EventResponse is the envelope sinks receive
+func (e Event) EventResponse() EventResponse {
+	return EventResponse{
+		ID:          e.ID,
+		Type:        e.Type,
+		EntityID:    e.EntityID,
+		WarehouseID: e.WarehouseID,
+		Data:        e.Payload,
+		OccurredAt:  e.CreatedAt,
+	}
+}
+
+type EventResponse struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	EntityID    int64           `json:"entity_id"`
+	WarehouseID int64           `json:"warehouse_id,omitempty"`
+	Data        json.RawMessage `json:"data"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+}
+
+type EventDataParameter struct {
+	Type        string
+	EntityID    int64
+	WarehouseID int64
+	Payload     interface{}
+}
+
+func NewEventDataParameter(eventType string, entityID, warehouseID int64, payload interface{}) EventDataParameter {
+	return EventDataParameter{
+		Type:        eventType,
+		EntityID:    entityID,
+		WarehouseID: warehouseID,
+		Payload:     payload,
+	}
+}
+
+// SKURelocatedPayload is the data of sku.relocated, sent besides sku.updated
+// when an SKU moves to another warehouse or bin
+type SKURelocatedPayload struct {
+	SKU  SKUResponse `json:"sku"`
+	From SKULocation `json:"from"`
+	To   SKULocation `json:"to"`
+}
+
+type SKULocation struct {
+	WHCode  string `json:"wh_code"`
+	BinCode string `json:"bin_code"`
+}
+
+type OutboxConfig struct {
+	Enabled bool
+	// How often pending events are looked for
+	Interval    time.Duration `validate:"min=0"`
+	BatchSize   int           `validate:"min=0"`
+	MaxAttempts int           `validate:"min=0"`
+	// Events handed to the network sinks are skipped by other dispatchers
+	// for Lease, it should outlast publishing a whole batch
+	Lease time.Duration `validate:"min=0"`
+	// Every event is posted to these URLs
+	Webhooks []string `validate:"dive,url"`
+}
+
+type OutboxRepository interface {
+	Create(ctx context.Context, data EventDataParameter) error
+	// SelectPending locks the events it returns until the transaction of ctx
+	// ends, other dispatchers skip them and events claimed past now
+	SelectPending(ctx context.Context, now time.Time, limit, maxAttempts int) ([]Event, error)
+	// Claim keeps other dispatchers off the events until the given time
+	Claim(ctx context.Context, eventIDs []int64, until time.Time) error
+	// Release hands claimed events back without counting an attempt
+	Release(ctx context.Context, eventIDs []int64) error
+	// MarkPublished and MarkFailed release the claim on the event as well
+	MarkPublished(ctx context.Context, eventID int64, publishedAt time.Time) error
+	MarkFailed(ctx context.Context, eventID int64, reason string) error
+}
+
+type OutboxUsecase interface {
+	// Dispatch publishes one batch of pending events
+	Dispatch(ctx context.Context) (int, error)
+	// Run dispatches until ctx is done
+	Run(ctx context.Context)
+}
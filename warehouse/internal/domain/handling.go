@@ -0,0 +1,155 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidTempRange  = errors.New("minimum temperature must not be above the maximum")
+	ErrCommodityInUse    = errors.New("commodity is still assigned to SKUs")
+	ErrHandlingTemp      = errors.New("bin temperature is outside the range of the commodity")
+	ErrHandlingHazmat    = errors.New("hazardous goods cannot share a bin with other commodities")
+	ErrHandlingFragile   = errors.New("fragile goods cannot be stored in floor bins")
+	ErrHandlingStackable = errors.New("goods that cannot be stacked need a bin of their own")
+	ErrHandlingNoBin     = errors.New("goods with handling rules must be assigned to an existing warehouse and bin")
+)
+
+var (
+	// Temperatures in celsius bins are kept at, by bin type
+	binTemperatureRanges    = map[string][2]float64{BinTypeCold: {2, 8}}
+	ambientTemperatureRange = [2]float64{15, 25}
+)
+
+// BinTemperatureRange is the range a bin type is kept at, cold bins are
+// chilled and every other bin is at ambient temperature.
+func BinTemperatureRange(binType string) (float64, float64) {
+	if r, ok := binTemperatureRanges[binType]; ok {
+		return r[0], r[1]
+	}
+	return ambientTemperatureRange[0], ambientTemperatureRange[1]
+}
+
+// ValidateTempRange checks the temperature range of a commodity
+func (cd CommodityDataParameter) ValidateTempRange() error {
+	if cd.MinTempC != nil && cd.MaxTempC != nil && *cd.MinTempC > *cd.MaxTempC {
+		return ErrInvalidTempRange
+	}
+	return nil
+}
+
+// CheckHandling tells whether goods of a commodity may go into a bin that
+// already holds goods of the neighbour commodities. Goods without commodity
+// are passed as a zero Commodity, which carries no rules.
+func CheckHandling(commodity Commodity, bin Bin, neighbours []Commodity) error {
+	minTemp, maxTemp := BinTemperatureRange(bin.Type)
+	if commodity.MinTempC != nil && minTemp < *commodity.MinTempC {
+		return ErrHandlingTemp
+	}
+	if commodity.MaxTempC != nil && maxTemp > *commodity.MaxTempC {
+		return ErrHandlingTemp
+	}
+
+	if commodity.Fragile && bin.Type == BinTypeFloor {
+		return ErrHandlingFragile
+	}
+
+	for _, neighbour := range neighbours {
+		if !commodity.stackable() || !neighbour.stackable() {
+			return ErrHandlingStackable
+		}
+
+		hazmat := len(commodity.HazmatClass) > 0 || len(neighbour.HazmatClass) > 0
+		if hazmat && neighbour.ID != commodity.ID {
+			return ErrHandlingHazmat
+		}
+	}
+
+	return nil
+}
+
+// CheckBinHandling is CheckHandling for goods of commodityID going into a bin
+// holding goods of neighbourIDs, every commodity is loaded with one query.
+// Commodity 0 stands for goods without commodity.
+func CheckBinHandling(ctx context.Context, commodities CommodityRepository, commodityID int64, bin Bin, neighbourIDs []int64) error {
+	commoditiesData, err := LoadCommodities(ctx, commodities, append([]int64{commodityID}, neighbourIDs...))
+	if err != nil {
+		return err
+	}
+
+	neighbours := make([]Commodity, 0, len(neighbourIDs))
+	for _, neighbourID := range neighbourIDs {
+		neighbours = append(neighbours, commoditiesData[neighbourID])
+	}
+
+	return CheckHandling(commoditiesData[commodityID], bin, neighbours)
+}
+
+// LoadCommodities selects the given commodities at once keyed by their ID, 0
+// maps to the zero Commodity. A missing one is reported as sql.ErrNoRows.
+func LoadCommodities(ctx context.Context, commodities CommodityRepository, commodityIDs []int64) (map[int64]Commodity, error) {
+	var (
+		commoditiesData = map[int64]Commodity{0: {}}
+		ids             []int64
+	)
+
+	for _, id := range commodityIDs {
+		if _, found := commoditiesData[id]; !found {
+			commoditiesData[id] = Commodity{}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 1 {
+		return commoditiesData, nil
+	}
+
+	commodityList, err := commodities.Select(ctx, CommodityQueryParameter{
+		PaginationQuery: PaginationQuery{Limit: int64(len(ids))},
+		ID:              ids,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, commodity := range commodityList {
+		commoditiesData[commodity.ID] = commodity
+	}
+	for _, id := range ids {
+		if commoditiesData[id].ID != id {
+			return nil, fmt.Errorf("commodity %d: %w", id, sql.ErrNoRows)
+		}
+	}
+
+	return commoditiesData, nil
+}
+
+// HasHandlingRules reports whether the commodity limits the bins its goods
+// may go into
+func (c Commodity) HasHandlingRules() bool {
+	return c.MinTempC != nil || c.MaxTempC != nil || c.Fragile || !c.stackable() || len(c.HazmatClass) > 0
+}
+
+// Goods without commodity can always be stacked
+func (c Commodity) stackable() bool {
+	return c.ID < 1 || c.Stackable
+}
+
+// CommodityReportResponse sums up the stock of a warehouse per commodity,
+// goods without commodity are reported under commodity 0.
+type CommodityReportResponse struct {
+	WarehouseID int64                    `json:"warehouse_id"`
+	Commodities []CommodityStockResponse `json:"commodities"`
+}
+
+type CommodityStockResponse struct {
+	CommodityID   int64   `json:"commodity_id"`
+	CommodityName string  `json:"commodity_name"`
+	HazmatClass   string  `json:"hazmat_class"`
+	SKUs          int64   `json:"skus"`
+	Bins          int64   `json:"bins"`
+	Units         int64   `json:"units"`
+	VolumeM3      float64 `json:"volume_m3"`
+	WeightKg      float64 `json:"weight_kg"`
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// fakeCommodities embeds the interface for the methods the tests never call
+type fakeCommodities struct {
+	CommodityRepository
+	commodities []Commodity
+	selects     int
+}
+
+func (f *fakeCommodities) Select(ctx context.Context, params CommodityQueryParameter) ([]Commodity, error) {
+	f.selects++
+
+	var commoditiesData []Commodity
+	for _, commodity := range f.commodities {
+		for _, id := range params.ID {
+			if commodity.ID == id {
+				commoditiesData = append(commoditiesData, commodity)
+			}
+		}
+	}
+	return commoditiesData, nil
+}
+
+func TestCheckBinHandling(t *testing.T) {
+	commodities := &fakeCommodities{commodities: []Commodity{
+		{ID: 1, Stackable: true},
+		{ID: 2, Stackable: true, HazmatClass: "3"},
+		{ID: 3, Stackable: true, Fragile: true},
+	}}
+	shelf := Bin{Type: BinTypeShelf}
+
+	tests := []struct {
+		name         string
+		commodityID  int64
+		bin          Bin
+		neighbourIDs []int64
+		want         error
+	}{
+		{"empty bin", 2, shelf, nil, nil},
+		{"same commodity", 1, shelf, []int64{1, 1}, nil},
+		{"goods without commodity", 0, shelf, []int64{0, 1}, nil},
+		{"hazardous neighbour", 1, shelf, []int64{0, 2}, ErrHandlingHazmat},
+		{"fragile on the floor", 3, Bin{Type: BinTypeFloor}, nil, ErrHandlingFragile},
+		{"missing commodity", 1, shelf, []int64{9}, sql.ErrNoRows},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commodities.selects = 0
+			err := CheckBinHandling(context.Background(), commodities, tt.commodityID, tt.bin, tt.neighbourIDs)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+			if commodities.selects > 1 {
+				t.Errorf("commodities selected %d times, want at most once", commodities.selects)
+			}
+		})
+	}
+}
+
+func TestHasHandlingRules(t *testing.T) {
+	minTemp := 2.0
+
+	tests := []struct {
+		name      string
+		commodity Commodity
+		want      bool
+	}{
+		{"no commodity", Commodity{}, false},
+		{"plain goods", Commodity{ID: 1, Stackable: true}, false},
+		{"not stackable", Commodity{ID: 1}, true},
+		{"chilled", Commodity{ID: 1, Stackable: true, MinTempC: &minTemp}, true},
+		{"hazardous", Commodity{ID: 1, Stackable: true, HazmatClass: "3"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.commodity.HasHandlingRules(); got != tt.want {
+				t.Errorf("HasHandlingRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// Set on responses replayed from an earlier request with the same key
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	IdempotencyStoreSQL    = "sql"
+	IdempotencyStoreMemory = "memory"
+)
+
+var (
+	ErrIdempotencyKeyInvalid  = errors.New("idempotency key must be 1 to 255 printable characters")
+	ErrIdempotencyInProgress  = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was already used with a different request")
+)
+
+type IdempotencyConfig struct {
+	Enabled bool
+	// Where responses are kept, only "sql" shares them between instances
+	Store string `validate:"omitempty,oneof=sql memory"`
+	// How long a response is replayed for
+	TTL time.Duration `validate:"min=0"`
+	// How long a key stays reserved for a request in progress, it should
+	// outlast the request timeout. A crashed request frees its key after it.
+	Lease time.Duration `validate:"min=0"`
+	// How often expired keys are purged
+	Interval time.Duration `validate:"min=0"`
+}
+
+// IdempotencyRecord is the first response to a request sent with an
+// idempotency key, it has no status code while that request is in progress
+// and expires with the lease until then
+type IdempotencyRecord struct {
+	// Hash of the key scoped to the principal that sent it
+	Key string
+	// Hash of the method, path and body of the request
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+}
+
+func (ir IdempotencyRecord) Completed() bool {
+	return ir.StatusCode > 0
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores record unless an unexpired record holds its key, that
+	// record is returned instead and reserved is false
+	Reserve(ctx context.Context, record IdempotencyRecord) (stored IdempotencyRecord, reserved bool, err error)
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyUsecase interface {
+	// Begin reserves key for the request with the given fingerprint. The
+	// record returned is completed when the same request was answered before
+	// and its response should be replayed.
+	Begin(ctx context.Context, key, fingerprint string) (IdempotencyRecord, error)
+	// Complete stores the response of a reserved request and keeps it for the
+	// TTL
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// Release frees a reserved key so the request can be retried
+	Release(ctx context.Context, record IdempotencyRecord) error
+	// Run purges expired keys until ctx is done
+	Run(ctx context.Context)
+}
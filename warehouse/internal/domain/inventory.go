@@ -0,0 +1,322 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	// Window used by the expiring report when none is given
+	DefaultExpiringWithin = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInsufficientStock = errors.New("not enough stock")
+	ErrInvalidQuantity   = errors.New("quantity must not be zero")
+)
+
+// StockBalance is the quantity of one lot of a SKU held in one bin
+type StockBalance struct {
+	ID          int64
+	SKUID       int64
+	SKU         string
+	LotID       int64
+	LotNumber   string
+	ExpiresAt   *time.Time
+	BinID       int64
+	BinCode     string
+	WarehouseID int64
+	Quantity    int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (sb StockBalance) StockBalanceResponse() StockBalanceResponse {
+	return StockBalanceResponse{
+		ID:          sb.ID,
+		SKUID:       sb.SKUID,
+		SKU:         sb.SKU,
+		LotID:       sb.LotID,
+		LotNumber:   sb.LotNumber,
+		ExpiresAt:   formatLotDate(sb.ExpiresAt),
+		BinID:       sb.BinID,
+		BinCode:     sb.BinCode,
+		WarehouseID: sb.WarehouseID,
+		Quantity:    sb.Quantity,
+		CreatedAt:   sb.CreatedAt,
+		UpdatedAt:   sb.UpdatedAt,
+	}
+}
+
+type StockBalanceResponse struct {
+	ID          int64     `json:"id"`
+	SKUID       int64     `json:"sku_id"`
+	SKU         string    `json:"sku"`
+	LotID       int64     `json:"lot_id"`
+	LotNumber   string    `json:"lot_number"`
+	ExpiresAt   string    `json:"expires_at,omitempty"`
+	BinID       int64     `json:"bin_id"`
+	BinCode     string    `json:"bin_code"`
+	WarehouseID int64     `json:"warehouse_id"`
+	Quantity    int64     `json:"quantity"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// StockAdjustParameter adds stock to a balance, or removes it when Quantity is
+// negative. Quantity counts packs of PackLevel, base units when it is empty.
+type StockAdjustParameter struct {
+	SKUID     int64  `json:"sku_id" validate:"required"`
+	LotID     int64  `json:"lot_id" validate:"required"`
+	BinID     int64  `json:"bin_id" validate:"required"`
+	Quantity  int64  `json:"quantity" validate:"required"`
+	PackLevel string `json:"pack_level" validate:"omitempty,oneof=each inner case pallet"`
+}
+
+type StockAllocateParameter struct {
+	SKUID       int64  `json:"sku_id" validate:"required"`
+	WarehouseID int64  `json:"warehouse_id"`
+	Quantity    int64  `json:"quantity" validate:"required,min=1"`
+	PackLevel   string `json:"pack_level" validate:"omitempty,oneof=each inner case pallet"`
+	// Commit takes the picked quantities off the balances, otherwise the
+	// allocation is only a proposal
+	Commit bool `json:"commit"`
+}
+
+type StockPick struct {
+	BalanceID int64
+	Quantity  int64
+}
+
+type StockPickResponse struct {
+	BalanceID int64  `json:"balance_id"`
+	LotID     int64  `json:"lot_id"`
+	LotNumber string `json:"lot_number"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	BinID     int64  `json:"bin_id"`
+	BinCode   string `json:"bin_code"`
+	Quantity  int64  `json:"quantity"`
+}
+
+// Quantities of an allocation are in base units
+type StockAllocationResponse struct {
+	SKUID     int64               `json:"sku_id"`
+	BaseUoM   string              `json:"base_uom"`
+	Requested int64               `json:"requested"`
+	Allocated int64               `json:"allocated"`
+	Committed bool                `json:"committed"`
+	Picks     []StockPickResponse `json:"picks"`
+}
+
+type ExpiringStockResponse struct {
+	WarehouseID   int64                  `json:"warehouse_id"`
+	WarehouseName string                 `json:"warehouse_name"`
+	Items         []ExpiringItemResponse `json:"items"`
+}
+
+type ExpiringItemResponse struct {
+	StockBalanceResponse
+	DaysToExpiry int64 `json:"days_to_expiry"`
+	Expired      bool  `json:"expired"`
+}
+
+type StockBalanceQueryParameter struct {
+	PaginationQuery
+	SKUID       []int64
+	LotID       []int64
+	BinID       []int64
+	WarehouseID []int64
+	InStock     bool
+	// Lots expiring at or before ExpiresBefore, lots without expiry are left out
+	ExpiresBefore time.Time
+	// Lots that have not expired yet at ExpiresAfter, lots without expiry are kept
+	ExpiresAfter time.Time
+}
+
+func (sq *StockBalanceQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		sq.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		sq.Limit = i
+	}
+
+	for key, target := range map[string]*[]int64{
+		"sku_id":       &sq.SKUID,
+		"lot_id":       &sq.LotID,
+		"bin_id":       &sq.BinID,
+		"warehouse_id": &sq.WarehouseID,
+	} {
+		for _, value := range uv[key] {
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return errors.New("Invalid " + key + " Parameter")
+			}
+
+			*target = append(*target, i)
+		}
+	}
+
+	if inStock := uv.Get("in_stock"); len(inStock) > 0 {
+		b, err := strconv.ParseBool(inStock)
+		if err != nil {
+			return errors.New("Invalid In Stock Parameter")
+		}
+		sq.InStock = b
+	}
+
+	return nil
+}
+
+// BuildSQLQuery expects stock_balances aliased as sb, lots as l and bins as b,
+// rows come in FEFO order: earliest expiry first, lots without expiry last.
+func (sq StockBalanceQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = sq.generatePaginationQuery(sb)
+
+	if len(sq.SKUID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sb.sku_id": sq.SKUID})
+	}
+
+	if len(sq.LotID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sb.lot_id": sq.LotID})
+	}
+
+	if len(sq.BinID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sb.bin_id": sq.BinID})
+	}
+
+	if len(sq.WarehouseID) > 0 {
+		sb = sb.Where(squirrel.Eq{"b.warehouse_id": sq.WarehouseID})
+	}
+
+	if sq.InStock {
+		sb = sb.Where(squirrel.Gt{"sb.quantity": 0})
+	}
+
+	if !sq.ExpiresBefore.IsZero() {
+		sb = sb.Where(squirrel.And{
+			squirrel.NotEq{"l.expires_at": nil},
+			squirrel.LtOrEq{"l.expires_at": sq.ExpiresBefore},
+		})
+	}
+
+	if !sq.ExpiresAfter.IsZero() {
+		sb = sb.Where(squirrel.Or{
+			squirrel.Eq{"l.expires_at": nil},
+			squirrel.GtOrEq{"l.expires_at": sq.ExpiresAfter},
+		})
+	}
+
+	return sb.OrderBy("l.expires_at IS NULL", "l.expires_at ASC", "sb.id ASC")
+}
+
+type ExpiringQueryParameter struct {
+	Within      time.Duration
+	WarehouseID []int64
+}
+
+func (eq *ExpiringQueryParameter) Parse(uv url.Values) error {
+	eq.Within = DefaultExpiringWithin
+
+	if within := uv.Get("within"); len(within) > 0 {
+		d, err := ParseWithin(within)
+		if err != nil {
+			return err
+		}
+		eq.Within = d
+	}
+
+	for _, value := range uv["warehouse_id"] {
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Warehouse ID Parameter")
+		}
+
+		eq.WarehouseID = append(eq.WarehouseID, i)
+	}
+
+	return nil
+}
+
+// ParseWithin reads a window such as "30d" or "2w", falling back to Go durations like "36h"
+func ParseWithin(value string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.ParseInt(strings.TrimSuffix(value, suffix), 10, 64)
+			if err != nil || n < 0 {
+				return 0, errors.New("Invalid Within Parameter")
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, errors.New("Invalid Within Parameter")
+	}
+
+	return d, nil
+}
+
+type StockRepository interface {
+	Select(params StockBalanceQueryParameter) ([]StockBalance, error)
+	Adjust(data StockAdjustParameter) (StockBalance, error)
+	Allocate(picks []StockPick) error
+}
+
+const (
+	stockBalancePageSize = 100
+)
+
+// SelectAllBalances walks every page of the stock balances matching params,
+// the page of params is ignored and balances keep the order of Select
+func SelectAllBalances(stock StockRepository, params StockBalanceQueryParameter) ([]StockBalance, error) {
+	var (
+		balancesData []StockBalance
+	)
+
+	params.Limit = stockBalancePageSize
+	params.Page = 1
+
+	for {
+		page, err := stock.Select(params)
+		if err != nil {
+			return balancesData, err
+		}
+
+		balancesData = append(balancesData, page...)
+		if int64(len(page)) < params.Limit {
+			break
+		}
+		params.Page++
+	}
+
+	return balancesData, nil
+}
+
+type InventoryUsecase interface {
+	Select(ctx context.Context, params StockBalanceQueryParameter) ([]StockBalanceResponse, error)
+	Adjust(ctx context.Context, data StockAdjustParameter) (StockBalanceResponse, error)
+	Allocate(ctx context.Context, data StockAllocateParameter) (StockAllocationResponse, error)
+	Expiring(ctx context.Context, params ExpiringQueryParameter) ([]ExpiringStockResponse, error)
+}
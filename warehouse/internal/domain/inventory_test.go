@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseWithin(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"0d", 0, false},
+		{"36h", 36 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"-1d", 0, true},
+		{"-2h", 0, true},
+		{"1.5d", 0, true},
+		{"d", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseWithin(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseWithin(%s) err = %v", tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseWithin(%s) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestExpiringQueryParameterParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		query           string
+		wantWithin      time.Duration
+		wantWarehouseID []int64
+		wantErr         bool
+	}{
+		{"defaults", "", DefaultExpiringWithin, nil, false},
+		{"within and warehouses", "within=1w&warehouse_id=3&warehouse_id=4", 7 * 24 * time.Hour, []int64{3, 4}, false},
+		{"invalid within", "within=later", 0, nil, true},
+		{"invalid warehouse", "warehouse_id=main", 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uv, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var params ExpiringQueryParameter
+			err = params.Parse(uv)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if params.Within != tt.wantWithin {
+				t.Errorf("within = %v, want %v", params.Within, tt.wantWithin)
+			}
+			if len(params.WarehouseID) != len(tt.wantWarehouseID) {
+				t.Fatalf("warehouse ids = %v, want %v", params.WarehouseID, tt.wantWarehouseID)
+			}
+			for i := range tt.wantWarehouseID {
+				if params.WarehouseID[i] != tt.wantWarehouseID[i] {
+					t.Errorf("warehouse ids = %v, want %v", params.WarehouseID, tt.wantWarehouseID)
+				}
+			}
+		})
+	}
+}
+
+// fakeStock pages through balances the way the repository does
+type fakeStock struct {
+	StockRepository
+	balances []StockBalance
+	params   []StockBalanceQueryParameter
+}
+
+func (f *fakeStock) Select(params StockBalanceQueryParameter) ([]StockBalance, error) {
+	f.params = append(f.params, params)
+
+	start := (params.Page - 1) * params.Limit
+	if start >= int64(len(f.balances)) {
+		return nil, nil
+	}
+	end := start + params.Limit
+	if end > int64(len(f.balances)) {
+		end = int64(len(f.balances))
+	}
+	return f.balances[start:end], nil
+}
+
+func TestSelectAllBalances(t *testing.T) {
+	tests := []struct {
+		name      string
+		balances  int
+		wantPages int
+	}{
+		{"no balances", 0, 1},
+		{"one partial page", 3, 1},
+		{"exactly one page", stockBalancePageSize, 2},
+		{"several pages", 2*stockBalancePageSize + 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stock := &fakeStock{}
+			for i := 0; i < tt.balances; i++ {
+				stock.balances = append(stock.balances, StockBalance{ID: int64(i + 1)})
+			}
+
+			got, err := SelectAllBalances(stock, StockBalanceQueryParameter{
+				PaginationQuery: PaginationQuery{Page: 5, Limit: 1},
+				SKUID:           []int64{7},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.balances {
+				t.Errorf("got %d balances, want %d", len(got), tt.balances)
+			}
+			for i, balance := range got {
+				if balance.ID != int64(i+1) {
+					t.Fatalf("balance %d has ID %d, order not kept", i, balance.ID)
+				}
+			}
+			if len(stock.params) != tt.wantPages {
+				t.Errorf("selected %d pages, want %d", len(stock.params), tt.wantPages)
+			}
+			if stock.params[0].Page != 1 || len(stock.params[0].SKUID) != 1 {
+				t.Errorf("first page params = %+v, want page 1 with the filters kept", stock.params[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	LabelFormatPNG = "png"
+	LabelFormatSVG = "svg"
+	LabelFormatPDF = "pdf"
+	LabelFormatZPL = "zpl"
+)
+
+var (
+	ErrLabelTemplateNotFound = errors.New("label template not found")
+	ErrLabelUnencodable      = errors.New("label data cannot be encoded with this symbology")
+)
+
+type LabelTemplate struct {
+	Name      string
+	Symbology string
+	WidthMM   float64
+	HeightMM  float64
+	// DPI is used for PNG and ZPL output, vector formats ignore it
+	DPI          int
+	ShowName     bool
+	ShowCode     bool
+	ShowLocation bool
+}
+
+type LabelSheetConfig struct {
+	PageWidthMM  float64
+	PageHeightMM float64
+	MarginMM     float64
+	GapMM        float64
+}
+
+type LabelUsecaseConfig struct {
+	DefaultTemplate string
+	Templates       []LabelTemplate
+	Sheet           LabelSheetConfig
+}
+
+// LabelContent is what ends up printed, independent of the entity it came from
+type LabelContent struct {
+	Data     string
+	Name     string
+	Location string
+}
+
+type LabelFile struct {
+	Data        []byte
+	ContentType string
+	FileName    string
+}
+
+type LabelParameter struct {
+	Format    string
+	Template  string
+	Symbology string
+	// SKU labels only, prints the label of a pack instead of a single unit
+	PackLevel string
+}
+
+func (lp *LabelParameter) Parse(uv url.Values) error {
+	lp.Format = strings.ToLower(uv.Get("format"))
+	if len(lp.Format) < 1 {
+		lp.Format = LabelFormatPNG
+	}
+
+	switch lp.Format {
+	case LabelFormatPNG, LabelFormatSVG, LabelFormatPDF, LabelFormatZPL:
+	default:
+		return errors.New("Invalid Format Parameter")
+	}
+
+	lp.Template = uv.Get("template")
+	lp.Symbology = strings.ToLower(uv.Get("symbology"))
+
+	lp.PackLevel = strings.ToLower(uv.Get("pack_level"))
+	if len(lp.PackLevel) > 0 {
+		valid := false
+		for _, level := range PackLevels {
+			if level == lp.PackLevel {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.New("Invalid Pack Level Parameter")
+		}
+	}
+
+	return nil
+}
+
+type LabelSheetParameter struct {
+	LabelParameter
+	SKUID []int64
+	BinID []int64
+}
+
+func (lp *LabelSheetParameter) Parse(uv url.Values) error {
+	if err := lp.LabelParameter.Parse(uv); err != nil {
+		return err
+	}
+
+	// Sheets are meant for printing in batches, so default to a PDF
+	if len(uv.Get("format")) < 1 {
+		lp.Format = LabelFormatPDF
+	}
+
+	if lp.Format != LabelFormatPDF && lp.Format != LabelFormatZPL {
+		return errors.New("Sheets Can Only Be Rendered As pdf or zpl")
+	}
+
+	if skuIDs := uv["sku_id"]; len(skuIDs) > 0 {
+		for _, skuID := range skuIDs {
+			i, err := strconv.ParseInt(skuID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid SKU ID Parameter")
+			}
+
+			lp.SKUID = append(lp.SKUID, i)
+		}
+	}
+
+	if binIDs := uv["bin_id"]; len(binIDs) > 0 {
+		for _, binID := range binIDs {
+			i, err := strconv.ParseInt(binID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Bin ID Parameter")
+			}
+
+			lp.BinID = append(lp.BinID, i)
+		}
+	}
+
+	if len(lp.SKUID)+len(lp.BinID) < 1 {
+		return errors.New("At Least One sku_id or bin_id Is Required")
+	}
+
+	return nil
+}
+
+type LabelUsecase interface {
+	SKULabel(ctx context.Context, skuID int64, params LabelParameter) (LabelFile, error)
+	BinLabel(ctx context.Context, binID int64, params LabelParameter) (LabelFile, error)
+	Sheet(ctx context.Context, params LabelSheetParameter) (LabelFile, error)
+}
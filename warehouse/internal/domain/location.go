@@ -0,0 +1,332 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	LocationTypeWarehouse = "warehouse"
+	LocationTypeZone      = "zone"
+	LocationTypeAisle     = "aisle"
+	LocationTypeRack      = "rack"
+	LocationTypeLevel     = "level"
+	LocationTypeBin       = "bin"
+
+	// Separator between the segments of a structured bin code
+	LocationCodeSeparator = "-"
+
+	LevelCodeLetters = "letters"
+	LevelCodeNumbers = "numbers"
+)
+
+var (
+	// LocationTypes from the top of the tree down, a node always sits below
+	// its parent in this order but levels may be skipped
+	LocationTypes = []string{
+		LocationTypeWarehouse,
+		LocationTypeZone,
+		LocationTypeAisle,
+		LocationTypeRack,
+		LocationTypeLevel,
+		LocationTypeBin,
+	}
+
+	ErrInvalidLocationParent = errors.New("location type must sit below its parent")
+	ErrLocationHasChildren   = errors.New("location still has children")
+	ErrLocationNotRack       = errors.New("bins can only be generated for a rack")
+	ErrLocationExists        = errors.New("location already exists")
+)
+
+type Location struct {
+	ID          int64
+	WarehouseID int64
+	ParentID    *int64
+	Type        string
+	Code        string
+	Name        string
+	// FullCode joins the codes from the zone down, for bins it is the bin code.
+	// A warehouse node keeps its own code.
+	FullCode string
+	// Path holds the ids from the root, like /1/4/9/, descendants share its prefix
+	Path      string
+	Depth     int64
+	BinID     *int64
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (lc Location) LocationResponse() LocationResponse {
+	return LocationResponse{
+		ID:          lc.ID,
+		WarehouseID: lc.WarehouseID,
+		ParentID:    lc.ParentID,
+		Type:        lc.Type,
+		Code:        lc.Code,
+		Name:        lc.Name,
+		FullCode:    lc.FullCode,
+		Path:        lc.Path,
+		Depth:       lc.Depth,
+		BinID:       lc.BinID,
+		Version:     lc.Version,
+		CreatedAt:   lc.CreatedAt,
+		UpdatedAt:   lc.UpdatedAt,
+	}
+}
+
+// ChildCode is the full code of a child with the given code, the warehouse
+// itself is left out of codes.
+func (lc Location) ChildCode(code string) string {
+	if lc.Type == LocationTypeWarehouse {
+		return code
+	}
+	return lc.FullCode + LocationCodeSeparator + code
+}
+
+// AncestorIDs returns the ids on the path from the root down to the parent
+func (lc Location) AncestorIDs() []int64 {
+	var ids []int64
+	for _, segment := range strings.Split(strings.Trim(lc.Path, "/"), "/") {
+		id, err := strconv.ParseInt(segment, 10, 64)
+		if err != nil || id == lc.ID {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type LocationResponse struct {
+	ID          int64     `json:"id"`
+	WarehouseID int64     `json:"warehouse_id"`
+	ParentID    *int64    `json:"parent_id"`
+	Type        string    `json:"type"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	FullCode    string    `json:"full_code"`
+	Path        string    `json:"path"`
+	Depth       int64     `json:"depth"`
+	BinID       *int64    `json:"bin_id,omitempty"`
+	Version     int64     `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// LocationDataParameter creates a node, a warehouse node needs WarehouseID and
+// every other node needs ParentID. Bin nodes also create the backing bin.
+type LocationDataParameter struct {
+	WarehouseID int64  `json:"warehouse_id"`
+	ParentID    int64  `json:"parent_id"`
+	Type        string `json:"type" validate:"required,oneof=warehouse zone aisle rack level bin"`
+	Code        string `json:"code" validate:"required,alphanum,max=16"`
+	Name        string `json:"name"`
+	// Only used for bin nodes
+	Bin LocationBinParameter `json:"bin"`
+
+	// Filled in from the parent
+	FullCode   string `json:"-"`
+	ParentPath string `json:"-"`
+	Depth      int64  `json:"-"`
+	BinID      int64  `json:"-"`
+}
+
+type LocationBinParameter struct {
+	Latitude    float64 `json:"latitude" validate:"omitempty,latitude"`
+	Longitude   float64 `json:"longitude" validate:"omitempty,longitude"`
+	Type        string  `json:"type" validate:"omitempty,oneof=shelf pallet floor cold"`
+	LengthMM    float64 `json:"length_mm" validate:"min=0"`
+	WidthMM     float64 `json:"width_mm" validate:"min=0"`
+	HeightMM    float64 `json:"height_mm" validate:"min=0"`
+	MaxWeightKg float64 `json:"max_weight_kg" validate:"min=0"`
+	MaxVolumeM3 float64 `json:"max_volume_m3" validate:"min=0"`
+}
+
+// BinDataParameter builds the bin backing a bin node
+func (lb LocationBinParameter) BinDataParameter(warehouseID int64, code string, zoneID string) BinDataParameter {
+	return BinDataParameter{
+		WarehouseID: warehouseID,
+		Name:        code,
+		Latitude:    lb.Latitude,
+		Longitude:   lb.Longitude,
+		ZoneID:      zoneID,
+		Type:        lb.Type,
+		LengthMM:    lb.LengthMM,
+		WidthMM:     lb.WidthMM,
+		HeightMM:    lb.HeightMM,
+		MaxWeightKg: lb.MaxWeightKg,
+		MaxVolumeM3: lb.MaxVolumeM3,
+	}
+}
+
+// LocationTemplateParameter lays out the levels of a rack and the bins on
+// each level. Levels are coded A, B, C.. or 01, 02.. and bins 01, 02..
+type LocationTemplateParameter struct {
+	Levels     int64                `json:"levels" validate:"required,min=1,max=26"`
+	Positions  int64                `json:"positions" validate:"required,min=1,max=99"`
+	LevelCodes string               `json:"level_codes" validate:"omitempty,oneof=letters numbers"`
+	Bin        LocationBinParameter `json:"bin"`
+}
+
+// LevelCode is the code of the nth level, counted from 1
+func (lt LocationTemplateParameter) LevelCode(n int64) string {
+	if lt.LevelCodes == LevelCodeNumbers {
+		return fmt.Sprintf("%02d", n)
+	}
+	return string(rune('A' + n - 1))
+}
+
+// PositionCode is the code of the nth bin on a level, counted from 1
+func (lt LocationTemplateParameter) PositionCode(n int64) string {
+	return fmt.Sprintf("%02d", n)
+}
+
+// LocationTypeBelow tells whether child may sit under parent
+func LocationTypeBelow(parent, child string) bool {
+	parentIndex, childIndex := -1, -1
+	for i, locationType := range LocationTypes {
+		if locationType == parent {
+			parentIndex = i
+		}
+		if locationType == child {
+			childIndex = i
+		}
+	}
+
+	return parentIndex >= 0 && childIndex > parentIndex
+}
+
+type LocationQueryParameter struct {
+	PaginationQuery
+	ID          []int64
+	WarehouseID []int64
+	ParentID    []int64
+	Type        []string
+	Code        []string
+	FullCode    []string
+	// Every node whose path starts with PathPrefix, excluding the node itself
+	PathPrefix string
+}
+
+func (lc *LocationQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		lc.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		lc.Limit = i
+	}
+
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			lc.ID = append(lc.ID, i)
+		}
+	}
+
+	if whID := uv["warehouse_id"]; len(whID) > 0 {
+		for _, whID := range whID {
+			i, err := strconv.ParseInt(whID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Warehouse ID Parameter")
+			}
+
+			lc.WarehouseID = append(lc.WarehouseID, i)
+		}
+	}
+
+	if parentIDs := uv["parent_id"]; len(parentIDs) > 0 {
+		for _, parentID := range parentIDs {
+			i, err := strconv.ParseInt(parentID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Parent ID Parameter")
+			}
+
+			lc.ParentID = append(lc.ParentID, i)
+		}
+	}
+
+	if types := uv["type"]; len(types) > 0 {
+		lc.Type = append(lc.Type, types...)
+	}
+
+	if codes := uv["code"]; len(codes) > 0 {
+		lc.Code = append(lc.Code, codes...)
+	}
+
+	if fullCodes := uv["full_code"]; len(fullCodes) > 0 {
+		lc.FullCode = append(lc.FullCode, fullCodes...)
+	}
+
+	return nil
+}
+
+func (lc LocationQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = lc.generatePaginationQuery(sb)
+
+	if len(lc.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": lc.ID})
+	}
+
+	if len(lc.WarehouseID) > 0 {
+		sb = sb.Where(squirrel.Eq{"warehouse_id": lc.WarehouseID})
+	}
+
+	if len(lc.ParentID) > 0 {
+		sb = sb.Where(squirrel.Eq{"parent_id": lc.ParentID})
+	}
+
+	if len(lc.Type) > 0 {
+		sb = sb.Where(squirrel.Eq{"type": lc.Type})
+	}
+
+	if len(lc.Code) > 0 {
+		sb = sb.Where(squirrel.Eq{"code": lc.Code})
+	}
+
+	if len(lc.FullCode) > 0 {
+		sb = sb.Where(squirrel.Eq{"full_code": lc.FullCode})
+	}
+
+	if len(lc.PathPrefix) > 0 {
+		sb = sb.Where(squirrel.Like{"path": lc.PathPrefix + "%"}).
+			Where(squirrel.NotEq{"path": lc.PathPrefix})
+	}
+
+	return sb.OrderBy("path ASC")
+}
+
+type LocationRepository interface {
+	Get(ctx context.Context, locationID int64) (Location, error)
+	Select(ctx context.Context, params LocationQueryParameter) ([]Location, error)
+	Create(ctx context.Context, data LocationDataParameter) (Location, error)
+	Delete(ctx context.Context, locationID, version int64) error
+}
+
+type LocationUsecase interface {
+	Get(ctx context.Context, locationID int64) (LocationResponse, error)
+	Select(ctx context.Context, params LocationQueryParameter) ([]LocationResponse, error)
+	Descendants(ctx context.Context, locationID int64, params LocationQueryParameter) ([]LocationResponse, error)
+	Create(ctx context.Context, data LocationDataParameter) (LocationResponse, error)
+	Generate(ctx context.Context, rackID int64, data LocationTemplateParameter) ([]LocationResponse, error)
+	Delete(ctx context.Context, locationID, version int64) (GenericResponse, error)
+}
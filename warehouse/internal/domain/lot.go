@@ -0,0 +1,178 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	// Lot dates are calendar dates, the time of day is not tracked
+	LotDateLayout = "2006-01-02"
+)
+
+var (
+	ErrInvalidLotDate  = errors.New("lot dates must be formatted as YYYY-MM-DD")
+	ErrLotExpiryBefore = errors.New("lot cannot expire before it is manufactured")
+	ErrLotNotFound     = errors.New("lot does not belong to this SKU")
+)
+
+type Lot struct {
+	ID             int64
+	SKUID          int64
+	LotNumber      string
+	ManufacturedAt *time.Time
+	ExpiresAt      *time.Time
+	Version        int64
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (lt Lot) LotResponse() LotResponse {
+	return LotResponse{
+		ID:             lt.ID,
+		SKUID:          lt.SKUID,
+		LotNumber:      lt.LotNumber,
+		ManufacturedAt: formatLotDate(lt.ManufacturedAt),
+		ExpiresAt:      formatLotDate(lt.ExpiresAt),
+		Version:        lt.Version,
+		CreatedAt:      lt.CreatedAt,
+		UpdatedAt:      lt.UpdatedAt,
+	}
+}
+
+type LotResponse struct {
+	ID             int64     `json:"id"`
+	SKUID          int64     `json:"sku_id"`
+	LotNumber      string    `json:"lot_number"`
+	ManufacturedAt string    `json:"manufactured_at,omitempty"`
+	ExpiresAt      string    `json:"expires_at,omitempty"`
+	Version        int64     `json:"version"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type LotDataParameter struct {
+	LotNumber      string `json:"lot_number" validate:"required"`
+	ManufacturedAt string `json:"manufactured_at"`
+	ExpiresAt      string `json:"expires_at"`
+}
+
+// Dates parses the optional manufacture and expiry dates
+func (ld LotDataParameter) Dates() (*time.Time, *time.Time, error) {
+	manufacturedAt, err := parseLotDate(ld.ManufacturedAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expiresAt, err := parseLotDate(ld.ExpiresAt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if manufacturedAt != nil && expiresAt != nil && expiresAt.Before(*manufacturedAt) {
+		return nil, nil, ErrLotExpiryBefore
+	}
+
+	return manufacturedAt, expiresAt, nil
+}
+
+type LotQueryParameter struct {
+	PaginationQuery
+	ID        []int64
+	SKUID     []int64
+	LotNumber []string
+}
+
+func (lt *LotQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		lt.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		lt.Limit = i
+	}
+
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			lt.ID = append(lt.ID, i)
+		}
+	}
+
+	if lotNumbers := uv["lot_number"]; len(lotNumbers) > 0 {
+		lt.LotNumber = append(lt.LotNumber, lotNumbers...)
+	}
+
+	return nil
+}
+
+func (lt LotQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = lt.generatePaginationQuery(sb)
+
+	if len(lt.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": lt.ID})
+	}
+
+	if len(lt.SKUID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sku_id": lt.SKUID})
+	}
+
+	if len(lt.LotNumber) > 0 {
+		sb = sb.Where(squirrel.Eq{"lot_number": lt.LotNumber})
+	}
+
+	return sb
+}
+
+type LotRepository interface {
+	Get(lotID int64) (Lot, error)
+	Select(params LotQueryParameter) ([]Lot, error)
+	Create(skuID int64, data LotDataParameter) (Lot, error)
+	Update(lotID, version int64, data LotDataParameter) (Lot, error)
+	Delete(lotID, version int64) error
+}
+
+type LotUsecase interface {
+	Get(ctx context.Context, lotID int64) (LotResponse, error)
+	Select(ctx context.Context, skuID int64, params LotQueryParameter) ([]LotResponse, error)
+	Create(ctx context.Context, skuID int64, data LotDataParameter) (LotResponse, error)
+	Update(ctx context.Context, lotID, version int64, data LotDataParameter) (LotResponse, error)
+	Delete(ctx context.Context, lotID, version int64) (GenericResponse, error)
+}
+
+func parseLotDate(value string) (*time.Time, error) {
+	if len(value) < 1 {
+		return nil, nil
+	}
+
+	t, err := time.Parse(LotDateLayout, value)
+	if err != nil {
+		return nil, ErrInvalidLotDate
+	}
+
+	return &t, nil
+}
+
+func formatLotDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(LotDateLayout)
+}
@@ -0,0 +1,180 @@
+package domain
+
+import (
+	"errors"
+)
+
+const (
+	BinTypeShelf  = "shelf"
+	BinTypePallet = "pallet"
+	BinTypeFloor  = "floor"
+	BinTypeCold   = "cold"
+
+	// Above this ratio a bin counts as full in utilization reports
+	BinFullRatio = 0.9
+)
+
+var (
+	ErrBinCapacityExceeded = errors.New("bin capacity exceeded")
+)
+
+// VolumeCapacity is the configured maximum volume, or the volume of the bin
+// when only its dimensions are known.
+func (b Bin) VolumeCapacity() float64 {
+	if b.MaxVolumeM3 > 0 {
+		return b.MaxVolumeM3
+	}
+	return b.LengthMM * b.WidthMM * b.HeightMM / mm3PerM3
+}
+
+// UnitMeasure is the volume and weight of one base unit, taken from the
+// smallest pack that has them and divided by its quantity.
+func (sk SKU) UnitMeasure() (volumeM3 float64, weightKg float64, ok bool) {
+	for _, level := range PackLevels {
+		pack, found := sk.Pack(level)
+		if !found || pack.Quantity < 1 {
+			continue
+		}
+
+		if volumeM3 == 0 && pack.VolumeM3() > 0 {
+			volumeM3 = pack.VolumeM3() / float64(pack.Quantity)
+		}
+		if weightKg == 0 && pack.WeightKg > 0 {
+			weightKg = pack.WeightKg / float64(pack.Quantity)
+		}
+	}
+
+	return volumeM3, weightKg, volumeM3 > 0 || weightKg > 0
+}
+
+type BinOccupancyResponse struct {
+	BinID       int64   `json:"bin_id"`
+	BinCode     string  `json:"bin_code"`
+	WarehouseID int64   `json:"warehouse_id"`
+	ZoneID      string  `json:"zone_id"`
+	Type        string  `json:"type"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Units       int64   `json:"units"`
+	VolumeM3    float64 `json:"volume_m3"`
+	WeightKg    float64 `json:"weight_kg"`
+	MaxVolumeM3 float64 `json:"max_volume_m3"`
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	// Ratios are 0 when the matching capacity is unknown
+	VolumeRatio float64 `json:"volume_ratio"`
+	WeightRatio float64 `json:"weight_ratio"`
+	Ratio       float64 `json:"ratio"`
+	// SKUs in the bin without dimensions or weight, their stock is not counted
+	UnmeasuredSKUs []string `json:"unmeasured_skus"`
+}
+
+type ZoneUtilizationResponse struct {
+	ZoneID      string  `json:"zone_id"`
+	Bins        int64   `json:"bins"`
+	FullBins    int64   `json:"full_bins"`
+	VolumeM3    float64 `json:"volume_m3"`
+	MaxVolumeM3 float64 `json:"max_volume_m3"`
+	WeightKg    float64 `json:"weight_kg"`
+	MaxWeightKg float64 `json:"max_weight_kg"`
+	Ratio       float64 `json:"ratio"`
+}
+
+// WarehouseUtilizationResponse is heatmap data, every bin is a point with its
+// occupancy ratio and zones carry the totals of their bins.
+type WarehouseUtilizationResponse struct {
+	WarehouseID int64                     `json:"warehouse_id"`
+	Zones       []ZoneUtilizationResponse `json:"zones"`
+	Bins        []BinOccupancyResponse    `json:"bins"`
+}
+
+// ComputeOccupancy adds up the stock of a bin, skus holds every SKU the
+// balances refer to.
+func ComputeOccupancy(bin Bin, balances []StockBalance, skus map[int64]SKU) BinOccupancyResponse {
+	var (
+		occupancy = BinOccupancyResponse{
+			BinID:          bin.ID,
+			BinCode:        bin.Name,
+			WarehouseID:    bin.WarehouseID,
+			ZoneID:         bin.ZoneID,
+			Type:           bin.Type,
+			Latitude:       bin.Latitude,
+			Longitude:      bin.Longitude,
+			MaxVolumeM3:    bin.VolumeCapacity(),
+			MaxWeightKg:    bin.MaxWeightKg,
+			UnmeasuredSKUs: []string{},
+		}
+		unmeasured = make(map[int64]bool)
+	)
+
+	for _, balance := range balances {
+		occupancy.Units += balance.Quantity
+
+		volumeM3, weightKg, ok := skus[balance.SKUID].UnitMeasure()
+		if !ok {
+			if !unmeasured[balance.SKUID] {
+				unmeasured[balance.SKUID] = true
+				occupancy.UnmeasuredSKUs = append(occupancy.UnmeasuredSKUs, balance.SKU)
+			}
+			continue
+		}
+
+		occupancy.VolumeM3 += volumeM3 * float64(balance.Quantity)
+		occupancy.WeightKg += weightKg * float64(balance.Quantity)
+	}
+
+	occupancy.updateRatios()
+	return occupancy
+}
+
+// Fits tells whether adding the given volume and weight keeps the bin within
+// its capacity, unknown capacities never block.
+func (bo BinOccupancyResponse) Fits(volumeM3, weightKg float64) bool {
+	if bo.MaxVolumeM3 > 0 && bo.VolumeM3+volumeM3 > bo.MaxVolumeM3 {
+		return false
+	}
+	if bo.MaxWeightKg > 0 && bo.WeightKg+weightKg > bo.MaxWeightKg {
+		return false
+	}
+	return true
+}
+
+func (bo *BinOccupancyResponse) updateRatios() {
+	if bo.MaxVolumeM3 > 0 {
+		bo.VolumeRatio = bo.VolumeM3 / bo.MaxVolumeM3
+	}
+	if bo.MaxWeightKg > 0 {
+		bo.WeightRatio = bo.WeightKg / bo.MaxWeightKg
+	}
+
+	// A bin is as full as its most constrained dimension
+	bo.Ratio = bo.VolumeRatio
+	if bo.WeightRatio > bo.Ratio {
+		bo.Ratio = bo.WeightRatio
+	}
+}
+
+// AddBin adds a bin to the totals of its zone
+func (zu *ZoneUtilizationResponse) AddBin(bo BinOccupancyResponse) {
+	zu.Bins++
+	if bo.Ratio >= BinFullRatio {
+		zu.FullBins++
+	}
+
+	zu.VolumeM3 += bo.VolumeM3
+	zu.MaxVolumeM3 += bo.MaxVolumeM3
+	zu.WeightKg += bo.WeightKg
+	zu.MaxWeightKg += bo.MaxWeightKg
+
+	var volumeRatio, weightRatio float64
+	if zu.MaxVolumeM3 > 0 {
+		volumeRatio = zu.VolumeM3 / zu.MaxVolumeM3
+	}
+	if zu.MaxWeightKg > 0 {
+		weightRatio = zu.WeightKg / zu.MaxWeightKg
+	}
+
+	zu.Ratio = volumeRatio
+	if weightRatio > zu.Ratio {
+		zu.Ratio = weightRatio
+	}
+}
@@ -0,0 +1,311 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+const (
+	RoleAdmin         = "admin"
+	RoleSupervisor    = "supervisor"
+	RoleOperator      = "operator"
+	RoleScannerDevice = "scanner-device"
+	RoleReadOnly      = "read-only"
+)
+
+// Permission is an action on a kind of resource, written as resource:action
+type Permission string
+
+const (
+	PermWarehouseRead   Permission = "warehouse:read"
+	PermWarehouseWrite  Permission = "warehouse:write"
+	PermWarehouseDelete Permission = "warehouse:delete"
+	PermBinRead         Permission = "bin:read"
+	PermBinWrite        Permission = "bin:write"
+	PermBinDelete       Permission = "bin:delete"
+	PermSKURead         Permission = "sku:read"
+	PermSKUWrite        Permission = "sku:write"
+	PermSKUDelete       Permission = "sku:delete"
+	PermCommodityRead   Permission = "commodity:read"
+	PermCommodityWrite  Permission = "commodity:write"
+	PermCommodityDelete Permission = "commodity:delete"
+	PermStockRead       Permission = "stock:read"
+	PermStockWrite      Permission = "stock:write"
+	PermSerialRead      Permission = "serial:read"
+	PermSerialWrite     Permission = "serial:write"
+	PermScanRead        Permission = "scan:read"
+	PermScanCreate      Permission = "scan:create"
+	PermAuditRead       Permission = "audit:read"
+	PermWebhookManage   Permission = "webhook:manage"
+)
+
+var (
+	ErrInvalidGrant = errors.New("invalid role grant")
+
+	readPermissions = []Permission{
+		PermWarehouseRead,
+		PermBinRead,
+		PermSKURead,
+		PermCommodityRead,
+		PermStockRead,
+		PermSerialRead,
+		PermScanRead,
+	}
+
+	rolePermissions = map[string][]Permission{
+		RoleAdmin: append([]Permission{
+			PermWarehouseWrite,
+			PermWarehouseDelete,
+			PermBinWrite,
+			PermBinDelete,
+			PermSKUWrite,
+			PermSKUDelete,
+			PermCommodityWrite,
+			PermCommodityDelete,
+			PermStockWrite,
+			PermSerialWrite,
+			PermScanCreate,
+			PermAuditRead,
+			PermWebhookManage,
+		}, readPermissions...),
+		RoleSupervisor: append([]Permission{
+			PermWarehouseWrite,
+			PermBinWrite,
+			PermBinDelete,
+			PermSKUWrite,
+			PermSKUDelete,
+			PermCommodityWrite,
+			PermStockWrite,
+			PermSerialWrite,
+			PermScanCreate,
+			PermAuditRead,
+		}, readPermissions...),
+		RoleOperator: append([]Permission{
+			PermBinWrite,
+			PermSKUWrite,
+			PermStockWrite,
+			PermSerialWrite,
+			PermScanCreate,
+		}, readPermissions...),
+		RoleScannerDevice: {
+			PermWarehouseRead,
+			PermBinRead,
+			PermSKURead,
+			PermStockRead,
+			PermSerialRead,
+			PermScanRead,
+			PermScanCreate,
+		},
+		RoleReadOnly: readPermissions,
+	}
+)
+
+// Grant gives a role in one warehouse, or in every warehouse when WarehouseID
+// is zero. Grants are written as "operator:12" or "read-only".
+type Grant struct {
+	Role        string
+	WarehouseID int64
+}
+
+func ParseGrant(s string) (Grant, error) {
+	var (
+		grant Grant
+	)
+
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	if _, ok := rolePermissions[parts[0]]; !ok {
+		return grant, ErrInvalidGrant
+	}
+	grant.Role = parts[0]
+
+	if len(parts) == 2 {
+		i, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil || i < 1 {
+			return grant, ErrInvalidGrant
+		}
+		grant.WarehouseID = i
+	}
+
+	return grant, nil
+}
+
+func ParseGrants(ss []string) ([]Grant, error) {
+	grants := []Grant{}
+	for _, s := range ss {
+		grant, err := ParseGrant(s)
+		if err != nil {
+			return grants, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+func (g Grant) String() string {
+	if g.WarehouseID == 0 {
+		return g.Role
+	}
+	return g.Role + ":" + strconv.FormatInt(g.WarehouseID, 10)
+}
+
+func (g Grant) has(perm Permission) bool {
+	for _, p := range rolePermissions[g.Role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin is true for principals holding admin over every warehouse
+func (p Principal) IsAdmin() bool {
+	for _, grant := range p.Grants {
+		if grant.Role == RoleAdmin && grant.WarehouseID == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Can reports whether the principal holds perm in the warehouse. A zero
+// warehouseID asks about resources shared by every warehouse, such as the SKU
+// catalogue, and needs a grant that is not scoped to a warehouse.
+func (p Principal) Can(perm Permission, warehouseID int64) bool {
+	for _, grant := range p.Grants {
+		if grant.has(perm) && (grant.WarehouseID == 0 || grant.WarehouseID == warehouseID) {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAny reports whether the principal holds perm in at least one warehouse
+func (p Principal) CanAny(perm Permission) bool {
+	for _, grant := range p.Grants {
+		if grant.has(perm) {
+			return true
+		}
+	}
+	return false
+}
+
+// Warehouses returns the warehouses the principal holds perm in, all is true
+// when one of its grants covers every warehouse.
+func (p Principal) Warehouses(perm Permission) (ids []int64, all bool) {
+	seen := make(map[int64]bool)
+	for _, grant := range p.Grants {
+		if !grant.has(perm) {
+			continue
+		}
+		if grant.WarehouseID == 0 {
+			return nil, true
+		}
+		if !seen[grant.WarehouseID] {
+			seen[grant.WarehouseID] = true
+			ids = append(ids, grant.WarehouseID)
+		}
+	}
+	return ids, false
+}
+
+// Authorize checks the principal of ctx holds perm in the warehouse
+func Authorize(ctx context.Context, perm Permission, warehouseID int64) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !principal.Can(perm, warehouseID) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeAdmin checks the principal of ctx administers every warehouse, for
+// endpoints managing credentials
+func AuthorizeAdmin(ctx context.Context) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !principal.IsAdmin() {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeAny checks the principal of ctx holds perm in any warehouse, for
+// reads of shared resources that every site needs to see
+func AuthorizeAny(ctx context.Context, perm Permission) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !principal.CanAny(perm) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// ScopeWarehouses narrows a warehouse filter down to the warehouses the
+// principal of ctx holds perm in. An empty filter becomes every permitted
+// warehouse, ok is false when none of the requested ones are permitted.
+func ScopeWarehouses(ctx context.Context, perm Permission, requested []int64) (ids []int64, ok bool, err error) {
+	principal, found := PrincipalFromContext(ctx)
+	if !found {
+		return nil, false, ErrUnauthenticated
+	}
+
+	permitted, all := principal.Warehouses(perm)
+	if all {
+		return requested, true, nil
+	}
+	if len(permitted) < 1 {
+		return nil, false, ErrForbidden
+	}
+	if len(requested) < 1 {
+		return permitted, true, nil
+	}
+
+	for _, id := range requested {
+		for _, p := range permitted {
+			if id == p {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	return ids, len(ids) > 0, nil
+}
+
+// ScopeWarehouseNames is ScopeWarehouses for resources naming their warehouse,
+// such as the wh_code of SKUs. names resolves warehouse ids to their names.
+func ScopeWarehouseNames(ctx context.Context, perm Permission, requested []string, names func(ctx context.Context, ids []int64) ([]string, error)) ([]string, bool, error) {
+	ids, ok, err := ScopeWarehouses(ctx, perm, nil)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	if len(ids) < 1 {
+		return requested, true, nil
+	}
+
+	permitted, err := names(ctx, ids)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(requested) < 1 {
+		return permitted, len(permitted) > 0, nil
+	}
+
+	var scoped []string
+	for _, name := range requested {
+		for _, p := range permitted {
+			if name == p {
+				scoped = append(scoped, name)
+				break
+			}
+		}
+	}
+	return scoped, len(scoped) > 0, nil
+}
@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseGrant(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Grant
+		wantErr error
+	}{
+		{"admin", Grant{Role: RoleAdmin}, nil},
+		{" operator:12 ", Grant{Role: RoleOperator, WarehouseID: 12}, nil},
+		{"scanner-device:3", Grant{Role: RoleScannerDevice, WarehouseID: 3}, nil},
+		{"owner", Grant{}, ErrInvalidGrant},
+		{"operator:", Grant{}, ErrInvalidGrant},
+		{"operator:0", Grant{}, ErrInvalidGrant},
+		{"operator:-4", Grant{}, ErrInvalidGrant},
+		{"operator:main", Grant{}, ErrInvalidGrant},
+		{"", Grant{}, ErrInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseGrant(tt.value)
+		if err != tt.wantErr {
+			t.Errorf("ParseGrant(%q) err = %v, want %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseGrant(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+		// Grants are stored as strings, reading one back must give the same grant
+		if again, err := ParseGrant(got.String()); err != nil || again != got {
+			t.Errorf("ParseGrant(%q) = %+v, %v", got.String(), again, err)
+		}
+	}
+}
+
+func TestPrincipalCan(t *testing.T) {
+	principal := Principal{Grants: []Grant{
+		{Role: RoleOperator, WarehouseID: 1},
+		{Role: RoleReadOnly},
+	}}
+
+	tests := []struct {
+		name        string
+		perm        Permission
+		warehouseID int64
+		want        bool
+	}{
+		{"scoped write", PermStockWrite, 1, true},
+		{"write elsewhere", PermStockWrite, 2, false},
+		{"read everywhere", PermStockRead, 2, true},
+		{"shared resource needs unscoped grant", PermSKUWrite, 0, false},
+		{"not granted", PermWebhookManage, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := principal.Can(tt.perm, tt.warehouseID); got != tt.want {
+				t.Errorf("Can(%s, %d) = %v, want %v", tt.perm, tt.warehouseID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScopeWarehouses(t *testing.T) {
+	scoped := Principal{Grants: []Grant{
+		{Role: RoleOperator, WarehouseID: 1},
+		{Role: RoleSupervisor, WarehouseID: 2},
+		{Role: RoleOperator, WarehouseID: 1},
+	}}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		perm      Permission
+		requested []int64
+		want      []int64
+		wantOK    bool
+		wantErr   error
+	}{
+		{"unauthenticated", context.Background(), PermStockRead, nil, nil, false, ErrUnauthenticated},
+		{"unscoped grant keeps filter", WithPrincipal(context.Background(), Principal{Grants: []Grant{{Role: RoleAdmin}}}), PermStockRead, []int64{7}, []int64{7}, true, nil},
+		{"unscoped grant without filter", WithPrincipal(context.Background(), Principal{Grants: []Grant{{Role: RoleReadOnly}}}), PermStockRead, nil, nil, true, nil},
+		{"empty filter becomes permitted", WithPrincipal(context.Background(), scoped), PermStockRead, nil, []int64{1, 2}, true, nil},
+		{"filter is narrowed", WithPrincipal(context.Background(), scoped), PermStockRead, []int64{2, 3}, []int64{2}, true, nil},
+		{"nothing requested is permitted", WithPrincipal(context.Background(), scoped), PermStockRead, []int64{3}, nil, false, nil},
+		{"no grant holds perm", WithPrincipal(context.Background(), Principal{Grants: []Grant{{Role: RoleScannerDevice, WarehouseID: 1}}}), PermStockWrite, nil, nil, false, ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := ScopeWarehouses(tt.ctx, tt.perm, tt.requested)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ids = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("ids = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestScopeWarehouseNames(t *testing.T) {
+	scoped := WithPrincipal(context.Background(), Principal{Grants: []Grant{
+		{Role: RoleOperator, WarehouseID: 1},
+		{Role: RoleOperator, WarehouseID: 2},
+	}})
+	names := func(ctx context.Context, ids []int64) ([]string, error) {
+		byID := map[int64]string{1: "JKT", 2: "SBY", 3: "BDG"}
+		var found []string
+		for _, id := range ids {
+			found = append(found, byID[id])
+		}
+		return found, nil
+	}
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		requested []string
+		want      []string
+		wantOK    bool
+		wantErr   error
+	}{
+		{"unauthenticated", context.Background(), nil, nil, false, ErrUnauthenticated},
+		{"unscoped grant keeps filter", WithPrincipal(context.Background(), Principal{Grants: []Grant{{Role: RoleAdmin}}}), []string{"BDG"}, []string{"BDG"}, true, nil},
+		{"unscoped grant without filter", WithPrincipal(context.Background(), Principal{Grants: []Grant{{Role: RoleReadOnly}}}), nil, nil, true, nil},
+		{"empty filter becomes permitted", scoped, nil, []string{"JKT", "SBY"}, true, nil},
+		{"filter is narrowed", scoped, []string{"SBY", "BDG"}, []string{"SBY"}, true, nil},
+		{"nothing requested is permitted", scoped, []string{"BDG"}, nil, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := ScopeWarehouseNames(tt.ctx, PermSKURead, tt.requested, names)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if ok != tt.wantOK {
+				t.Errorf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("names = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+type BarcodeScan struct {
+	ID          int64
+	UserID      string
+	DeviceID    string
+	WarehouseID int64
+	ImageHash   string
+	ImageKey    string
+	Decoder     string
+	Detections  []BarcodeLambda
+	Results     []WarehouseBarcode
+	Error       string
+	CreatedAt   time.Time
+}
+
+func (bs BarcodeScan) BarcodeScanResponse() BarcodeScanResponse {
+	return BarcodeScanResponse{
+		ID:          bs.ID,
+		UserID:      bs.UserID,
+		DeviceID:    bs.DeviceID,
+		WarehouseID: bs.WarehouseID,
+		ImageHash:   bs.ImageHash,
+		HasImage:    len(bs.ImageKey) > 0,
+		Decoder:     bs.Decoder,
+		Detections:  bs.Detections,
+		Results:     bs.Results,
+		Error:       bs.Error,
+		CreatedAt:   bs.CreatedAt,
+	}
+}
+
+type BarcodeScanResponse struct {
+	ID          int64              `json:"id"`
+	UserID      string             `json:"user_id"`
+	DeviceID    string             `json:"device_id"`
+	WarehouseID int64              `json:"warehouse_id,omitempty"`
+	ImageHash   string             `json:"image_hash"`
+	HasImage    bool               `json:"has_image"`
+	Decoder     string             `json:"decoder"`
+	Detections  []BarcodeLambda    `json:"detections"`
+	Results     []WarehouseBarcode `json:"results"`
+	Error       string             `json:"error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// BarcodeScanMetadata describes who sent an upload and from where
+type BarcodeScanMetadata struct {
+	UserID      string
+	DeviceID    string
+	WarehouseID int64
+}
+
+type BarcodeScanDataParameter struct {
+	BarcodeScanMetadata
+	ImageHash  string
+	ImageKey   string
+	Decoder    string
+	Detections []BarcodeLambda
+	Results    []WarehouseBarcode
+	Error      string
+}
+
+type BarcodeScanImage struct {
+	Data        []byte
+	ContentType string
+}
+
+type BarcodeScanQueryParameter struct {
+	PaginationQuery
+	ID          []int64
+	WarehouseID []int64
+	UserID      []string
+	DeviceID    []string
+	ImageHash   []string
+	Decoder     []string
+	From        time.Time
+	To          time.Time
+}
+
+func (bs *BarcodeScanQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		bs.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		bs.Limit = i
+	}
+
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			bs.ID = append(bs.ID, i)
+		}
+	}
+
+	if whID := uv["warehouse_id"]; len(whID) > 0 {
+		for _, whID := range whID {
+			i, err := strconv.ParseInt(whID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Warehouse ID Parameter")
+			}
+
+			bs.WarehouseID = append(bs.WarehouseID, i)
+		}
+	}
+
+	if userIDs := uv["user_id"]; len(userIDs) > 0 {
+		bs.UserID = append(bs.UserID, userIDs...)
+	}
+
+	if deviceIDs := uv["device_id"]; len(deviceIDs) > 0 {
+		bs.DeviceID = append(bs.DeviceID, deviceIDs...)
+	}
+
+	if hashes := uv["image_hash"]; len(hashes) > 0 {
+		bs.ImageHash = append(bs.ImageHash, hashes...)
+	}
+
+	if decoders := uv["decoder"]; len(decoders) > 0 {
+		bs.Decoder = append(bs.Decoder, decoders...)
+	}
+
+	if from := uv.Get("from"); len(from) > 0 {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return errors.New("Invalid From Parameter, Use RFC3339")
+		}
+		bs.From = t
+	}
+
+	if to := uv.Get("to"); len(to) > 0 {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return errors.New("Invalid To Parameter, Use RFC3339")
+		}
+		bs.To = t
+	}
+
+	return nil
+}
+
+func (bs BarcodeScanQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = bs.generatePaginationQuery(sb)
+
+	if len(bs.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": bs.ID})
+	}
+
+	if len(bs.WarehouseID) > 0 {
+		sb = sb.Where(squirrel.Eq{"warehouse_id": bs.WarehouseID})
+	}
+
+	if len(bs.UserID) > 0 {
+		sb = sb.Where(squirrel.Eq{"user_id": bs.UserID})
+	}
+
+	if len(bs.DeviceID) > 0 {
+		sb = sb.Where(squirrel.Eq{"device_id": bs.DeviceID})
+	}
+
+	if len(bs.ImageHash) > 0 {
+		sb = sb.Where(squirrel.Eq{"image_hash": bs.ImageHash})
+	}
+
+	if len(bs.Decoder) > 0 {
+		sb = sb.Where(squirrel.Eq{"decoder": bs.Decoder})
+	}
+
+	if !bs.From.IsZero() {
+		sb = sb.Where(squirrel.GtOrEq{"created_at": bs.From})
+	}
+
+	if !bs.To.IsZero() {
+		sb = sb.Where(squirrel.Lt{"created_at": bs.To})
+	}
+
+	return sb.OrderBy("created_at DESC")
+}
+
+type BarcodeScanRepository interface {
+	Get(ctx context.Context, scanID int64) (BarcodeScan, error)
+	Select(ctx context.Context, params BarcodeScanQueryParameter) ([]BarcodeScan, error)
+	Create(ctx context.Context, data BarcodeScanDataParameter) (BarcodeScan, error)
+}
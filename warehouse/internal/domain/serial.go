@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	SerialStatusInStock  = "in_stock"
+	SerialStatusPicked   = "picked"
+	SerialStatusShipped  = "shipped"
+	SerialStatusReturned = "returned"
+)
+
+var (
+	ErrSKUNotSerialized        = errors.New("SKU is not serialized")
+	ErrInvalidSerialTransition = errors.New("serial number cannot move to this status")
+	ErrSerialBinRequired       = errors.New("a bin is required for this status")
+
+	// Statuses each status may move to
+	serialTransitions = map[string][]string{
+		SerialStatusInStock:  {SerialStatusPicked},
+		SerialStatusPicked:   {SerialStatusShipped, SerialStatusInStock},
+		SerialStatusShipped:  {SerialStatusReturned},
+		SerialStatusReturned: {SerialStatusInStock},
+	}
+)
+
+// SerialNumber is a single unit of a serialized SKU, BinID is nil while the
+// unit is not on a shelf.
+type SerialNumber struct {
+	ID        int64
+	SKUID     int64
+	Serial    string
+	Status    string
+	BinID     *int64
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (sn SerialNumber) SerialNumberResponse() SerialNumberResponse {
+	return SerialNumberResponse{
+		ID:        sn.ID,
+		SKUID:     sn.SKUID,
+		Serial:    sn.Serial,
+		Status:    sn.Status,
+		BinID:     sn.BinID,
+		Version:   sn.Version,
+		CreatedAt: sn.CreatedAt,
+		UpdatedAt: sn.UpdatedAt,
+	}
+}
+
+type SerialNumberResponse struct {
+	ID        int64                       `json:"id"`
+	SKUID     int64                       `json:"sku_id"`
+	Serial    string                      `json:"serial"`
+	Status    string                      `json:"status"`
+	BinID     *int64                      `json:"bin_id"`
+	History   []SerialNumberEventResponse `json:"history,omitempty"`
+	Version   int64                       `json:"version"`
+	CreatedAt time.Time                   `json:"created_at"`
+	UpdatedAt time.Time                   `json:"updated_at"`
+}
+
+type SerialNumberEvent struct {
+	ID             int64
+	SerialNumberID int64
+	Status         string
+	BinID          *int64
+	Note           string
+	CreatedAt      time.Time
+}
+
+func (se SerialNumberEvent) SerialNumberEventResponse() SerialNumberEventResponse {
+	return SerialNumberEventResponse{
+		ID:        se.ID,
+		Status:    se.Status,
+		BinID:     se.BinID,
+		Note:      se.Note,
+		CreatedAt: se.CreatedAt,
+	}
+}
+
+type SerialNumberEventResponse struct {
+	ID        int64     `json:"id"`
+	Status    string    `json:"status"`
+	BinID     *int64    `json:"bin_id"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SerialNumberDataParameter registers a unit, it starts in stock in the given bin
+type SerialNumberDataParameter struct {
+	Serial string `json:"serial" validate:"required"`
+	BinID  int64  `json:"bin_id" validate:"required"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+type SerialNumberMoveParameter struct {
+	Status string `json:"status" validate:"required,oneof=in_stock picked shipped returned"`
+	BinID  int64  `json:"bin_id"`
+	Note   string `json:"note" validate:"max=255"`
+}
+
+// Validate checks the move against the current status, units on a shelf need a bin
+func (sm SerialNumberMoveParameter) Validate(current string) error {
+	allowed := false
+	for _, status := range serialTransitions[current] {
+		if status == sm.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return ErrInvalidSerialTransition
+	}
+
+	if (sm.Status == SerialStatusInStock || sm.Status == SerialStatusReturned) && sm.BinID < 1 {
+		return ErrSerialBinRequired
+	}
+
+	return nil
+}
+
+type SerialNumberQueryParameter struct {
+	PaginationQuery
+	ID     []int64
+	SKUID  []int64
+	Serial []string
+	Status []string
+	BinID  []int64
+	// Units in bins of these warehouses, units off the shelf have no bin
+	WarehouseID []int64
+}
+
+func (sq *SerialNumberQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		sq.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		sq.Limit = i
+	}
+
+	if serials := uv["serial"]; len(serials) > 0 {
+		sq.Serial = append(sq.Serial, serials...)
+	}
+
+	if statuses := uv["status"]; len(statuses) > 0 {
+		sq.Status = append(sq.Status, statuses...)
+	}
+
+	if binIDs := uv["bin_id"]; len(binIDs) > 0 {
+		for _, binID := range binIDs {
+			i, err := strconv.ParseInt(binID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Bin ID Parameter")
+			}
+
+			sq.BinID = append(sq.BinID, i)
+		}
+	}
+
+	for _, value := range uv["warehouse_id"] {
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Warehouse ID Parameter")
+		}
+
+		sq.WarehouseID = append(sq.WarehouseID, i)
+	}
+
+	return nil
+}
+
+func (sq SerialNumberQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = sq.generatePaginationQuery(sb)
+
+	if len(sq.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": sq.ID})
+	}
+
+	if len(sq.SKUID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sku_id": sq.SKUID})
+	}
+
+	if len(sq.Serial) > 0 {
+		sb = sb.Where(squirrel.Eq{"serial": sq.Serial})
+	}
+
+	if len(sq.Status) > 0 {
+		sb = sb.Where(squirrel.Eq{"status": sq.Status})
+	}
+
+	if len(sq.BinID) > 0 {
+		sb = sb.Where(squirrel.Eq{"bin_id": sq.BinID})
+	}
+
+	if len(sq.WarehouseID) > 0 {
+		bins, args, _ := squirrel.Select("id").From("bins").Where(squirrel.Eq{"warehouse_id": sq.WarehouseID}).ToSql()
+		sb = sb.Where("bin_id IN ("+bins+")", args...)
+	}
+
+	return sb
+}
+
+type SerialNumberRepository interface {
+	Get(serialID int64) (SerialNumber, error)
+	Select(params SerialNumberQueryParameter) ([]SerialNumber, error)
+	Create(skuID int64, data SerialNumberDataParameter) (SerialNumber, error)
+	Move(serialID, version int64, data SerialNumberMoveParameter) (SerialNumber, error)
+	History(serialID int64) ([]SerialNumberEvent, error)
+}
+
+type SerialNumberUsecase interface {
+	Get(ctx context.Context, serialID int64) (SerialNumberResponse, error)
+	Select(ctx context.Context, skuID int64, params SerialNumberQueryParameter) ([]SerialNumberResponse, error)
+	Create(ctx context.Context, skuID int64, data SerialNumberDataParameter) (SerialNumberResponse, error)
+	Move(ctx context.Context, serialID, version int64, data SerialNumberMoveParameter) (SerialNumberResponse, error)
+}
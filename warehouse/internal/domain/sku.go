@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -10,38 +11,61 @@ import (
 )
 
 type SKU struct {
-	ID        int64
-	SKU       string
-	Name      string
-	WHCode    string
-	BinCode   string
-	ZoneID    string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID   int64
+	SKU  string
+	Name string
+	// WHCode is the name of the warehouse and BinCode the name of a bin in it
+	WHCode  string
+	BinCode string
+	ZoneID  string
+	// Serialized SKUs are tracked per unit in serial_numbers
+	Serialized bool
+	BaseUoM    string
+	Packs      []SKUPack
+	// Zero when the SKU has no commodity
+	CommodityID int64
+	Version     int64
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 func (sk SKU) SKUResponse() SKUResponse {
+	packs := []SKUPackResponse{}
+	for _, pack := range sk.Packs {
+		packs = append(packs, pack.SKUPackResponse())
+	}
+
 	return SKUResponse{
-		ID:        sk.ID,
-		SKU:       sk.SKU,
-		Name:      sk.Name,
-		WHCode:    sk.WHCode,
-		BinCode:   sk.BinCode,
-		ZoneID:    sk.ZoneID,
-		CreatedAt: sk.CreatedAt,
-		UpdatedAt: sk.UpdatedAt,
+		ID:          sk.ID,
+		SKU:         sk.SKU,
+		Name:        sk.Name,
+		WHCode:      sk.WHCode,
+		BinCode:     sk.BinCode,
+		ZoneID:      sk.ZoneID,
+		Serialized:  sk.Serialized,
+		BaseUoM:     sk.BaseUoM,
+		Packs:       packs,
+		CommodityID: sk.CommodityID,
+		Version:     sk.Version,
+		CreatedAt:   sk.CreatedAt,
+		UpdatedAt:   sk.UpdatedAt,
 	}
 }
 
 type SKUResponse struct {
-	ID        int64     `json:"id"`
-	SKU       string    `json:"sku"`
-	Name      string    `json:"name"`
-	WHCode    string    `json:"wh_code"`
-	BinCode   string    `json:"bin_code"`
-	ZoneID    string    `json:"zone_id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          int64             `json:"id"`
+	SKU         string            `json:"sku"`
+	Name        string            `json:"name"`
+	WHCode      string            `json:"wh_code"`
+	BinCode     string            `json:"bin_code"`
+	ZoneID      string            `json:"zone_id"`
+	Serialized  bool              `json:"serialized"`
+	BaseUoM     string            `json:"base_uom"`
+	Packs       []SKUPackResponse `json:"packs"`
+	CommodityID int64             `json:"commodity_id,omitempty"`
+	Version     int64             `json:"version"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
 }
 
 type SKUDataParameter struct {
@@ -50,11 +74,43 @@ type SKUDataParameter struct {
 	BinCode string `json:"bin_code" validate:"required"`
 	ZoneID  string `json:"zone_id" validate:"required"`
 	Name    string `json:"name" validate:"required"`
+	// Optional, SKUs are not serialized unless asked for
+	Serialized bool `json:"serialized"`
+	// Optional, defaults to pcs
+	BaseUoM string                 `json:"base_uom"`
+	Packs   []SKUPackDataParameter `json:"packs" validate:"dive"`
+	// Optional, the commodity whose handling rules apply
+	CommodityID int64 `json:"commodity_id" validate:"min=0"`
+}
+
+// SKUDataParameter is the write form of the SKU, the document a merge patch
+// applies to
+func (sr SKUResponse) SKUDataParameter() SKUDataParameter {
+	packs := []SKUPackDataParameter{}
+	for _, pack := range sr.Packs {
+		packs = append(packs, SKUPackDataParameter(pack))
+	}
+
+	return SKUDataParameter{
+		SKU:         sr.SKU,
+		WHCode:      sr.WHCode,
+		BinCode:     sr.BinCode,
+		ZoneID:      sr.ZoneID,
+		Name:        sr.Name,
+		Serialized:  sr.Serialized,
+		BaseUoM:     sr.BaseUoM,
+		Packs:       packs,
+		CommodityID: sr.CommodityID,
+	}
 }
 
 type SKUQueryParameter struct {
 	PaginationQuery
-	SKU []string
+	ID          []int64
+	SKU         []string
+	WHCode      []string
+	BinCode     []string
+	CommodityID []int64
 }
 
 func (wh *SKUQueryParameter) Parse(uv url.Values) error {
@@ -74,35 +130,83 @@ func (wh *SKUQueryParameter) Parse(uv url.Values) error {
 		wh.Limit = i
 	}
 
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("invalid ID Parameter")
+			}
+
+			wh.ID = append(wh.ID, i)
+		}
+	}
+
 	if skus := uv["sku"]; len(skus) > 0 {
 		wh.SKU = append(wh.SKU, skus...)
 	}
 
+	if whCodes := uv["wh_code"]; len(whCodes) > 0 {
+		wh.WHCode = append(wh.WHCode, whCodes...)
+	}
+
+	if binCodes := uv["bin_code"]; len(binCodes) > 0 {
+		wh.BinCode = append(wh.BinCode, binCodes...)
+	}
+
+	if commodityIDs := uv["commodity_id"]; len(commodityIDs) > 0 {
+		for _, commodityID := range commodityIDs {
+			i, err := strconv.ParseInt(commodityID, 10, 64)
+			if err != nil {
+				return errors.New("invalid Commodity ID Parameter")
+			}
+
+			wh.CommodityID = append(wh.CommodityID, i)
+		}
+	}
+
 	return nil
 }
 
 func (wh SKUQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
 	sb = wh.generatePaginationQuery(sb)
 
+	if len(wh.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": wh.ID})
+	}
+
 	if len(wh.SKU) > 0 {
 		sb = sb.Where(squirrel.Eq{"sku": wh.SKU})
 	}
 
+	if len(wh.WHCode) > 0 {
+		sb = sb.Where(squirrel.Eq{"wh_code": wh.WHCode})
+	}
+
+	if len(wh.BinCode) > 0 {
+		sb = sb.Where(squirrel.Eq{"bin_code": wh.BinCode})
+	}
+
+	if len(wh.CommodityID) > 0 {
+		sb = sb.Where(squirrel.Eq{"commodity_id": wh.CommodityID})
+	}
+
 	return sb
 }
 
 type SKURepository interface {
-	Get(skuID int64) (SKU, error)
-	Select(params SKUQueryParameter) ([]SKU, error)
-	Create(data SKUDataParameter) (SKU, error)
-	Update(skuID int64, data SKUDataParameter) (SKU, error)
-	Delete(skuID int64) error
+	Get(ctx context.Context, skuID int64) (SKU, error)
+	Select(ctx context.Context, params SKUQueryParameter) ([]SKU, error)
+	Create(ctx context.Context, data SKUDataParameter) (SKU, error)
+	Update(ctx context.Context, skuID, version int64, data SKUDataParameter) (SKU, error)
+	Patch(ctx context.Context, skuID, version int64, data SKUDataParameter, fields []string) (SKU, error)
+	Delete(ctx context.Context, skuID, version int64) error
 }
 
 type SKUUsecase interface {
-	Get(skuID int64) (SKUResponse, error)
-	Select(params SKUQueryParameter) ([]SKUResponse, error)
-	Create(data SKUDataParameter) (SKUResponse, error)
-	Update(skuID int64, data SKUDataParameter) (SKUResponse, error)
-	Delete(skuID int64) (GenericResponse, error)
+	Get(ctx context.Context, skuID int64) (SKUResponse, error)
+	Select(ctx context.Context, params SKUQueryParameter) ([]SKUResponse, error)
+	Create(ctx context.Context, data SKUDataParameter) (SKUResponse, error)
+	Update(ctx context.Context, skuID, version int64, data SKUDataParameter) (SKUResponse, error)
+	Patch(ctx context.Context, skuID, version int64, data SKUDataParameter, fields []string) (SKUResponse, error)
+	Delete(ctx context.Context, skuID, version int64) (GenericResponse, error)
 }
@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	SKUBarcodeTypeEAN13    = "ean13"
+	SKUBarcodeTypeUPC      = "upc"
+	SKUBarcodeTypeGTIN     = "gtin"
+	SKUBarcodeTypeCode128  = "code128"
+	SKUBarcodeTypeInternal = "internal"
+
+	PackLevelEach   = "each"
+	PackLevelInner  = "inner"
+	PackLevelCase   = "case"
+	PackLevelPallet = "pallet"
+)
+
+var (
+	ErrSKUBarcodeNotFound = errors.New("barcode does not belong to this SKU")
+	ErrInvalidPackLevel   = errors.New("an each barcode must have a quantity of 1")
+)
+
+// SKUBarcode is an alternative barcode printed on a SKU or on one of its
+// packs, Quantity is how many eaches scanning it stands for.
+type SKUBarcode struct {
+	ID        int64
+	SKUID     int64
+	Barcode   string
+	Type      string
+	PackLevel string
+	Quantity  int64
+	Version   int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (sb SKUBarcode) SKUBarcodeResponse() SKUBarcodeResponse {
+	return SKUBarcodeResponse{
+		ID:        sb.ID,
+		SKUID:     sb.SKUID,
+		Barcode:   sb.Barcode,
+		Type:      sb.Type,
+		PackLevel: sb.PackLevel,
+		Quantity:  sb.Quantity,
+		Version:   sb.Version,
+		CreatedAt: sb.CreatedAt,
+		UpdatedAt: sb.UpdatedAt,
+	}
+}
+
+type SKUBarcodeResponse struct {
+	ID        int64     `json:"id"`
+	SKUID     int64     `json:"sku_id"`
+	Barcode   string    `json:"barcode"`
+	Type      string    `json:"type"`
+	PackLevel string    `json:"pack_level"`
+	Quantity  int64     `json:"quantity"`
+	Version   int64     `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type SKUBarcodeDataParameter struct {
+	Barcode   string `json:"barcode" validate:"required"`
+	Type      string `json:"type" validate:"required,oneof=ean13 upc gtin code128 internal"`
+	PackLevel string `json:"pack_level" validate:"required,oneof=each inner case pallet"`
+	Quantity  int64  `json:"quantity" validate:"required,min=1"`
+}
+
+type SKUBarcodeQueryParameter struct {
+	PaginationQuery
+	ID        []int64
+	SKUID     []int64
+	Barcode   []string
+	PackLevel []string
+}
+
+func (wh *SKUBarcodeQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("invalid Page Parameter")
+		}
+		wh.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("invalid Limit Parameter")
+		}
+		wh.Limit = i
+	}
+
+	if barcodes := uv["barcode"]; len(barcodes) > 0 {
+		wh.Barcode = append(wh.Barcode, barcodes...)
+	}
+
+	if packLevels := uv["pack_level"]; len(packLevels) > 0 {
+		wh.PackLevel = append(wh.PackLevel, packLevels...)
+	}
+
+	return nil
+}
+
+func (wh SKUBarcodeQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = wh.generatePaginationQuery(sb)
+
+	if len(wh.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": wh.ID})
+	}
+
+	if len(wh.SKUID) > 0 {
+		sb = sb.Where(squirrel.Eq{"sku_id": wh.SKUID})
+	}
+
+	if len(wh.Barcode) > 0 {
+		sb = sb.Where(squirrel.Eq{"barcode": wh.Barcode})
+	}
+
+	if len(wh.PackLevel) > 0 {
+		sb = sb.Where(squirrel.Eq{"pack_level": wh.PackLevel})
+	}
+
+	return sb
+}
+
+type SKUBarcodeRepository interface {
+	Get(barcodeID int64) (SKUBarcode, error)
+	Select(params SKUBarcodeQueryParameter) ([]SKUBarcode, error)
+	Create(skuID int64, data SKUBarcodeDataParameter) (SKUBarcode, error)
+	Update(barcodeID, version int64, data SKUBarcodeDataParameter) (SKUBarcode, error)
+	Delete(barcodeID, version int64) error
+}
+
+type SKUBarcodeUsecase interface {
+	Select(ctx context.Context, skuID int64, params SKUBarcodeQueryParameter) ([]SKUBarcodeResponse, error)
+	Create(ctx context.Context, skuID int64, data SKUBarcodeDataParameter) (SKUBarcodeResponse, error)
+	Update(ctx context.Context, skuID int64, barcodeID, version int64, data SKUBarcodeDataParameter) (SKUBarcodeResponse, error)
+	Delete(ctx context.Context, skuID int64, barcodeID, version int64) (GenericResponse, error)
+}
@@ -0,0 +1,141 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultBaseUoM = "pcs"
+
+	mm3PerM3 = 1e9
+)
+
+var (
+	ErrInvalidBaseUoM      = errors.New("unknown base unit of measure")
+	ErrDuplicatePackLevel  = errors.New("pack level defined more than once")
+	ErrInvalidPackQuantity = errors.New("pack quantities must grow with each level and be a multiple of the level below")
+	ErrUnknownPackLevel    = errors.New("SKU has no such pack level")
+
+	// Units stock of a SKU can be counted in
+	BaseUoMs = []string{"pcs", "kg", "g", "l", "ml", "m"}
+
+	// Pack levels from the smallest to the largest
+	PackLevels = []string{PackLevelEach, PackLevelInner, PackLevelCase, PackLevelPallet}
+)
+
+// SKUPack describes one packaging level of a SKU, Quantity is how many base
+// units it holds. Weight is in kilograms and dimensions in millimetres.
+type SKUPack struct {
+	SKUID    int64
+	Level    string
+	Quantity int64
+	WeightKg float64
+	LengthMM float64
+	WidthMM  float64
+	HeightMM float64
+}
+
+func (sp SKUPack) SKUPackResponse() SKUPackResponse {
+	return SKUPackResponse{
+		Level:    sp.Level,
+		Quantity: sp.Quantity,
+		WeightKg: sp.WeightKg,
+		LengthMM: sp.LengthMM,
+		WidthMM:  sp.WidthMM,
+		HeightMM: sp.HeightMM,
+	}
+}
+
+// VolumeM3 is zero when the dimensions are unknown
+func (sp SKUPack) VolumeM3() float64 {
+	return sp.LengthMM * sp.WidthMM * sp.HeightMM / mm3PerM3
+}
+
+type SKUPackResponse struct {
+	Level    string  `json:"level"`
+	Quantity int64   `json:"quantity"`
+	WeightKg float64 `json:"weight_kg"`
+	LengthMM float64 `json:"length_mm"`
+	WidthMM  float64 `json:"width_mm"`
+	HeightMM float64 `json:"height_mm"`
+}
+
+type SKUPackDataParameter struct {
+	Level    string  `json:"level" validate:"required,oneof=each inner case pallet"`
+	Quantity int64   `json:"quantity" validate:"required,min=1"`
+	WeightKg float64 `json:"weight_kg" validate:"min=0"`
+	LengthMM float64 `json:"length_mm" validate:"min=0"`
+	WidthMM  float64 `json:"width_mm" validate:"min=0"`
+	HeightMM float64 `json:"height_mm" validate:"min=0"`
+}
+
+// ValidatePacks checks the unit and the pack hierarchy of a SKU, each holds a
+// single base unit and every larger level holds whole packs of the one below.
+func ValidatePacks(baseUoM string, packs []SKUPackDataParameter) error {
+	known := false
+	for _, uom := range BaseUoMs {
+		if uom == baseUoM {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return ErrInvalidBaseUoM
+	}
+
+	byLevel := make(map[string]SKUPackDataParameter)
+	for _, pack := range packs {
+		if _, ok := byLevel[pack.Level]; ok {
+			return ErrDuplicatePackLevel
+		}
+		byLevel[pack.Level] = pack
+	}
+
+	previous := int64(1)
+	for _, level := range PackLevels {
+		pack, ok := byLevel[level]
+		if !ok {
+			continue
+		}
+
+		if level == PackLevelEach {
+			if pack.Quantity != 1 {
+				return ErrInvalidPackQuantity
+			}
+			continue
+		}
+
+		if pack.Quantity <= previous || pack.Quantity%previous != 0 {
+			return ErrInvalidPackQuantity
+		}
+		previous = pack.Quantity
+	}
+
+	return nil
+}
+
+// Pack returns the given level, an each always exists even when not defined
+func (sk SKU) Pack(level string) (SKUPack, bool) {
+	for _, pack := range sk.Packs {
+		if strings.EqualFold(pack.Level, level) {
+			return pack, true
+		}
+	}
+
+	if len(level) < 1 || level == PackLevelEach {
+		return SKUPack{SKUID: sk.ID, Level: PackLevelEach, Quantity: 1}, true
+	}
+
+	return SKUPack{}, false
+}
+
+// ToBaseQuantity converts a quantity counted in packs of the given level to base units
+func (sk SKU) ToBaseQuantity(level string, quantity int64) (int64, error) {
+	pack, ok := sk.Pack(level)
+	if !ok {
+		return 0, ErrUnknownPackLevel
+	}
+
+	return quantity * pack.Quantity, nil
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -14,6 +15,7 @@ type Warehouse struct {
 	Name      string
 	Latitude  float64
 	Longitude float64
+	Version   int64
 	CreatedAt time.Time
 	UpdatedAt time.Time
 }
@@ -24,6 +26,7 @@ func (wh Warehouse) WarehouseResponse() WarehouseResponse {
 		Name:      wh.Name,
 		Latitude:  wh.Latitude,
 		Longitude: wh.Longitude,
+		Version:   wh.Version,
 		CreatedAt: wh.CreatedAt,
 		UpdatedAt: wh.UpdatedAt,
 	}
@@ -35,6 +38,7 @@ type WarehouseResponse struct {
 	Latitude  float64       `json:"latitude"`
 	Longitude float64       `json:"longitude"`
 	Bins      []BinResponse `json:"bins"`
+	Version   int64         `json:"version"`
 	CreatedAt time.Time     `json:"created_at"`
 	UpdatedAt time.Time     `json:"updated_at"`
 }
@@ -45,9 +49,20 @@ type WarehouseDataParameter struct {
 	Longitude float64 `json:"longitude" validate:"required,longitude"`
 }
 
+// WarehouseDataParameter is the write form of the warehouse, the document a
+// merge patch applies to
+func (wr WarehouseResponse) WarehouseDataParameter() WarehouseDataParameter {
+	return WarehouseDataParameter{
+		Name:      wr.Name,
+		Latitude:  wr.Latitude,
+		Longitude: wr.Longitude,
+	}
+}
+
 type WarehouseQueryParameter struct {
 	PaginationQuery
-	ID []int64
+	ID   []int64
+	Name []string
 }
 
 func (wh *WarehouseQueryParameter) Parse(uv url.Values) error {
@@ -78,6 +93,10 @@ func (wh *WarehouseQueryParameter) Parse(uv url.Values) error {
 		}
 	}
 
+	if names := uv["name"]; len(names) > 0 {
+		wh.Name = append(wh.Name, names...)
+	}
+
 	return nil
 }
 
@@ -88,21 +107,29 @@ func (wh WarehouseQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squir
 		sb = sb.Where(squirrel.Eq{"id": wh.ID})
 	}
 
+	if len(wh.Name) > 0 {
+		sb = sb.Where(squirrel.Eq{"name": wh.Name})
+	}
+
 	return sb
 }
 
 type WarehouseRepository interface {
-	Get(warehouseID int64) (Warehouse, error)
-	Select(params WarehouseQueryParameter) ([]Warehouse, error)
-	Create(data WarehouseDataParameter) (Warehouse, error)
-	Update(warehouseID int64, data WarehouseDataParameter) (Warehouse, error)
-	Delete(warehouseID int64) error
+	Get(ctx context.Context, warehouseID int64) (Warehouse, error)
+	Select(ctx context.Context, params WarehouseQueryParameter) ([]Warehouse, error)
+	Create(ctx context.Context, data WarehouseDataParameter) (Warehouse, error)
+	Update(ctx context.Context, warehouseID, version int64, data WarehouseDataParameter) (Warehouse, error)
+	Patch(ctx context.Context, warehouseID, version int64, data WarehouseDataParameter, fields []string) (Warehouse, error)
+	Delete(ctx context.Context, warehouseID, version int64) error
 }
 
 type WarehouseUsecase interface {
-	Get(warehouseID int64) (WarehouseResponse, error)
-	Select(params WarehouseQueryParameter) ([]WarehouseResponse, error)
-	Create(data WarehouseDataParameter) (WarehouseResponse, error)
-	Update(warehouseID int64, data WarehouseDataParameter) (WarehouseResponse, error)
-	Delete(warehouseID int64) (GenericResponse, error)
+	Get(ctx context.Context, warehouseID int64) (WarehouseResponse, error)
+	Select(ctx context.Context, params WarehouseQueryParameter) ([]WarehouseResponse, error)
+	// Names returns the names of the given warehouses, missing ones are left out
+	Names(ctx context.Context, warehouseIDs []int64) ([]string, error)
+	Create(ctx context.Context, data WarehouseDataParameter) (WarehouseResponse, error)
+	Update(ctx context.Context, warehouseID, version int64, data WarehouseDataParameter) (WarehouseResponse, error)
+	Patch(ctx context.Context, warehouseID, version int64, data WarehouseDataParameter, fields []string) (WarehouseResponse, error)
+	Delete(ctx context.Context, warehouseID, version int64) (GenericResponse, error)
 }
@@ -0,0 +1,374 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	// Dead deliveries ran out of attempts, they are only sent again when
+	// redelivered by hand
+	WebhookDeliveryDead = "dead"
+
+	// Receivers recompute the HMAC-SHA256 of "<timestamp>.<body>" with the
+	// subscription secret and compare it with the signature, sent as
+	// "sha256=<hex>"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+var (
+	ErrUnknownEventType = errors.New("unknown event type")
+	// The delivery was attempted again by another worker after the lease of
+	// the one recording this attempt ran out
+	ErrWebhookDeliveryConflict = errors.New("webhook delivery attempted by another worker")
+)
+
+type WebhookConfig struct {
+	Enabled bool
+	// How often due deliveries are looked for
+	Interval    time.Duration `validate:"min=0"`
+	BatchSize   int           `validate:"min=0"`
+	MaxAttempts int           `validate:"min=0"`
+	// Timeout of a single delivery
+	Timeout time.Duration `validate:"min=0"`
+	// Claimed deliveries are skipped by other workers for Lease, it should
+	// outlast sending a whole batch that times out
+	Lease   time.Duration `validate:"min=0"`
+	Backoff WebhookBackoffConfig
+}
+
+// WebhookBackoffConfig spaces out retries, the nth retry waits
+// Initial * Factor^n up to Max
+type WebhookBackoffConfig struct {
+	Initial time.Duration `validate:"min=0"`
+	Max     time.Duration `validate:"min=0"`
+	Factor  float64       `validate:"min=0"`
+	Jitter  time.Duration `validate:"min=0"`
+}
+
+// WebhookSubscription sends events whose type matches one of EventTypes to
+// URL. Types may use path.Match patterns, "bin.*" matches every bin event.
+type WebhookSubscription struct {
+	ID         int64
+	URL        string
+	EventTypes []string
+	Secret     string
+	Active     bool
+	Version    int64
+	CreatedBy  string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (ws WebhookSubscription) Matches(eventType string) bool {
+	for _, pattern := range ws.EventTypes {
+		if ok, _ := path.Match(pattern, eventType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (ws WebhookSubscription) WebhookSubscriptionResponse() WebhookSubscriptionResponse {
+	return WebhookSubscriptionResponse{
+		ID:         ws.ID,
+		URL:        ws.URL,
+		EventTypes: ws.EventTypes,
+		Active:     ws.Active,
+		Version:    ws.Version,
+		CreatedBy:  ws.CreatedBy,
+		CreatedAt:  ws.CreatedAt,
+		UpdatedAt:  ws.UpdatedAt,
+	}
+}
+
+type WebhookSubscriptionResponse struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	Version    int64     `json:"version"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// WebhookSubscriptionCreatedResponse carries the signing secret, it is only
+// shown once
+type WebhookSubscriptionCreatedResponse struct {
+	WebhookSubscriptionResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookSubscriptionDataParameter struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,required"`
+	// Optional, defaults to true
+	Active *bool `json:"active"`
+
+	// Filled in by the usecase
+	Secret    string `json:"-"`
+	CreatedBy string `json:"-"`
+}
+
+// ValidateEventTypes checks every type or pattern matches a known event
+func (wd WebhookSubscriptionDataParameter) ValidateEventTypes() error {
+	for _, pattern := range wd.EventTypes {
+		matched := false
+		for _, eventType := range EventTypes {
+			if ok, err := path.Match(pattern, eventType); err == nil && ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return ErrUnknownEventType
+		}
+	}
+	return nil
+}
+
+type WebhookQueryParameter struct {
+	PaginationQuery
+	ID     []int64
+	Active *bool
+}
+
+func (wq *WebhookQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		wq.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		wq.Limit = i
+	}
+
+	if uid := uv["id"]; len(uid) > 0 {
+		for _, _uid := range uid {
+			i, err := strconv.ParseInt(_uid, 10, 64)
+			if err != nil {
+				return errors.New("Invalid ID Parameter")
+			}
+
+			wq.ID = append(wq.ID, i)
+		}
+	}
+
+	if active := uv.Get("active"); len(active) > 0 {
+		b, err := strconv.ParseBool(active)
+		if err != nil {
+			return errors.New("Invalid Active Parameter")
+		}
+		wq.Active = &b
+	}
+
+	return nil
+}
+
+func (wq WebhookQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = wq.generatePaginationQuery(sb)
+
+	if len(wq.ID) > 0 {
+		sb = sb.Where(squirrel.Eq{"id": wq.ID})
+	}
+
+	if wq.Active != nil {
+		sb = sb.Where(squirrel.Eq{"active": *wq.Active})
+	}
+
+	return sb.OrderBy("id DESC")
+}
+
+// WebhookDelivery is one event sent to one subscription, it doubles as the
+// delivery log of the subscription
+type WebhookDelivery struct {
+	ID             int64
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	ResponseCode   int
+	LastError      string
+	NextAttemptAt  time.Time
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (wd WebhookDelivery) WebhookDeliveryResponse() WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             wd.ID,
+		SubscriptionID: wd.SubscriptionID,
+		EventID:        wd.EventID,
+		EventType:      wd.EventType,
+		Payload:        wd.Payload,
+		Status:         wd.Status,
+		Attempts:       wd.Attempts,
+		ResponseCode:   wd.ResponseCode,
+		LastError:      wd.LastError,
+		NextAttemptAt:  wd.NextAttemptAt,
+		DeliveredAt:    wd.DeliveredAt,
+		CreatedAt:      wd.CreatedAt,
+		UpdatedAt:      wd.UpdatedAt,
+	}
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	EventID        int64           `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseCode   int             `json:"response_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type WebhookDeliveryDataParameter struct {
+	SubscriptionID int64
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	NextAttemptAt  time.Time
+}
+
+// WebhookDeliveryAttempt is the outcome of sending a delivery once
+type WebhookDeliveryAttempt struct {
+	Status        string
+	Attempts      int
+	ResponseCode  int
+	LastError     string
+	NextAttemptAt time.Time
+	DeliveredAt   *time.Time
+}
+
+type WebhookDeliveryQueryParameter struct {
+	PaginationQuery
+	SubscriptionID []int64
+	EventType      []string
+	Status         []string
+}
+
+func (wq *WebhookDeliveryQueryParameter) Parse(uv url.Values) error {
+	if page := uv.Get("page"); len(page) > 0 {
+		i, err := strconv.ParseInt(page, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Page Parameter")
+		}
+		wq.Page = i
+	}
+
+	if limit := uv.Get("limit"); len(limit) > 0 {
+		i, err := strconv.ParseInt(limit, 10, 64)
+		if err != nil {
+			return errors.New("Invalid Limit Parameter")
+		}
+		wq.Limit = i
+	}
+
+	if subscriptionIDs := uv["subscription_id"]; len(subscriptionIDs) > 0 {
+		for _, subscriptionID := range subscriptionIDs {
+			i, err := strconv.ParseInt(subscriptionID, 10, 64)
+			if err != nil {
+				return errors.New("Invalid Subscription ID Parameter")
+			}
+
+			wq.SubscriptionID = append(wq.SubscriptionID, i)
+		}
+	}
+
+	if eventTypes := uv["event_type"]; len(eventTypes) > 0 {
+		wq.EventType = append(wq.EventType, eventTypes...)
+	}
+
+	if statuses := uv["status"]; len(statuses) > 0 {
+		wq.Status = append(wq.Status, statuses...)
+	}
+
+	return nil
+}
+
+func (wq WebhookDeliveryQueryParameter) BuildSQLQuery(sb squirrel.SelectBuilder) squirrel.SelectBuilder {
+	sb = wq.generatePaginationQuery(sb)
+
+	if len(wq.SubscriptionID) > 0 {
+		sb = sb.Where(squirrel.Eq{"subscription_id": wq.SubscriptionID})
+	}
+
+	if len(wq.EventType) > 0 {
+		sb = sb.Where(squirrel.Eq{"event_type": wq.EventType})
+	}
+
+	if len(wq.Status) > 0 {
+		sb = sb.Where(squirrel.Eq{"status": wq.Status})
+	}
+
+	return sb.OrderBy("id DESC")
+}
+
+type WebhookRepository interface {
+	Get(ctx context.Context, subscriptionID int64) (WebhookSubscription, error)
+	Select(ctx context.Context, params WebhookQueryParameter) ([]WebhookSubscription, error)
+	SelectActive(ctx context.Context) ([]WebhookSubscription, error)
+	Create(ctx context.Context, data WebhookSubscriptionDataParameter) (WebhookSubscription, error)
+	Update(ctx context.Context, subscriptionID, version int64, data WebhookSubscriptionDataParameter) (WebhookSubscription, error)
+	// Delete removes the subscription together with its deliveries
+	Delete(ctx context.Context, subscriptionID, version int64) error
+
+	GetDelivery(ctx context.Context, deliveryID int64) (WebhookDelivery, error)
+	SelectDeliveries(ctx context.Context, params WebhookDeliveryQueryParameter) ([]WebhookDelivery, error)
+	// ClaimDueDeliveries moves the next attempt of the due deliveries it
+	// returns lease past now, other workers skip them until it runs out
+	ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]WebhookDelivery, error)
+	// CreateDelivery does nothing when the event was already queued for the
+	// subscription, so events published twice are delivered once
+	CreateDelivery(ctx context.Context, data WebhookDeliveryDataParameter) error
+	// UpdateDelivery records an attempt made when the delivery had attempts
+	// attempts, ErrWebhookDeliveryConflict when another one was recorded since
+	UpdateDelivery(ctx context.Context, deliveryID int64, attempts int, data WebhookDeliveryAttempt) (WebhookDelivery, error)
+}
+
+type WebhookUsecase interface {
+	Get(ctx context.Context, subscriptionID int64) (WebhookSubscriptionResponse, error)
+	Select(ctx context.Context, params WebhookQueryParameter) ([]WebhookSubscriptionResponse, error)
+	Create(ctx context.Context, data WebhookSubscriptionDataParameter) (WebhookSubscriptionCreatedResponse, error)
+	Update(ctx context.Context, subscriptionID, version int64, data WebhookSubscriptionDataParameter) (WebhookSubscriptionResponse, error)
+	Delete(ctx context.Context, subscriptionID, version int64) (GenericResponse, error)
+	SelectDeliveries(ctx context.Context, params WebhookDeliveryQueryParameter) ([]WebhookDeliveryResponse, error)
+	// Redeliver sends a delivery again right away, whatever its status
+	Redeliver(ctx context.Context, deliveryID int64) (WebhookDeliveryResponse, error)
+
+	// Enqueue queues an event for every active subscription matching it
+	Enqueue(ctx context.Context, event EventResponse) error
+	// Deliver sends one batch of due deliveries
+	Deliver(ctx context.Context) (int, error)
+	// Run delivers until ctx is done
+	Run(ctx context.Context)
+}
@@ -0,0 +1,177 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// Methods whose requests may carry an Idempotency-Key
+	mutatingMethods = map[string]bool{
+		http.MethodPost:   true,
+		http.MethodPut:    true,
+		http.MethodPatch:  true,
+		http.MethodDelete: true,
+	}
+)
+
+// NewMiddleware answers retries of a mutating request sent with the same
+// Idempotency-Key with the response of the first one. It has to run after
+// authentication, keys are scoped to the principal. Server errors are not
+// kept so the request can be retried. Bodies are buffered to fingerprint the
+// request, those over maxBodyBytes get 413.
+func NewMiddleware(logger *logrus.Logger, cfg domain.IdempotencyConfig, maxBodyBytes int64, idempotency domain.IdempotencyUsecase) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(domain.HeaderIdempotencyKey)
+			if len(key) < 1 || !mutatingMethods[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			bodyData, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+			if err != nil {
+				if httpcommon.IsRequestTooLarge(err) {
+					httpcommon.ResponseJSONError(w, http.StatusRequestEntityTooLarge, "Request Too Large")
+					return
+				}
+				httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+				return
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(bodyData))
+
+			record, err := idempotency.Begin(r.Context(), key, fingerprint(r, bodyData))
+			if err != nil {
+				switch {
+				case errors.Is(err, domain.ErrIdempotencyKeyInvalid):
+					httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+				case errors.Is(err, domain.ErrIdempotencyInProgress):
+					httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+				case errors.Is(err, domain.ErrIdempotencyKeyMismatch):
+					httpcommon.ResponseJSONError(w, http.StatusUnprocessableEntity, err.Error())
+				default:
+					logger.Errorln(err)
+					httpcommon.ResponseJSONError(w, http.StatusInternalServerError, "Cannot Check Idempotency Key")
+				}
+				return
+			}
+
+			if record.Completed() {
+				replay(w, record)
+				return
+			}
+
+			rec := &recorder{
+				ResponseWriter: w,
+				before:         w.Header().Clone(),
+			}
+
+			// Free the key when the handler panics or fails, a retry then
+			// runs the request again
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+				if err := idempotency.Release(r.Context(), record); err != nil {
+					logger.Errorln(err)
+				}
+			}()
+
+			next.ServeHTTP(rec, r)
+
+			if rec.statusCode >= http.StatusInternalServerError {
+				return
+			}
+			if rec.statusCode == 0 {
+				rec.statusCode = http.StatusOK
+			}
+
+			record.StatusCode = rec.statusCode
+			record.Header = rec.header()
+			record.Body = rec.body.Bytes()
+			if err := idempotency.Complete(r.Context(), record); err != nil {
+				logger.Errorln(err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// fingerprint tells requests apart, a key reused for another method, path or
+// body is a different request
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, record domain.IdempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(domain.HeaderIdempotentReplayed, "true")
+	w.WriteHeader(record.StatusCode)
+	w.Write(record.Body)
+}
+
+// recorder passes the response through and keeps a copy of it
+type recorder struct {
+	http.ResponseWriter
+	before     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *recorder) WriteHeader(statusCode int) {
+	if rec.statusCode == 0 {
+		rec.statusCode = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// header returns the headers the handler set, the ones set by outer
+// middleware are theirs to set again
+func (rec *recorder) header() http.Header {
+	header := make(http.Header)
+	for name, values := range rec.Header() {
+		if !equalValues(rec.before[name], values) {
+			header[name] = values
+		}
+	}
+	return header
+}
+
+func equalValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
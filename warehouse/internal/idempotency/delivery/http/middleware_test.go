@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/idempotency/repository"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/idempotency/usecase"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	testKey          = "key-1"
+	testMaxBodyBytes = 16
+)
+
+func newRequest(body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/skus", strings.NewReader(body))
+	r.Header.Set(domain.HeaderIdempotencyKey, testKey)
+	return r
+}
+
+// serve runs a request, a panic in the handler is recovered as the router
+// would and answered with a 500
+func serve(handler http.Handler, r *http.Request) (rec *httptest.ResponseRecorder) {
+	rec = httptest.NewRecorder()
+	defer func() {
+		if recover() != nil {
+			rec.Code = http.StatusInternalServerError
+		}
+	}()
+	handler.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestMiddleware(t *testing.T) {
+	created := func(w http.ResponseWriter) {
+		w.Header().Set("Location", "/skus/1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}
+
+	tests := []struct {
+		name string
+		// respond answers the n-th call of the handler, counted from 1
+		respond      func(call int, w http.ResponseWriter)
+		bodies       []string
+		reserved     bool
+		wantStatus   int
+		wantBody     string
+		wantCalls    int
+		wantReplayed bool
+	}{
+		{
+			name:         "retry is replayed",
+			respond:      func(call int, w http.ResponseWriter) { created(w) },
+			bodies:       []string{`{"a":1}`, `{"a":1}`},
+			wantStatus:   http.StatusCreated,
+			wantBody:     `{"id":1}`,
+			wantCalls:    1,
+			wantReplayed: true,
+		},
+		{
+			name:       "key reused for another body",
+			respond:    func(call int, w http.ResponseWriter) { created(w) },
+			bodies:     []string{`{"a":1}`, `{"a":2}`},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name:       "request still in progress",
+			respond:    func(call int, w http.ResponseWriter) { created(w) },
+			bodies:     []string{`{"a":1}`},
+			reserved:   true,
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name: "server error releases the key",
+			respond: func(call int, w http.ResponseWriter) {
+				if call == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				created(w)
+			},
+			bodies:     []string{`{"a":1}`, `{"a":1}`},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantCalls:  2,
+		},
+		{
+			name: "panic releases the key",
+			respond: func(call int, w http.ResponseWriter) {
+				if call == 1 {
+					panic("handler failed")
+				}
+				created(w)
+			},
+			bodies:     []string{`{"a":1}`, `{"a":1}`},
+			wantStatus: http.StatusCreated,
+			wantBody:   `{"id":1}`,
+			wantCalls:  2,
+		},
+		{
+			name:       "body over the limit",
+			respond:    func(call int, w http.ResponseWriter) { created(w) },
+			bodies:     []string{strings.Repeat("a", testMaxBodyBytes+1)},
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	cfg := domain.IdempotencyConfig{Enabled: true}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idempotency := usecase.NewUsecase(logger, cfg, repository.NewMemory(logger))
+
+			calls := 0
+			handler := NewMiddleware(logger, cfg, testMaxBodyBytes, idempotency)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				tt.respond(calls, w)
+			}))
+
+			if tt.reserved {
+				body := tt.bodies[len(tt.bodies)-1]
+				if _, err := idempotency.Begin(context.Background(), testKey, fingerprint(newRequest(body), []byte(body))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var rec *httptest.ResponseRecorder
+			for _, body := range tt.bodies {
+				rec = serve(handler, newRequest(body))
+			}
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if len(tt.wantBody) > 0 && rec.Body.String() != tt.wantBody {
+				t.Errorf("body = %s, want %s", rec.Body, tt.wantBody)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler called %d times, want %d", calls, tt.wantCalls)
+			}
+			if replayed := rec.Header().Get(domain.HeaderIdempotentReplayed) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && rec.Header().Get("Location") != "/skus/1" {
+				t.Errorf("Location = %q, want the header of the first response", rec.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestRecorderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("X-Request-Id", "outer")
+	w.Header().Set("Vary", "Origin")
+
+	rec := &recorder{
+		ResponseWriter: w,
+		before:         w.Header().Clone(),
+	}
+	rec.Header().Set("Location", "/skus/1")
+	rec.Header().Add("Vary", "Accept")
+	rec.WriteHeader(http.StatusCreated)
+
+	want := http.Header{
+		"Location": {"/skus/1"},
+		"Vary":     {"Origin", "Accept"},
+	}
+	if got := rec.header(); !reflect.DeepEqual(got, want) {
+		t.Errorf("header = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryRepository keeps records in the process, it suits a single instance
+// and loses every key on restart
+type memoryRepository struct {
+	logger  *logrus.Logger
+	mu      sync.Mutex
+	records map[string]domain.IdempotencyRecord
+}
+
+func NewMemory(logger *logrus.Logger) domain.IdempotencyRepository {
+	return &memoryRepository{
+		logger:  logger,
+		records: make(map[string]domain.IdempotencyRecord),
+	}
+}
+
+func (mr *memoryRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if stored, ok := mr.records[record.Key]; ok && !stored.ExpiresAt.Before(record.CreatedAt) {
+		return stored, false, nil
+	}
+
+	mr.records[record.Key] = record
+	return record, true, nil
+}
+
+func (mr *memoryRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	stored, ok := mr.records[record.Key]
+	if !ok {
+		return nil
+	}
+
+	stored.StatusCode = record.StatusCode
+	stored.Header = record.Header
+	stored.Body = record.Body
+	stored.ExpiresAt = record.ExpiresAt
+	mr.records[record.Key] = stored
+	return nil
+}
+
+func (mr *memoryRepository) Release(ctx context.Context, key string) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	if stored, ok := mr.records[key]; ok && !stored.Completed() {
+		delete(mr.records, key)
+	}
+	return nil
+}
+
+func (mr *memoryRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var (
+		deleted int64
+	)
+
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	for key, stored := range mr.records {
+		if stored.ExpiresAt.Before(now) {
+			delete(mr.records, key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
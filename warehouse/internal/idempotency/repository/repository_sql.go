@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+func (ir *idempotencyRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	var (
+		stored   domain.IdempotencyRecord
+		reserved bool
+	)
+
+	err := sqltx.Run(ctx, ir.sql, func(ctx context.Context) error {
+		tx := sqltx.From(ctx, ir.sql)
+
+		// An expired record no longer holds its key
+		query, args, err := squirrel.Delete("idempotency_keys").
+			Where(squirrel.Eq{"idempotency_key": record.Key}).
+			Where(squirrel.Lt{"expires_at": record.CreatedAt}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+			return err
+		}
+
+		query, args, err = squirrel.Insert("idempotency_keys").Options("IGNORE").Columns(
+			"idempotency_key",
+			"fingerprint",
+			"expires_at",
+			"created_at",
+			"updated_at",
+		).Values(
+			record.Key,
+			record.Fingerprint,
+			record.ExpiresAt,
+			record.CreatedAt,
+			record.CreatedAt,
+		).ToSql()
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if affected > 0 {
+			stored, reserved = record, true
+			return nil
+		}
+
+		stored, err = ir.get(ctx, record.Key)
+		return err
+	})
+	if err != nil {
+		ir.logger.Errorln(err)
+		return domain.IdempotencyRecord{}, false, err
+	}
+
+	return stored, reserved, nil
+}
+
+func (ir *idempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := squirrel.Update("idempotency_keys").
+		Set("status_code", record.StatusCode).
+		Set("header", string(header)).
+		Set("body", record.Body).
+		Set("expires_at", record.ExpiresAt).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"idempotency_key": record.Key}).
+		ToSql()
+
+	if err != nil {
+		ir.logger.Errorln(err)
+		return err
+	}
+
+	query = ir.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, ir.sql).Exec(query, args...); err != nil {
+		ir.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (ir *idempotencyRepository) Release(ctx context.Context, key string) error {
+	query, args, err := squirrel.Delete("idempotency_keys").
+		Where(squirrel.Eq{"idempotency_key": key, "status_code": 0}).
+		ToSql()
+
+	if err != nil {
+		ir.logger.Errorln(err)
+		return err
+	}
+
+	query = ir.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, ir.sql).Exec(query, args...); err != nil {
+		ir.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (ir *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query, args, err := squirrel.Delete("idempotency_keys").
+		Where(squirrel.Lt{"expires_at": now}).
+		ToSql()
+
+	if err != nil {
+		ir.logger.Errorln(err)
+		return 0, err
+	}
+
+	query = ir.sql.Rebind(query)
+	result, err := sqltx.From(ctx, ir.sql).Exec(query, args...)
+	if err != nil {
+		ir.logger.Errorln(err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (ir *idempotencyRepository) get(ctx context.Context, key string) (domain.IdempotencyRecord, error) {
+	var (
+		record domain.IdempotencyRecord
+		header sql.NullString
+	)
+
+	query, args, err := squirrel.Select(
+		"idempotency_key",
+		"fingerprint",
+		"status_code",
+		"header",
+		"body",
+		"expires_at",
+		"created_at",
+	).From("idempotency_keys").Where(
+		squirrel.Eq{"idempotency_key": key},
+	).ToSql()
+
+	if err != nil {
+		return record, err
+	}
+
+	query = ir.sql.Rebind(query)
+	row := sqltx.From(ctx, ir.sql).QueryRow(query, args...)
+	if err := row.Scan(
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&header,
+		&record.Body,
+		&record.ExpiresAt,
+		&record.CreatedAt,
+	); err != nil {
+		return record, err
+	}
+
+	if header.Valid {
+		if err := json.Unmarshal([]byte(header.String), &record.Header); err != nil {
+			return record, err
+		}
+	}
+
+	return record, nil
+}
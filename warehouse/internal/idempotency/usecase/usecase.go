@@ -0,0 +1,127 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxKeyLength = 255
+)
+
+type idempotencyUsecase struct {
+	logger      *logrus.Logger
+	config      domain.IdempotencyConfig
+	idempotency domain.IdempotencyRepository
+}
+
+func NewUsecase(logger *logrus.Logger, config domain.IdempotencyConfig, idempotency domain.IdempotencyRepository) domain.IdempotencyUsecase {
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.Lease <= 0 {
+		config.Lease = time.Minute
+	}
+	if config.Interval <= 0 {
+		config.Interval = time.Hour
+	}
+
+	return &idempotencyUsecase{
+		logger:      logger,
+		config:      config,
+		idempotency: idempotency,
+	}
+}
+
+func (uc *idempotencyUsecase) Begin(ctx context.Context, key, fingerprint string) (domain.IdempotencyRecord, error) {
+	if !validKey(key) {
+		return domain.IdempotencyRecord{}, domain.ErrIdempotencyKeyInvalid
+	}
+
+	t := time.Now()
+	record := domain.IdempotencyRecord{
+		Key:         scopedKey(ctx, key),
+		Fingerprint: fingerprint,
+		ExpiresAt:   t.Add(uc.config.Lease),
+		CreatedAt:   t,
+	}
+
+	stored, reserved, err := uc.idempotency.Reserve(ctx, record)
+	if err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+
+	switch {
+	case reserved:
+		return stored, nil
+	case stored.Fingerprint != fingerprint:
+		return domain.IdempotencyRecord{}, domain.ErrIdempotencyKeyMismatch
+	case !stored.Completed():
+		return domain.IdempotencyRecord{}, domain.ErrIdempotencyInProgress
+	}
+
+	return stored, nil
+}
+
+func (uc *idempotencyUsecase) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	record.ExpiresAt = time.Now().Add(uc.config.TTL)
+	return uc.idempotency.Complete(ctx, record)
+}
+
+func (uc *idempotencyUsecase) Release(ctx context.Context, record domain.IdempotencyRecord) error {
+	return uc.idempotency.Release(ctx, record.Key)
+}
+
+func (uc *idempotencyUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := uc.idempotency.DeleteExpired(ctx, time.Now())
+			if err != nil {
+				uc.logger.Errorln(err)
+				continue
+			}
+			if deleted > 0 {
+				uc.logger.Debugf("idempotency purged %d expired keys", deleted)
+			}
+		}
+	}
+}
+
+// scopedKey ties key to the principal of ctx so two clients picking the same
+// key never see each other's responses
+func scopedKey(ctx context.Context, key string) string {
+	var (
+		scope string
+	)
+
+	if principal, ok := domain.PrincipalFromContext(ctx); ok {
+		scope = principal.Type + ":" + principal.ID
+	}
+
+	sum := sha256.Sum256([]byte(scope + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func validKey(key string) bool {
+	if len(key) < 1 || len(key) > maxKeyLength {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	inventory domain.InventoryUsecase
+	bin       domain.BinUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, inventory domain.InventoryUsecase, bin domain.BinUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		inventory: inventory,
+		bin:       bin,
+		validator: validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/inventory", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/inventory/adjust", httpInstance.Adjust).Methods("POST")
+	router.HandleFunc("/inventory/allocate", httpInstance.Allocate).Methods("POST")
+	router.HandleFunc("/inventory/expiring", httpInstance.Expiring).Methods("GET")
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.StockBalanceQueryParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list stock of the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermStockRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.StockBalanceResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.inventory.Select(r.Context(), queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Inventory")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Adjust(w http.ResponseWriter, r *http.Request) {
+	var (
+		adjustData domain.StockAdjustParameter
+	)
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &adjustData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&adjustData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	if err := h.authorizeBin(r, domain.PermStockWrite, adjustData.BinID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	response, err := h.inventory.Adjust(r.Context(), adjustData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Adjusting Stock")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Allocate(w http.ResponseWriter, r *http.Request) {
+	var (
+		allocateData domain.StockAllocateParameter
+	)
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &allocateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&allocateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	// A proposal only reads stock, without a warehouse it spans every one
+	perm := domain.PermStockRead
+	if allocateData.Commit {
+		perm = domain.PermStockWrite
+	}
+	if err := domain.Authorize(r.Context(), perm, allocateData.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.inventory.Allocate(r.Context(), allocateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Allocating Stock")
+		return
+	}
+
+	if response.Committed {
+		httpcommon.ResponseJSON(w, http.StatusCreated, response)
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Expiring(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.ExpiringQueryParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermStockRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.ExpiringStockResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.inventory.Expiring(r.Context(), queryParam)
+	if err != nil {
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Expiring Inventory")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+// authorizeBin checks perm in the warehouse the bin is stored in
+func (h *httpDelivery) authorizeBin(r *http.Request, perm domain.Permission, binID int64) error {
+	binData, err := h.bin.Get(r.Context(), binID)
+	if err != nil {
+		return err
+	}
+
+	return domain.Authorize(r.Context(), perm, binData.WarehouseID)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrInsufficientStock):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, "Not Enough Stock")
+	case errors.Is(err, domain.ErrBinCapacityExceeded):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, "Bin Capacity Exceeded")
+	case errors.Is(err, domain.ErrHandlingTemp),
+		errors.Is(err, domain.ErrHandlingHazmat),
+		errors.Is(err, domain.ErrHandlingFragile),
+		errors.Is(err, domain.ErrHandlingStackable):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrLotNotFound):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Lot, Make sure the Lot belongs to the SKU")
+	case errors.Is(err, domain.ErrUnknownPackLevel):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "SKU has no such Pack Level")
+	case errors.Is(err, domain.ErrInvalidQuantity):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Quantity must not be zero")
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
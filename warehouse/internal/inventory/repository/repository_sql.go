@@ -0,0 +1,197 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+func (sr *stockRepository) Select(params domain.StockBalanceQueryParameter) ([]domain.StockBalance, error) {
+	var (
+		balancesData []domain.StockBalance
+	)
+
+	selector := squirrel.Select(
+		"sb.id",
+		"sb.sku_id",
+		"s.sku",
+		"sb.lot_id",
+		"l.lot_number",
+		"l.expires_at",
+		"sb.bin_id",
+		"b.name",
+		"b.warehouse_id",
+		"sb.quantity",
+		"sb.created_at",
+		"sb.updated_at",
+	).From("stock_balances sb").
+		Join("skus s ON s.id = sb.sku_id").
+		Join("lots l ON l.id = sb.lot_id").
+		Join("bins b ON b.id = sb.bin_id")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return balancesData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	rows, err := sr.sql.Query(query, args...)
+	if err != nil {
+		return balancesData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			balanceData domain.StockBalance
+			expiresAt   sql.NullTime
+		)
+
+		if err := rows.Scan(
+			&balanceData.ID,
+			&balanceData.SKUID,
+			&balanceData.SKU,
+			&balanceData.LotID,
+			&balanceData.LotNumber,
+			&expiresAt,
+			&balanceData.BinID,
+			&balanceData.BinCode,
+			&balanceData.WarehouseID,
+			&balanceData.Quantity,
+			&balanceData.CreatedAt,
+			&balanceData.UpdatedAt,
+		); err != nil {
+			return balancesData, err
+		}
+
+		if expiresAt.Valid {
+			balanceData.ExpiresAt = &expiresAt.Time
+		}
+
+		balancesData = append(balancesData, balanceData)
+	}
+
+	return balancesData, nil
+}
+
+// Adjust creates the balance when it does not exist yet and refuses to take
+// it below zero.
+func (sr *stockRepository) Adjust(data domain.StockAdjustParameter) (domain.StockBalance, error) {
+	var (
+		balanceData domain.StockBalance
+		t           = time.Now()
+	)
+
+	tx, err := sr.sql.Beginx()
+	if err != nil {
+		return balanceData, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := squirrel.Insert("stock_balances").Columns(
+		"sku_id",
+		"lot_id",
+		"bin_id",
+		"quantity",
+		"created_at",
+		"updated_at",
+	).Values(
+		data.SKUID,
+		data.LotID,
+		data.BinID,
+		0,
+		t, t,
+	).Suffix("ON DUPLICATE KEY UPDATE id = id").ToSql()
+	if err != nil {
+		return balanceData, err
+	}
+
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		sr.logger.Errorln(err)
+		return balanceData, err
+	}
+
+	query, args, err = squirrel.Update("stock_balances").
+		Set("quantity", squirrel.Expr("quantity + ?", data.Quantity)).
+		Set("updated_at", t).
+		Where(squirrel.Eq{
+			"sku_id": data.SKUID,
+			"lot_id": data.LotID,
+			"bin_id": data.BinID,
+		}).
+		Where(squirrel.Expr("quantity + ? >= 0", data.Quantity)).
+		ToSql()
+	if err != nil {
+		return balanceData, err
+	}
+
+	result, err := tx.Exec(tx.Rebind(query), args...)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return balanceData, err
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return balanceData, err
+	} else if affected < 1 {
+		return balanceData, domain.ErrInsufficientStock
+	}
+
+	if err := tx.Commit(); err != nil {
+		return balanceData, err
+	}
+
+	balancesData, err := sr.Select(domain.StockBalanceQueryParameter{
+		SKUID: []int64{data.SKUID},
+		LotID: []int64{data.LotID},
+		BinID: []int64{data.BinID},
+	})
+	if err != nil {
+		return balanceData, err
+	}
+
+	if len(balancesData) < 1 {
+		return balanceData, sql.ErrNoRows
+	}
+
+	return balancesData[0], nil
+}
+
+// Allocate takes every pick off its balance, either all of them succeed or none
+func (sr *stockRepository) Allocate(picks []domain.StockPick) error {
+	tx, err := sr.sql.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, pick := range picks {
+		query, args, err := squirrel.Update("stock_balances").
+			Set("quantity", squirrel.Expr("quantity - ?", pick.Quantity)).
+			Set("updated_at", time.Now()).
+			Where(squirrel.Eq{"id": pick.BalanceID}).
+			Where(squirrel.GtOrEq{"quantity": pick.Quantity}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			sr.logger.Errorln(err)
+			return err
+		}
+
+		// Somebody else picked from this balance since it was read
+		if affected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if affected < 1 {
+			return domain.ErrInsufficientStock
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,280 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type inventoryUsecase struct {
+	logger    *logrus.Logger
+	stock     domain.StockRepository
+	sku       domain.SKURepository
+	lot       domain.LotRepository
+	bin       domain.BinRepository
+	warehouse domain.WarehouseRepository
+	commodity domain.CommodityRepository
+}
+
+func NewUsecase(logger *logrus.Logger, stock domain.StockRepository, sku domain.SKURepository, lot domain.LotRepository, bin domain.BinRepository, warehouse domain.WarehouseRepository, commodity domain.CommodityRepository) domain.InventoryUsecase {
+	return &inventoryUsecase{
+		logger:    logger,
+		stock:     stock,
+		sku:       sku,
+		lot:       lot,
+		bin:       bin,
+		warehouse: warehouse,
+		commodity: commodity,
+	}
+}
+
+func (uc *inventoryUsecase) Select(ctx context.Context, params domain.StockBalanceQueryParameter) ([]domain.StockBalanceResponse, error) {
+	var (
+		balanceResponses = []domain.StockBalanceResponse{}
+	)
+
+	balancesData, err := uc.stock.Select(params)
+	if err != nil {
+		return balanceResponses, err
+	}
+
+	for _, balance := range balancesData {
+		balanceResponses = append(balanceResponses, balance.StockBalanceResponse())
+	}
+
+	return balanceResponses, nil
+}
+
+func (uc *inventoryUsecase) Adjust(ctx context.Context, data domain.StockAdjustParameter) (domain.StockBalanceResponse, error) {
+	var (
+		balanceResponse domain.StockBalanceResponse
+	)
+
+	if data.Quantity == 0 {
+		return balanceResponse, domain.ErrInvalidQuantity
+	}
+
+	lotData, err := uc.lot.Get(data.LotID)
+	if err != nil {
+		return balanceResponse, err
+	}
+	if lotData.SKUID != data.SKUID {
+		return balanceResponse, domain.ErrLotNotFound
+	}
+
+	binData, err := uc.bin.Get(ctx, data.BinID)
+	if err != nil {
+		return balanceResponse, err
+	}
+
+	// Balances are always kept in base units
+	skuData, err := uc.sku.Get(ctx, data.SKUID)
+	if err != nil {
+		return balanceResponse, err
+	}
+
+	data.Quantity, err = skuData.ToBaseQuantity(data.PackLevel, data.Quantity)
+	if err != nil {
+		return balanceResponse, err
+	}
+	data.PackLevel = domain.PackLevelEach
+
+	if data.Quantity > 0 {
+		if err := uc.checkPutaway(ctx, binData, skuData, data.Quantity); err != nil {
+			return balanceResponse, err
+		}
+	}
+
+	balanceData, err := uc.stock.Adjust(data)
+	if err != nil {
+		return balanceResponse, err
+	}
+
+	balanceResponse = balanceData.StockBalanceResponse()
+	return balanceResponse, nil
+}
+
+// Allocate picks first expired first out, lots that are already expired are
+// never picked and lots without expiry go last.
+func (uc *inventoryUsecase) Allocate(ctx context.Context, data domain.StockAllocateParameter) (domain.StockAllocationResponse, error) {
+	var (
+		allocationResponse = domain.StockAllocationResponse{
+			SKUID:     data.SKUID,
+			Requested: data.Quantity,
+			Picks:     []domain.StockPickResponse{},
+		}
+		picks  []domain.StockPick
+		params = domain.StockBalanceQueryParameter{
+			SKUID:        []int64{data.SKUID},
+			InStock:      true,
+			ExpiresAfter: today(),
+		}
+	)
+
+	if data.WarehouseID > 0 {
+		params.WarehouseID = []int64{data.WarehouseID}
+	}
+
+	skuData, err := uc.sku.Get(ctx, data.SKUID)
+	if err != nil {
+		return allocationResponse, err
+	}
+
+	data.Quantity, err = skuData.ToBaseQuantity(data.PackLevel, data.Quantity)
+	if err != nil {
+		return allocationResponse, err
+	}
+	allocationResponse.BaseUoM = skuData.BaseUoM
+	allocationResponse.Requested = data.Quantity
+
+	balancesData, err := domain.SelectAllBalances(uc.stock, params)
+	if err != nil {
+		return allocationResponse, err
+	}
+
+	for _, balance := range balancesData {
+		remaining := data.Quantity - allocationResponse.Allocated
+		if remaining < 1 {
+			break
+		}
+
+		quantity := balance.Quantity
+		if quantity > remaining {
+			quantity = remaining
+		}
+
+		picks = append(picks, domain.StockPick{
+			BalanceID: balance.ID,
+			Quantity:  quantity,
+		})
+		allocationResponse.Picks = append(allocationResponse.Picks, domain.StockPickResponse{
+			BalanceID: balance.ID,
+			LotID:     balance.LotID,
+			LotNumber: balance.LotNumber,
+			ExpiresAt: balance.StockBalanceResponse().ExpiresAt,
+			BinID:     balance.BinID,
+			BinCode:   balance.BinCode,
+			Quantity:  quantity,
+		})
+		allocationResponse.Allocated += quantity
+	}
+
+	if !data.Commit {
+		return allocationResponse, nil
+	}
+
+	if allocationResponse.Allocated < data.Quantity {
+		return allocationResponse, domain.ErrInsufficientStock
+	}
+
+	if err := uc.stock.Allocate(picks); err != nil {
+		return allocationResponse, err
+	}
+
+	allocationResponse.Committed = true
+	return allocationResponse, nil
+}
+
+func (uc *inventoryUsecase) Expiring(ctx context.Context, params domain.ExpiringQueryParameter) ([]domain.ExpiringStockResponse, error) {
+	var (
+		expiringResponses = []domain.ExpiringStockResponse{}
+		byWarehouse       = make(map[int64]int)
+		now               = today()
+	)
+
+	balancesData, err := domain.SelectAllBalances(uc.stock, domain.StockBalanceQueryParameter{
+		WarehouseID:   params.WarehouseID,
+		InStock:       true,
+		ExpiresBefore: now.Add(params.Within),
+	})
+	if err != nil {
+		return expiringResponses, err
+	}
+
+	for _, balance := range balancesData {
+		index, ok := byWarehouse[balance.WarehouseID]
+		if !ok {
+			warehouseData, err := uc.warehouse.Get(ctx, balance.WarehouseID)
+			if err != nil {
+				return expiringResponses, err
+			}
+
+			index = len(expiringResponses)
+			byWarehouse[balance.WarehouseID] = index
+			expiringResponses = append(expiringResponses, domain.ExpiringStockResponse{
+				WarehouseID:   warehouseData.ID,
+				WarehouseName: warehouseData.Name,
+				Items:         []domain.ExpiringItemResponse{},
+			})
+		}
+
+		expiringResponses[index].Items = append(expiringResponses[index].Items, domain.ExpiringItemResponse{
+			StockBalanceResponse: balance.StockBalanceResponse(),
+			DaysToExpiry:         int64(balance.ExpiresAt.Sub(now).Hours() / 24),
+			Expired:              balance.ExpiresAt.Before(now),
+		})
+	}
+
+	return expiringResponses, nil
+}
+
+// checkPutaway refuses stock that would overflow the volume or weight of a
+// bin, or that breaks the handling rules of its commodity. SKUs without
+// measurements are let through the capacity check.
+func (uc *inventoryUsecase) checkPutaway(ctx context.Context, binData domain.Bin, skuData domain.SKU, quantity int64) error {
+	balancesData, err := domain.SelectAllBalances(uc.stock, domain.StockBalanceQueryParameter{
+		BinID:   []int64{binData.ID},
+		InStock: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	skusData := map[int64]domain.SKU{skuData.ID: skuData}
+	for _, balance := range balancesData {
+		if _, found := skusData[balance.SKUID]; found {
+			continue
+		}
+
+		balanceSKU, err := uc.sku.Get(ctx, balance.SKUID)
+		if err != nil {
+			return err
+		}
+		skusData[balance.SKUID] = balanceSKU
+	}
+
+	if err := uc.checkHandling(ctx, binData, skuData, skusData); err != nil {
+		return err
+	}
+
+	volumeM3, weightKg, ok := skuData.UnitMeasure()
+	if !ok {
+		return nil
+	}
+
+	occupancy := domain.ComputeOccupancy(binData, balancesData, skusData)
+	if !occupancy.Fits(volumeM3*float64(quantity), weightKg*float64(quantity)) {
+		return domain.ErrBinCapacityExceeded
+	}
+
+	return nil
+}
+
+// checkHandling applies the commodity rules against the other SKUs in the bin
+func (uc *inventoryUsecase) checkHandling(ctx context.Context, binData domain.Bin, skuData domain.SKU, skusData map[int64]domain.SKU) error {
+	var neighbourIDs []int64
+	for skuID, binSKU := range skusData {
+		if skuID != skuData.ID {
+			neighbourIDs = append(neighbourIDs, binSKU.CommodityID)
+		}
+	}
+
+	return domain.CheckBinHandling(ctx, uc.commodity, skuData.CommodityID, binData, neighbourIDs)
+}
+
+// today is midnight UTC, lot dates are stored without a time of day
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,180 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger *logrus.Logger
+	label  domain.LabelUsecase
+	bin    domain.BinUsecase
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, label domain.LabelUsecase, bin domain.BinUsecase) {
+	httpInstance := &httpDelivery{
+		logger: logger,
+		label:  label,
+		bin:    bin,
+	}
+
+	// Bind with given router
+	router.HandleFunc("/sku/{id}/label", httpInstance.SKULabel).Methods("GET")
+	router.HandleFunc("/bin/{id}/label", httpInstance.BinLabel).Methods("GET")
+	router.HandleFunc("/label/sheet", httpInstance.Sheet).Methods("GET")
+}
+
+func (h *httpDelivery) SKULabel(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID      int64
+		queryParam domain.LabelParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := domain.AuthorizeAny(r.Context(), domain.PermSKURead); err != nil {
+		h.responseLabelError(w, err, "Not Allowed")
+		return
+	}
+
+	file, err := h.label.SKULabel(r.Context(), skuID, queryParam)
+	if err != nil {
+		h.responseLabelError(w, err, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	responseLabel(w, file)
+}
+
+func (h *httpDelivery) BinLabel(w http.ResponseWriter, r *http.Request) {
+	var (
+		binID      int64
+		queryParam domain.LabelParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		binID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.authorizeBins(r, domain.PermBinRead, binID); err != nil {
+		h.responseLabelError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	file, err := h.label.BinLabel(r.Context(), binID, queryParam)
+	if err != nil {
+		h.responseLabelError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	responseLabel(w, file)
+}
+
+func (h *httpDelivery) Sheet(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.LabelSheetParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if len(queryParam.SKUID) > 0 {
+		if err := domain.AuthorizeAny(r.Context(), domain.PermSKURead); err != nil {
+			h.responseLabelError(w, err, "Not Allowed")
+			return
+		}
+	}
+
+	if err := h.authorizeBins(r, domain.PermBinRead, queryParam.BinID...); err != nil {
+		h.responseLabelError(w, err, "Cannot find every SKU and Bin of the Sheet")
+		return
+	}
+
+	file, err := h.label.Sheet(r.Context(), queryParam)
+	if err != nil {
+		h.responseLabelError(w, err, "Cannot find every SKU and Bin of the Sheet")
+		return
+	}
+
+	responseLabel(w, file)
+}
+
+// authorizeBins checks perm in the warehouse of every bin
+func (h *httpDelivery) authorizeBins(r *http.Request, perm domain.Permission, binIDs ...int64) error {
+	for _, binID := range binIDs {
+		binData, err := h.bin.Get(r.Context(), binID)
+		if err != nil {
+			return err
+		}
+
+		if err := domain.Authorize(r.Context(), perm, binData.WarehouseID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *httpDelivery) responseLabelError(w http.ResponseWriter, err error, notFoundMessage string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrLabelTemplateNotFound):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unknown Label Template")
+	case errors.Is(err, domain.ErrLabelUnencodable):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Label Data Cannot Be Encoded With This Symbology")
+	case errors.Is(err, domain.ErrUnknownPackLevel):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "SKU has no such Pack Level")
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, notFoundMessage)
+	}
+}
+
+func responseLabel(w http.ResponseWriter, file domain.LabelFile) {
+	w.Header().Set("Content-Disposition", `inline; filename="`+file.FileName+`"`)
+	httpcommon.ResponseBytes(w, http.StatusOK, file.ContentType, file.Data)
+}
@@ -0,0 +1,174 @@
+package usecase
+
+import (
+	"strings"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/barcodegen"
+)
+
+const (
+	labelPaddingMM = 2
+	labelSpacingMM = 1
+	// Rough advance of an average glyph relative to the font size, used to
+	// truncate text since not every output format can measure it
+	glyphWidthRatio = 0.6
+)
+
+// Every renderer draws the same layout, all measures are in millimetres from
+// the top left corner of the label.
+type labelLayout struct {
+	width  float64
+	height float64
+	symbol *barcodegen.Symbol
+	// Top left corner of the first module and the size of a single module
+	symbolX    float64
+	symbolY    float64
+	moduleMM   float64
+	barHeight  float64
+	texts      []labelText
+	symbology  string
+	symbolData string
+}
+
+type labelText struct {
+	x    float64
+	y    float64
+	size float64
+	text string
+}
+
+type labelRect struct {
+	x, y, w, h float64
+}
+
+func buildLayout(tpl domain.LabelTemplate, symbol *barcodegen.Symbol, content domain.LabelContent) labelLayout {
+	var (
+		l = labelLayout{
+			width:      tpl.WidthMM,
+			height:     tpl.HeightMM,
+			symbol:     symbol,
+			symbology:  symbol.Symbology,
+			symbolData: content.Data,
+		}
+		textSize = clampFloat(tpl.HeightMM*0.11, 2, 5)
+		top      = float64(labelPaddingMM)
+		bottom   = tpl.HeightMM - labelPaddingMM
+		maxChars = int((tpl.WidthMM - 2*labelPaddingMM) / (textSize * glyphWidthRatio))
+	)
+
+	if tpl.ShowName && len(content.Name) > 0 {
+		l.texts = append(l.texts, labelText{x: labelPaddingMM, y: top, size: textSize, text: truncate(content.Name, maxChars)})
+		top += textSize + labelSpacingMM
+	}
+
+	if tpl.ShowLocation && len(content.Location) > 0 {
+		bottom -= textSize * 0.85
+		l.texts = append(l.texts, labelText{x: labelPaddingMM, y: bottom, size: textSize * 0.85, text: truncate(content.Location, maxChars)})
+		bottom -= labelSpacingMM
+	}
+
+	if tpl.ShowCode {
+		bottom -= textSize
+		l.texts = append(l.texts, labelText{x: labelPaddingMM, y: bottom, size: textSize, text: truncate(symbol.Text, maxChars)})
+		bottom -= labelSpacingMM
+	}
+
+	// The symbol takes whatever space is left, quiet zones included
+	areaWidth := tpl.WidthMM - 2*labelPaddingMM
+	areaHeight := bottom - top
+	if areaHeight < 1 {
+		areaHeight = 1
+	}
+
+	modulesWide := float64(symbol.Width + 2*symbol.QuietZone)
+	if symbol.Linear() {
+		l.moduleMM = areaWidth / modulesWide
+		l.barHeight = areaHeight
+		l.symbolX = labelPaddingMM + float64(symbol.QuietZone)*l.moduleMM
+		l.symbolY = top
+		return l
+	}
+
+	l.moduleMM = minFloat(areaWidth, areaHeight) / modulesWide
+	l.barHeight = float64(symbol.Height) * l.moduleMM
+	l.symbolX = labelPaddingMM + (areaWidth-float64(symbol.Width)*l.moduleMM)/2
+	l.symbolY = top + (areaHeight-l.barHeight)/2
+	return l
+}
+
+// rects returns every dark area of the symbol, neighbouring modules merged
+func (l labelLayout) rects() []labelRect {
+	var rects []labelRect
+
+	if l.symbol.Linear() {
+		for _, bar := range l.symbol.Bars() {
+			rects = append(rects, labelRect{
+				x: l.symbolX + float64(bar[0])*l.moduleMM,
+				y: l.symbolY,
+				w: float64(bar[1]) * l.moduleMM,
+				h: l.barHeight,
+			})
+		}
+		return rects
+	}
+
+	for y := 0; y < l.symbol.Height; y++ {
+		for x := 0; x < l.symbol.Width; {
+			if !l.symbol.Dark(x, y) {
+				x++
+				continue
+			}
+
+			start := x
+			for x < l.symbol.Width && l.symbol.Dark(x, y) {
+				x++
+			}
+			rects = append(rects, labelRect{
+				x: l.symbolX + float64(start)*l.moduleMM,
+				y: l.symbolY + float64(y)*l.moduleMM,
+				w: float64(x-start) * l.moduleMM,
+				h: l.moduleMM,
+			})
+		}
+	}
+
+	return rects
+}
+
+// printable keeps text to the ASCII range every output font can draw
+func printable(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 32 || r > 126 {
+			r = '?'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func truncate(s string, max int) string {
+	s = printable(s)
+	if max < 4 || len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
+
+func clampFloat(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,251 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/barcodegen"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/imaging"
+)
+
+const (
+	mmPerInch = 25.4
+	// PDF user space is measured in points
+	pointsPerMM = 72 / mmPerInch
+	// Cell height of the bitmap font used for PNG labels
+	bitmapFontHeight = 17
+)
+
+func renderPNG(l labelLayout, dpi int) ([]byte, error) {
+	scale := float64(dpi) / mmPerInch
+	px := func(mm float64) int {
+		return int(math.Round(mm * scale))
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, px(l.width), px(l.height)))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, r := range l.rects() {
+		rect := image.Rect(px(r.x), px(r.y), px(r.x+r.w), px(r.y+r.h))
+		draw.Draw(canvas, rect, image.Black, image.Point{}, draw.Src)
+	}
+
+	for _, t := range l.texts {
+		textScale := int(math.Round(t.size * scale / bitmapFontHeight))
+		imaging.DrawLabel(canvas, image.Pt(px(t.x), px(t.y)), t.text, color.Black, color.White, textScale)
+	}
+
+	return imaging.EncodeToBytes(canvas, imaging.FormatPNG)
+}
+
+func renderSVG(l labelLayout) []byte {
+	var b bytes.Buffer
+
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s">`+"\n",
+		num(l.width), num(l.height), num(l.width), num(l.height))
+	fmt.Fprintf(&b, `<rect x="0" y="0" width="%s" height="%s" fill="#fff"/>`+"\n", num(l.width), num(l.height))
+
+	b.WriteString(`<g fill="#000" shape-rendering="crispEdges">` + "\n")
+	for _, r := range l.rects() {
+		fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s"/>`+"\n", num(r.x), num(r.y), num(r.w), num(r.h))
+	}
+	b.WriteString("</g>\n")
+
+	for _, t := range l.texts {
+		// SVG places text on its baseline, layouts use the top of the line
+		fmt.Fprintf(&b, `<text x="%s" y="%s" font-family="Helvetica, Arial, sans-serif" font-size="%s">%s</text>`+"\n",
+			num(t.x), num(t.y+t.size*0.8), num(t.size), xmlEscape(t.text))
+	}
+
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// renderPDF puts every label on a page of its own size
+func renderPDF(layouts []labelLayout) []byte {
+	var pages []pdfPage
+
+	for _, l := range layouts {
+		pages = append(pages, pdfPage{
+			width:   l.width,
+			height:  l.height,
+			content: pdfLabelContent(l, 0, 0, l.height),
+		})
+	}
+
+	return writePDF(pages)
+}
+
+// renderPDFSheet packs labels in a grid on full pages for office printers
+func renderPDFSheet(layouts []labelLayout, sheet domain.LabelSheetConfig) []byte {
+	var (
+		pages   []pdfPage
+		current bytes.Buffer
+		width   = layouts[0].width
+		height  = layouts[0].height
+		columns = int((sheet.PageWidthMM - 2*sheet.MarginMM + sheet.GapMM) / (width + sheet.GapMM))
+		rows    = int((sheet.PageHeightMM - 2*sheet.MarginMM + sheet.GapMM) / (height + sheet.GapMM))
+	)
+
+	if columns < 1 {
+		columns = 1
+	}
+	if rows < 1 {
+		rows = 1
+	}
+
+	for i, l := range layouts {
+		slot := i % (columns * rows)
+		if slot == 0 && i > 0 {
+			pages = append(pages, pdfPage{width: sheet.PageWidthMM, height: sheet.PageHeightMM, content: current.String()})
+			current.Reset()
+		}
+
+		x := sheet.MarginMM + float64(slot%columns)*(width+sheet.GapMM)
+		y := sheet.MarginMM + float64(slot/columns)*(height+sheet.GapMM)
+		current.WriteString(pdfLabelContent(l, x, y, sheet.PageHeightMM))
+	}
+	pages = append(pages, pdfPage{width: sheet.PageWidthMM, height: sheet.PageHeightMM, content: current.String()})
+
+	return writePDF(pages)
+}
+
+func pdfLabelContent(l labelLayout, offsetX, offsetY, pageHeight float64) string {
+	var b strings.Builder
+
+	// PDF origin is the bottom left corner
+	pt := func(mm float64) string {
+		return num(mm * pointsPerMM)
+	}
+	flip := func(y float64) float64 {
+		return pageHeight - offsetY - y
+	}
+
+	b.WriteString("0 g\n")
+	for _, r := range l.rects() {
+		fmt.Fprintf(&b, "%s %s %s %s re f\n", pt(offsetX+r.x), pt(flip(r.y+r.h)), pt(r.w), pt(r.h))
+	}
+
+	for _, t := range l.texts {
+		fmt.Fprintf(&b, "BT /F1 %s Tf %s %s Td (%s) Tj ET\n", pt(t.size), pt(offsetX+t.x), pt(flip(t.y+t.size*0.8)), pdfEscape(t.text))
+	}
+
+	return b.String()
+}
+
+// renderZPL uses the printer's own barcode commands, so bars are generated by
+// the printer at its native resolution.
+func renderZPL(layouts []labelLayout, dpi int) []byte {
+	var b bytes.Buffer
+
+	dots := func(mm float64) int {
+		return int(math.Round(mm * float64(dpi) / mmPerInch))
+	}
+
+	for _, l := range layouts {
+		module := dots(l.moduleMM)
+		if module < 1 {
+			module = 1
+		}
+
+		b.WriteString("^XA\n")
+		fmt.Fprintf(&b, "^PW%d\n^LL%d\n", dots(l.width), dots(l.height))
+
+		for _, t := range l.texts {
+			fmt.Fprintf(&b, "^FO%d,%d^A0N,%d,%d^FD%s^FS\n", dots(t.x), dots(t.y), dots(t.size), dots(t.size), zplEscape(t.text))
+		}
+
+		fmt.Fprintf(&b, "^FO%d,%d^BY%d\n", dots(l.symbolX), dots(l.symbolY), module)
+		switch l.symbology {
+		case barcodegen.SymbologyCode128:
+			fmt.Fprintf(&b, "^BCN,%d,N,N,N,A^FD%s^FS\n", dots(l.barHeight), zplEscape(l.symbolData))
+		case barcodegen.SymbologyEAN13:
+			// The printer computes the check digit itself
+			fmt.Fprintf(&b, "^BEN,%d,N,N^FD%s^FS\n", dots(l.barHeight), l.symbol.Text[:12])
+		case barcodegen.SymbologyQR:
+			magnification := module
+			if magnification > 10 {
+				magnification = 10
+			}
+			fmt.Fprintf(&b, "^BQN,2,%d^FDMA,%s^FS\n", magnification, zplEscape(l.symbolData))
+		}
+
+		b.WriteString("^XZ\n")
+	}
+
+	return b.Bytes()
+}
+
+type pdfPage struct {
+	width   float64
+	height  float64
+	content string
+}
+
+// writePDF writes a minimal PDF 1.4 document using the built in Helvetica
+func writePDF(pages []pdfPage) []byte {
+	var (
+		b       bytes.Buffer
+		offsets []int
+	)
+
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	b.WriteString("%PDF-1.4\n")
+
+	// Objects 1 to 3 are fixed, each page then takes a page and a content object
+	kids := make([]string, 0, len(pages))
+	for i := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+i*2))
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			num(page.width*pointsPerMM), num(page.height*pointsPerMM), 5+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(page.content), page.content))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return b.Bytes()
+}
+
+func num(f float64) string {
+	s := strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.3f", f), "0"), ".")
+	if s == "" || s == "-" || s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;").Replace(s)
+}
+
+func pdfEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s)
+}
+
+// zplEscape drops the command prefixes, ZPL has no way to quote them in ^FD
+func zplEscape(s string) string {
+	return strings.NewReplacer("^", "", "~", "").Replace(printable(s))
+}
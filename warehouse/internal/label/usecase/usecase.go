@@ -0,0 +1,259 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/barcodegen"
+	"github.com/sirupsen/logrus"
+)
+
+type labelUsecase struct {
+	logger     *logrus.Logger
+	config     domain.LabelUsecaseConfig
+	sku        domain.SKURepository
+	skuBarcode domain.SKUBarcodeRepository
+	bin        domain.BinRepository
+	warehouse  domain.WarehouseRepository
+}
+
+var (
+	// Used when the configuration does not define any template
+	defaultTemplate = domain.LabelTemplate{
+		Name:         "default",
+		Symbology:    barcodegen.SymbologyCode128,
+		WidthMM:      50,
+		HeightMM:     25,
+		DPI:          203,
+		ShowName:     true,
+		ShowCode:     true,
+		ShowLocation: true,
+	}
+
+	defaultSheet = domain.LabelSheetConfig{
+		PageWidthMM:  210,
+		PageHeightMM: 297,
+		MarginMM:     10,
+		GapMM:        2,
+	}
+)
+
+func NewUsecase(logger *logrus.Logger, cfg domain.LabelUsecaseConfig, sku domain.SKURepository, skuBarcode domain.SKUBarcodeRepository, bin domain.BinRepository, warehouse domain.WarehouseRepository) domain.LabelUsecase {
+	if cfg.Sheet.PageWidthMM <= 0 || cfg.Sheet.PageHeightMM <= 0 {
+		cfg.Sheet = defaultSheet
+	}
+
+	return &labelUsecase{
+		logger:     logger,
+		config:     cfg,
+		sku:        sku,
+		skuBarcode: skuBarcode,
+		bin:        bin,
+		warehouse:  warehouse,
+	}
+}
+
+func (uc *labelUsecase) SKULabel(ctx context.Context, skuID int64, params domain.LabelParameter) (domain.LabelFile, error) {
+	content, err := uc.skuContent(ctx, skuID, params.PackLevel)
+	if err != nil {
+		return domain.LabelFile{}, err
+	}
+
+	fileName := fmt.Sprintf("sku-%d", skuID)
+	if len(params.PackLevel) > 0 {
+		fileName += "-" + params.PackLevel
+	}
+
+	return uc.render([]domain.LabelContent{content}, params, fileName, false)
+}
+
+func (uc *labelUsecase) BinLabel(ctx context.Context, binID int64, params domain.LabelParameter) (domain.LabelFile, error) {
+	content, err := uc.binContent(ctx, binID)
+	if err != nil {
+		return domain.LabelFile{}, err
+	}
+
+	return uc.render([]domain.LabelContent{content}, params, fmt.Sprintf("bin-%d", binID), false)
+}
+
+func (uc *labelUsecase) Sheet(ctx context.Context, params domain.LabelSheetParameter) (domain.LabelFile, error) {
+	var (
+		contents []domain.LabelContent
+	)
+
+	for _, skuID := range params.SKUID {
+		content, err := uc.skuContent(ctx, skuID, params.PackLevel)
+		if err != nil {
+			return domain.LabelFile{}, err
+		}
+		contents = append(contents, content)
+	}
+
+	for _, binID := range params.BinID {
+		content, err := uc.binContent(ctx, binID)
+		if err != nil {
+			return domain.LabelFile{}, err
+		}
+		contents = append(contents, content)
+	}
+
+	return uc.render(contents, params.LabelParameter, "labels", true)
+}
+
+// skuContent builds the label of a SKU, or of one of its packs when a pack
+// level is given. Packs print their own barcode when one is registered.
+func (uc *labelUsecase) skuContent(ctx context.Context, skuID int64, packLevel string) (domain.LabelContent, error) {
+	skuData, err := uc.sku.Get(ctx, skuID)
+	if err != nil {
+		return domain.LabelContent{}, err
+	}
+
+	var location []string
+	if len(skuData.WHCode) > 0 {
+		location = append(location, skuData.WHCode)
+	}
+	if len(skuData.BinCode) > 0 {
+		location = append(location, skuData.BinCode)
+	}
+	if len(skuData.ZoneID) > 0 {
+		location = append(location, "Zone "+skuData.ZoneID)
+	}
+
+	content := domain.LabelContent{
+		Data:     skuData.SKU,
+		Name:     skuData.Name,
+		Location: strings.Join(location, " / "),
+	}
+
+	if len(packLevel) < 1 || packLevel == domain.PackLevelEach {
+		return content, nil
+	}
+
+	pack, ok := skuData.Pack(packLevel)
+	if !ok {
+		return content, domain.ErrUnknownPackLevel
+	}
+	content.Name = fmt.Sprintf("%s - %s of %d %s", skuData.Name, strings.ToUpper(pack.Level[:1])+pack.Level[1:], pack.Quantity, skuData.BaseUoM)
+
+	barcodesFound, err := uc.skuBarcode.Select(domain.SKUBarcodeQueryParameter{
+		SKUID:     []int64{skuID},
+		PackLevel: []string{packLevel},
+		PaginationQuery: domain.PaginationQuery{
+			Limit: 1,
+			Page:  1,
+		},
+	})
+	if err != nil {
+		return content, err
+	}
+	if len(barcodesFound) > 0 {
+		content.Data = barcodesFound[0].Barcode
+	}
+
+	return content, nil
+}
+
+func (uc *labelUsecase) binContent(ctx context.Context, binID int64) (domain.LabelContent, error) {
+	binData, err := uc.bin.Get(ctx, binID)
+	if err != nil {
+		return domain.LabelContent{}, err
+	}
+
+	warehouseData, err := uc.warehouse.Get(ctx, binData.WarehouseID)
+	if err != nil {
+		return domain.LabelContent{}, err
+	}
+
+	return domain.LabelContent{
+		Data:     binData.Name,
+		Name:     "Bin " + binData.Name,
+		Location: warehouseData.Name,
+	}, nil
+}
+
+func (uc *labelUsecase) template(params domain.LabelParameter) (domain.LabelTemplate, error) {
+	var (
+		tpl  = defaultTemplate
+		name = params.Template
+	)
+
+	if len(name) < 1 {
+		name = uc.config.DefaultTemplate
+	}
+
+	if len(name) > 0 {
+		found := false
+		for _, t := range uc.config.Templates {
+			if strings.EqualFold(t.Name, name) {
+				tpl, found = t, true
+				break
+			}
+		}
+
+		if !found {
+			return tpl, domain.ErrLabelTemplateNotFound
+		}
+	}
+
+	if len(params.Symbology) > 0 {
+		tpl.Symbology = params.Symbology
+	}
+	if tpl.DPI < 1 {
+		tpl.DPI = defaultTemplate.DPI
+	}
+	if tpl.WidthMM <= 0 || tpl.HeightMM <= 0 {
+		tpl.WidthMM, tpl.HeightMM = defaultTemplate.WidthMM, defaultTemplate.HeightMM
+	}
+
+	return tpl, nil
+}
+
+func (uc *labelUsecase) render(contents []domain.LabelContent, params domain.LabelParameter, fileName string, sheet bool) (domain.LabelFile, error) {
+	var (
+		file    domain.LabelFile
+		layouts []labelLayout
+	)
+
+	tpl, err := uc.template(params)
+	if err != nil {
+		return file, err
+	}
+
+	for _, content := range contents {
+		symbol, err := barcodegen.Encode(tpl.Symbology, content.Data)
+		if err != nil {
+			return file, fmt.Errorf("%w: %s", domain.ErrLabelUnencodable, err)
+		}
+
+		layouts = append(layouts, buildLayout(tpl, symbol, content))
+	}
+
+	switch params.Format {
+	case domain.LabelFormatPNG:
+		file.Data, err = renderPNG(layouts[0], tpl.DPI)
+		file.ContentType = "image/png"
+	case domain.LabelFormatSVG:
+		file.Data = renderSVG(layouts[0])
+		file.ContentType = "image/svg+xml"
+	case domain.LabelFormatPDF:
+		if sheet {
+			file.Data = renderPDFSheet(layouts, uc.config.Sheet)
+		} else {
+			file.Data = renderPDF(layouts)
+		}
+		file.ContentType = "application/pdf"
+	case domain.LabelFormatZPL:
+		file.Data = renderZPL(layouts, tpl.DPI)
+		file.ContentType = "application/zpl"
+	default:
+		return file, fmt.Errorf("unsupported label format %q", params.Format)
+	}
+	if err != nil {
+		return file, err
+	}
+
+	file.FileName = fileName + "." + params.Format
+	return file, nil
+}
@@ -0,0 +1,305 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	location  domain.LocationUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, location domain.LocationUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		location:  location,
+		validator: validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/location", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/location", httpInstance.Create).Methods("POST")
+	router.HandleFunc("/location/{id}", httpInstance.Get).Methods("GET")
+	router.HandleFunc("/location/{id}", httpInstance.Delete).Methods("DELETE")
+	router.HandleFunc("/location/{id}/descendants", httpInstance.Descendants).Methods("GET")
+	router.HandleFunc("/location/{id}/generate", httpInstance.Generate).Methods("POST")
+}
+
+func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	var (
+		locationID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		locationID = id
+	}
+
+	response, err := h.location.Get(r.Context(), locationID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Location, Make sure you find correct Location")
+		return
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermBinRead, response.WarehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.LocationQueryParameter
+	)
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list locations of the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermBinRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.LocationResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.location.Select(r.Context(), queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Location")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Descendants(w http.ResponseWriter, r *http.Request) {
+	var (
+		locationID int64
+		queryParam domain.LocationQueryParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		locationID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	if err := h.authorizeLocation(r, domain.PermBinRead, locationID); err != nil {
+		h.responseError(w, err, "Cannot find Location, Make sure you find correct Location")
+		return
+	}
+
+	responses, err := h.location.Descendants(r.Context(), locationID, queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Location, Make sure you find correct Location")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var (
+		createData domain.LocationDataParameter
+	)
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	// Nodes below the warehouse belong to the warehouse of their parent
+	if createData.ParentID > 0 {
+		err = h.authorizeLocation(r, domain.PermBinWrite, createData.ParentID)
+	} else {
+		err = domain.Authorize(r.Context(), domain.PermBinWrite, createData.WarehouseID)
+	}
+	if err != nil {
+		h.responseError(w, err, "Cannot find Parent Location, Make sure you find correct Location")
+		return
+	}
+
+	response, err := h.location.Create(r.Context(), createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating Location")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Generate(w http.ResponseWriter, r *http.Request) {
+	var (
+		rackID       int64
+		templateData domain.LocationTemplateParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		rackID = id
+	}
+
+	if err := h.authorizeLocation(r, domain.PermBinWrite, rackID); err != nil {
+		h.responseError(w, err, "Cannot find Location, Make sure you find correct Location")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &templateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&templateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	responses, err := h.location.Generate(r.Context(), rackID, templateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Generating Bins")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusCreated, responses)
+}
+
+func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	var (
+		locationID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		locationID = id
+	}
+
+	if err := h.authorizeLocation(r, domain.PermBinDelete, locationID); err != nil {
+		h.responseError(w, err, "Cannot find Location, Make sure you find correct Location")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.location.Delete(r.Context(), locationID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Location")
+	} else {
+		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
+	}
+}
+
+// authorizeLocation checks perm in the warehouse the location belongs to
+func (h *httpDelivery) authorizeLocation(r *http.Request, perm domain.Permission, locationID int64) error {
+	locationData, err := h.location.Get(r.Context(), locationID)
+	if err != nil {
+		return err
+	}
+
+	return domain.Authorize(r.Context(), perm, locationData.WarehouseID)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	var (
+		conflict *domain.ConflictError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrInvalidLocationParent), errors.Is(err, domain.ErrLocationNotRack):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrLocationExists), errors.Is(err, domain.ErrLocationHasChildren):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &conflict):
+		httpcommon.ResponseJSONErrorDetail(w, http.StatusConflict, conflict.Error(), conflict.ConflictResponse())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
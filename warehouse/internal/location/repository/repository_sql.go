@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqlerr"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (lr *locationRepository) Get(ctx context.Context, locationID int64) (domain.Location, error) {
+	query, args, err := squirrel.Select(
+		"id",
+		"warehouse_id",
+		"parent_id",
+		"type",
+		"code",
+		"name",
+		"full_code",
+		"path",
+		"depth",
+		"bin_id",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("locations").Where(
+		squirrel.Eq{"id": locationID},
+	).ToSql()
+
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	query = lr.sql.Rebind(query)
+	row := sqltx.From(ctx, lr.sql).QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.Location{}, err
+	}
+
+	return scanLocation(row)
+}
+
+func (lr *locationRepository) Select(ctx context.Context, params domain.LocationQueryParameter) ([]domain.Location, error) {
+	var (
+		locationsData []domain.Location
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"warehouse_id",
+		"parent_id",
+		"type",
+		"code",
+		"name",
+		"full_code",
+		"path",
+		"depth",
+		"bin_id",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("locations")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return locationsData, err
+	}
+
+	query = lr.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, lr.sql).Query(query, args...)
+	if err != nil {
+		return locationsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		locationData, err := scanLocation(rows)
+		if err != nil {
+			return locationsData, err
+		}
+
+		locationsData = append(locationsData, locationData)
+	}
+
+	return locationsData, nil
+}
+
+func (lr *locationRepository) Create(ctx context.Context, data domain.LocationDataParameter) (domain.Location, error) {
+	var (
+		locationData domain.Location
+		t            = time.Now()
+	)
+
+	query, args, err := squirrel.Insert("locations").Columns(
+		"warehouse_id",
+		"parent_id",
+		"type",
+		"code",
+		"name",
+		"full_code",
+		"path",
+		"depth",
+		"bin_id",
+		"created_at",
+		"updated_at",
+	).Values(
+		data.WarehouseID,
+		nullableID(data.ParentID),
+		data.Type,
+		data.Code,
+		data.Name,
+		data.FullCode,
+		data.ParentPath,
+		data.Depth,
+		nullableID(data.BinID),
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		lr.logger.Errorln(err)
+		return locationData, err
+	}
+
+	// The path ends with the id of the node, so it is only known after insert
+	err = sqltx.Run(ctx, lr.sql, func(ctx context.Context) error {
+		tx := sqltx.From(ctx, lr.sql)
+
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+
+		lastInserted, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		path := data.ParentPath
+		if len(path) < 1 {
+			path = "/"
+		}
+		path += strconv.FormatInt(lastInserted, 10) + "/"
+
+		query, args, err := squirrel.Update("locations").
+			Set("path", path).
+			Where(squirrel.Eq{"id": lastInserted}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+			return err
+		}
+
+		locationData, err = lr.Get(ctx, lastInserted)
+		return err
+	})
+	if err != nil {
+		// The full code is unique within its warehouse
+		if sqlerr.IsDuplicateKey(err) {
+			return locationData, domain.ErrLocationExists
+		}
+		lr.logger.Errorln(err)
+		return locationData, err
+	}
+
+	return locationData, nil
+}
+
+func (lr *locationRepository) Delete(ctx context.Context, locationID, version int64) error {
+	query, args, err := squirrel.Delete("locations").Where(squirrel.Eq{"id": locationID, "version": version}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	query = lr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, lr.sql).Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return lr.checkVersion(ctx, result, locationID)
+}
+
+func (lr *locationRepository) checkVersion(ctx context.Context, result sql.Result, locationID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := lr.Get(ctx, locationID)
+		return err
+	})
+}
+
+func scanLocation(row scanner) (domain.Location, error) {
+	var (
+		locationData domain.Location
+		parentID     sql.NullInt64
+		binID        sql.NullInt64
+	)
+
+	err := row.Scan(
+		&locationData.ID,
+		&locationData.WarehouseID,
+		&parentID,
+		&locationData.Type,
+		&locationData.Code,
+		&locationData.Name,
+		&locationData.FullCode,
+		&locationData.Path,
+		&locationData.Depth,
+		&binID,
+		&locationData.Version,
+		&locationData.CreatedAt,
+		&locationData.UpdatedAt,
+	)
+	if err != nil {
+		return locationData, err
+	}
+
+	if parentID.Valid {
+		locationData.ParentID = &parentID.Int64
+	}
+	if binID.Valid {
+		locationData.BinID = &binID.Int64
+	}
+
+	return locationData, nil
+}
+
+// Root nodes have no parent and only bin nodes have a bin
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// Enough for every child of a rack or level a template can generate
+	childPageSize = 100
+)
+
+type locationUsecase struct {
+	logger    *logrus.Logger
+	location  domain.LocationRepository
+	bin       domain.BinRepository
+	warehouse domain.WarehouseRepository
+	tx        domain.Transactor
+	audit     domain.AuditRepository
+	outbox    domain.OutboxRepository
+}
+
+func NewUsecase(logger *logrus.Logger, location domain.LocationRepository, bin domain.BinRepository, warehouse domain.WarehouseRepository, tx domain.Transactor, audit domain.AuditRepository, outbox domain.OutboxRepository) domain.LocationUsecase {
+	return &locationUsecase{
+		logger:    logger,
+		location:  location,
+		bin:       bin,
+		warehouse: warehouse,
+		tx:        tx,
+		audit:     audit,
+		outbox:    outbox,
+	}
+}
+
+func (uc *locationUsecase) Get(ctx context.Context, locationID int64) (domain.LocationResponse, error) {
+	var (
+		locationResponse domain.LocationResponse
+	)
+
+	locationData, err := uc.location.Get(ctx, locationID)
+	if err != nil {
+		return locationResponse, err
+	}
+
+	locationResponse = locationData.LocationResponse()
+	return locationResponse, nil
+}
+
+func (uc *locationUsecase) Select(ctx context.Context, params domain.LocationQueryParameter) ([]domain.LocationResponse, error) {
+	var (
+		locationResponses = []domain.LocationResponse{}
+	)
+
+	locationsData, err := uc.location.Select(ctx, params)
+	if err != nil {
+		return locationResponses, err
+	}
+
+	for _, location := range locationsData {
+		locationResponses = append(locationResponses, location.LocationResponse())
+	}
+
+	return locationResponses, nil
+}
+
+func (uc *locationUsecase) Descendants(ctx context.Context, locationID int64, params domain.LocationQueryParameter) ([]domain.LocationResponse, error) {
+	var (
+		locationResponses = []domain.LocationResponse{}
+	)
+
+	locationData, err := uc.location.Get(ctx, locationID)
+	if err != nil {
+		return locationResponses, err
+	}
+
+	params.PathPrefix = locationData.Path
+	return uc.Select(ctx, params)
+}
+
+func (uc *locationUsecase) Create(ctx context.Context, data domain.LocationDataParameter) (domain.LocationResponse, error) {
+	var (
+		locationResponse domain.LocationResponse
+	)
+
+	locationData, err := uc.create(ctx, data)
+	if err != nil {
+		return locationResponse, err
+	}
+
+	locationResponse = locationData.LocationResponse()
+	return locationResponse, nil
+}
+
+// Generate lays out the levels of a rack and the bins on every level, levels
+// and bins that already exist are kept so a template can be applied again.
+// The whole layout is created in one transaction, a failure leaves none of it.
+func (uc *locationUsecase) Generate(ctx context.Context, rackID int64, data domain.LocationTemplateParameter) ([]domain.LocationResponse, error) {
+	var (
+		locationResponses = []domain.LocationResponse{}
+	)
+
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		rackData, err := uc.location.Get(ctx, rackID)
+		if err != nil {
+			return err
+		}
+		if rackData.Type != domain.LocationTypeRack {
+			return domain.ErrLocationNotRack
+		}
+
+		levels, err := uc.children(ctx, rackData.ID)
+		if err != nil {
+			return err
+		}
+
+		for l := int64(1); l <= data.Levels; l++ {
+			levelCode := data.LevelCode(l)
+
+			levelData, ok := levels[levelCode]
+			if !ok {
+				levelData, err = uc.create(ctx, domain.LocationDataParameter{
+					ParentID: rackData.ID,
+					Type:     domain.LocationTypeLevel,
+					Code:     levelCode,
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			bins, err := uc.children(ctx, levelData.ID)
+			if err != nil {
+				return err
+			}
+
+			for p := int64(1); p <= data.Positions; p++ {
+				positionCode := data.PositionCode(p)
+				if _, ok := bins[positionCode]; ok {
+					continue
+				}
+
+				binData, err := uc.create(ctx, domain.LocationDataParameter{
+					ParentID: levelData.ID,
+					Type:     domain.LocationTypeBin,
+					Code:     positionCode,
+					Bin:      data.Bin,
+				})
+				if err != nil {
+					return err
+				}
+
+				locationResponses = append(locationResponses, binData.LocationResponse())
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return []domain.LocationResponse{}, err
+	}
+
+	return locationResponses, nil
+}
+
+func (uc *locationUsecase) Delete(ctx context.Context, locationID, version int64) (domain.GenericResponse, error) {
+	locationData, err := uc.location.Get(ctx, locationID)
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	children, err := uc.location.Select(ctx, domain.LocationQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: 1},
+		ParentID:        []int64{locationID},
+	})
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+	if len(children) > 0 {
+		return domain.GenericResponse{}, domain.ErrLocationHasChildren
+	}
+
+	// The node and its bin go together
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.location.Delete(ctx, locationID, version); err != nil {
+			return err
+		}
+
+		if locationData.BinID != nil {
+			return uc.deleteBin(ctx, *locationData.BinID)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	return domain.GenericResponse{
+		Success: true,
+	}, nil
+}
+
+// create places a node under its parent, bin nodes get a bin named after
+// their full code.
+func (uc *locationUsecase) create(ctx context.Context, data domain.LocationDataParameter) (domain.Location, error) {
+	var (
+		parentData domain.Location
+	)
+
+	if data.Type == domain.LocationTypeWarehouse {
+		if data.ParentID > 0 {
+			return domain.Location{}, domain.ErrInvalidLocationParent
+		}
+
+		// Check if warehouse exists
+		if _, err := uc.warehouse.Get(ctx, data.WarehouseID); err != nil {
+			return domain.Location{}, err
+		}
+
+		data.FullCode = data.Code
+		data.ParentPath = ""
+		data.Depth = 0
+	} else {
+		if data.ParentID < 1 {
+			return domain.Location{}, domain.ErrInvalidLocationParent
+		}
+
+		var err error
+		parentData, err = uc.location.Get(ctx, data.ParentID)
+		if err != nil {
+			return domain.Location{}, err
+		}
+		if !domain.LocationTypeBelow(parentData.Type, data.Type) {
+			return domain.Location{}, domain.ErrInvalidLocationParent
+		}
+
+		data.WarehouseID = parentData.WarehouseID
+		data.FullCode = parentData.ChildCode(data.Code)
+		data.ParentPath = parentData.Path
+		data.Depth = parentData.Depth + 1
+	}
+
+	// A warehouse has a single root and full codes are unique in it, the
+	// unique index settles concurrent requests
+	existingQuery := domain.LocationQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: 1},
+		WarehouseID:     []int64{data.WarehouseID},
+		Type:            []string{domain.LocationTypeWarehouse},
+	}
+	if data.Type != domain.LocationTypeWarehouse {
+		existingQuery.Type = nil
+		existingQuery.FullCode = []string{data.FullCode}
+	}
+
+	existing, err := uc.location.Select(ctx, existingQuery)
+	if err != nil {
+		return domain.Location{}, err
+	}
+	if len(existing) > 0 {
+		return domain.Location{}, domain.ErrLocationExists
+	}
+
+	if data.Type != domain.LocationTypeBin {
+		return uc.location.Create(ctx, data)
+	}
+
+	zoneID, err := uc.zoneCode(ctx, parentData)
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	binParameter := data.Bin.BinDataParameter(data.WarehouseID, data.FullCode, zoneID)
+	if len(binParameter.Type) < 1 {
+		binParameter.Type = domain.BinTypeShelf
+	}
+
+	// Do not leave a bin without its node behind
+	var locationData domain.Location
+	err = uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		binData, err := uc.createBin(ctx, binParameter)
+		if err != nil {
+			return err
+		}
+		data.BinID = binData.ID
+
+		locationData, err = uc.location.Create(ctx, data)
+		return err
+	})
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	return locationData, nil
+}
+
+// createBin creates the bin backing a bin node, audited like bins created
+// directly
+func (uc *locationUsecase) createBin(ctx context.Context, data domain.BinDataParameter) (domain.Bin, error) {
+	var (
+		binData domain.Bin
+	)
+
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		binData, err = uc.bin.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityBin, binData.ID, binData.WarehouseID, domain.AuditActionCreate, nil, binData.BinResponse())); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBinCreated, binData.ID, binData.WarehouseID, binData.BinResponse()))
+	})
+	if err != nil {
+		return domain.Bin{}, err
+	}
+
+	return binData, nil
+}
+
+func (uc *locationUsecase) deleteBin(ctx context.Context, binID int64) error {
+	return uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.bin.Get(ctx, binID)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.bin.Delete(ctx, binID, before.Version); err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityBin, binID, before.WarehouseID, domain.AuditActionDelete, before.BinResponse(), nil)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventBinDeleted, binID, before.WarehouseID, before.BinResponse()))
+	})
+}
+
+// zoneCode is the code of the zone a node sits in, empty outside of zones
+func (uc *locationUsecase) zoneCode(ctx context.Context, locationData domain.Location) (string, error) {
+	if locationData.Type == domain.LocationTypeZone {
+		return locationData.Code, nil
+	}
+
+	ancestorIDs := locationData.AncestorIDs()
+	if len(ancestorIDs) < 1 {
+		return "", nil
+	}
+
+	zones, err := uc.location.Select(ctx, domain.LocationQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: 1},
+		ID:              ancestorIDs,
+		Type:            []string{domain.LocationTypeZone},
+	})
+	if err != nil || len(zones) < 1 {
+		return "", err
+	}
+
+	return zones[0].Code, nil
+}
+
+// children returns the children of a node by their code
+func (uc *locationUsecase) children(ctx context.Context, locationID int64) (map[string]domain.Location, error) {
+	var (
+		childrenData = make(map[string]domain.Location)
+	)
+
+	locationsData, err := uc.location.Select(ctx, domain.LocationQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: childPageSize},
+		ParentID:        []int64{locationID},
+	})
+	if err != nil {
+		return childrenData, err
+	}
+
+	for _, locationData := range locationsData {
+		childrenData[locationData.Code] = locationData
+	}
+
+	return childrenData, nil
+}
@@ -0,0 +1,269 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	lot       domain.LotUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, lot domain.LotUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		lot:       lot,
+		validator: validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/sku/{id}/lots", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/sku/{id}/lots", httpInstance.Create).Methods("POST")
+	router.HandleFunc("/lot/{id}", httpInstance.Get).Methods("GET")
+	router.HandleFunc("/lot/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/lot/{id}", httpInstance.Delete).Methods("DELETE")
+}
+
+func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	var (
+		lotID int64
+	)
+
+	if err := domain.AuthorizeAny(r.Context(), domain.PermSKURead); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		lotID = id
+	}
+
+	response, err := h.lot.Get(r.Context(), lotID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Lot, Make sure you find correct Lot")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID      int64
+		queryParam domain.LotQueryParameter
+	)
+
+	if err := domain.AuthorizeAny(r.Context(), domain.PermSKURead); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	responses, err := h.lot.Select(r.Context(), skuID, queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID      int64
+		createData domain.LotDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.lot.Create(r.Context(), skuID, createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating Lot")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
+	var (
+		lotID      int64
+		updateData domain.LotDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		lotID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &updateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&updateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.lot.Update(r.Context(), lotID, version, updateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating Lot")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	var (
+		lotID int64
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		lotID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.lot.Delete(r.Context(), lotID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Lot")
+	} else {
+		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
+	}
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrInvalidLotDate), errors.Is(err, domain.ErrLotExpiryBefore):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
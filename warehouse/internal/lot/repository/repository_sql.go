@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (lr *lotRepository) Get(lotID int64) (domain.Lot, error) {
+	query, args, err := squirrel.Select(
+		"id",
+		"sku_id",
+		"lot_number",
+		"manufactured_at",
+		"expires_at",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("lots").Where(
+		squirrel.Eq{"id": lotID},
+	).ToSql()
+
+	if err != nil {
+		return domain.Lot{}, err
+	}
+
+	query = lr.sql.Rebind(query)
+	row := lr.sql.QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.Lot{}, err
+	}
+
+	return scanLot(row)
+}
+
+func (lr *lotRepository) Select(params domain.LotQueryParameter) ([]domain.Lot, error) {
+	var (
+		lotsData []domain.Lot
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"sku_id",
+		"lot_number",
+		"manufactured_at",
+		"expires_at",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("lots")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return lotsData, err
+	}
+
+	query = lr.sql.Rebind(query)
+	rows, err := lr.sql.Query(query, args...)
+	if err != nil {
+		return lotsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		lotData, err := scanLot(rows)
+		if err != nil {
+			return lotsData, err
+		}
+
+		lotsData = append(lotsData, lotData)
+	}
+
+	return lotsData, nil
+}
+
+func (lr *lotRepository) Create(skuID int64, data domain.LotDataParameter) (domain.Lot, error) {
+	var (
+		lotData domain.Lot
+		t       = time.Now()
+	)
+
+	manufacturedAt, expiresAt, err := data.Dates()
+	if err != nil {
+		return lotData, err
+	}
+
+	query, args, err := squirrel.Insert("lots").Columns(
+		"sku_id",
+		"lot_number",
+		"manufactured_at",
+		"expires_at",
+		"created_at",
+		"updated_at",
+	).Values(
+		skuID,
+		data.LotNumber,
+		manufacturedAt,
+		expiresAt,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		lr.logger.Errorln(err)
+		return lotData, err
+	}
+
+	query = lr.sql.Rebind(query)
+	result, err := lr.sql.Exec(query, args...)
+	if err != nil {
+		lr.logger.Errorln(err)
+		return lotData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		lr.logger.Errorln(err)
+		return lotData, err
+	}
+
+	lotData, err = lr.Get(lastInserted)
+	if err != nil {
+		lr.logger.Errorln(err)
+		return lotData, err
+	}
+
+	return lotData, nil
+}
+
+func (lr *lotRepository) Update(lotID, version int64, data domain.LotDataParameter) (domain.Lot, error) {
+	var (
+		lotData domain.Lot
+	)
+
+	manufacturedAt, expiresAt, err := data.Dates()
+	if err != nil {
+		return lotData, err
+	}
+
+	query, args, err := squirrel.Update("lots").
+		Set("lot_number", data.LotNumber).
+		Set("manufactured_at", manufacturedAt).
+		Set("expires_at", expiresAt).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": lotID, "version": version}).
+		ToSql()
+	if err != nil {
+		return lotData, err
+	}
+
+	query = lr.sql.Rebind(query)
+	result, err := lr.sql.Exec(query, args...)
+	if err != nil {
+		return lotData, err
+	}
+
+	if err := lr.checkVersion(result, lotID); err != nil {
+		return lotData, err
+	}
+
+	lotData, err = lr.Get(lotID)
+	if err != nil {
+		return lotData, err
+	}
+
+	return lotData, nil
+}
+
+func (lr *lotRepository) Delete(lotID, version int64) error {
+	query, args, err := squirrel.Delete("lots").Where(squirrel.Eq{"id": lotID, "version": version}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	query = lr.sql.Rebind(query)
+	result, err := lr.sql.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return lr.checkVersion(result, lotID)
+}
+
+func (lr *lotRepository) checkVersion(result sql.Result, lotID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := lr.Get(lotID)
+		return err
+	})
+}
+
+func scanLot(row scanner) (domain.Lot, error) {
+	var (
+		lotData        domain.Lot
+		manufacturedAt sql.NullTime
+		expiresAt      sql.NullTime
+	)
+
+	err := row.Scan(
+		&lotData.ID,
+		&lotData.SKUID,
+		&lotData.LotNumber,
+		&manufacturedAt,
+		&expiresAt,
+		&lotData.Version,
+		&lotData.CreatedAt,
+		&lotData.UpdatedAt,
+	)
+	if err != nil {
+		return lotData, err
+	}
+
+	if manufacturedAt.Valid {
+		lotData.ManufacturedAt = &manufacturedAt.Time
+	}
+	if expiresAt.Valid {
+		lotData.ExpiresAt = &expiresAt.Time
+	}
+
+	return lotData, nil
+}
@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type lotUsecase struct {
+	logger *logrus.Logger
+	sku    domain.SKURepository
+	lot    domain.LotRepository
+}
+
+func NewUsecase(logger *logrus.Logger, sku domain.SKURepository, lot domain.LotRepository) domain.LotUsecase {
+	return &lotUsecase{
+		logger: logger,
+		sku:    sku,
+		lot:    lot,
+	}
+}
+
+func (uc *lotUsecase) Get(ctx context.Context, lotID int64) (domain.LotResponse, error) {
+	var (
+		lotResponse domain.LotResponse
+	)
+
+	lotData, err := uc.lot.Get(lotID)
+	if err != nil {
+		return lotResponse, err
+	}
+
+	lotResponse = lotData.LotResponse()
+	return lotResponse, nil
+}
+
+func (uc *lotUsecase) Select(ctx context.Context, skuID int64, params domain.LotQueryParameter) ([]domain.LotResponse, error) {
+	var (
+		lotResponses = []domain.LotResponse{}
+	)
+
+	if _, err := uc.sku.Get(ctx, skuID); err != nil {
+		return lotResponses, err
+	}
+
+	params.SKUID = []int64{skuID}
+	lotsData, err := uc.lot.Select(params)
+	if err != nil {
+		return lotResponses, err
+	}
+
+	for _, lot := range lotsData {
+		lotResponses = append(lotResponses, lot.LotResponse())
+	}
+
+	return lotResponses, nil
+}
+
+func (uc *lotUsecase) Create(ctx context.Context, skuID int64, data domain.LotDataParameter) (domain.LotResponse, error) {
+	var (
+		lotResponse domain.LotResponse
+	)
+
+	if _, _, err := data.Dates(); err != nil {
+		return lotResponse, err
+	}
+
+	if _, err := uc.sku.Get(ctx, skuID); err != nil {
+		return lotResponse, err
+	}
+
+	lotData, err := uc.lot.Create(skuID, data)
+	if err != nil {
+		return lotResponse, err
+	}
+
+	lotResponse = lotData.LotResponse()
+	return lotResponse, nil
+}
+
+func (uc *lotUsecase) Update(ctx context.Context, lotID, version int64, data domain.LotDataParameter) (domain.LotResponse, error) {
+	var (
+		lotResponse domain.LotResponse
+	)
+
+	if _, _, err := data.Dates(); err != nil {
+		return lotResponse, err
+	}
+
+	lotData, err := uc.lot.Update(lotID, version, data)
+	if err != nil {
+		return lotResponse, err
+	}
+
+	lotResponse = lotData.LotResponse()
+	return lotResponse, nil
+}
+
+func (uc *lotUsecase) Delete(ctx context.Context, lotID, version int64) (domain.GenericResponse, error) {
+	err := uc.lot.Delete(lotID, version)
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	return domain.GenericResponse{
+		Success: true,
+	}, nil
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+func (or *outboxRepository) Create(ctx context.Context, data domain.EventDataParameter) error {
+	payload, err := json.Marshal(data.Payload)
+	if err != nil {
+		return err
+	}
+
+	query, args, err := squirrel.Insert("outbox_events").Columns(
+		"event_type",
+		"entity_id",
+		"warehouse_id",
+		"payload",
+		"created_at",
+	).Values(
+		data.Type,
+		data.EntityID,
+		data.WarehouseID,
+		string(payload),
+		time.Now(),
+	).ToSql()
+
+	if err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	query = or.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, or.sql).Exec(query, args...); err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (or *outboxRepository) SelectPending(ctx context.Context, now time.Time, limit, maxAttempts int) ([]domain.Event, error) {
+	var (
+		eventsData []domain.Event
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"event_type",
+		"entity_id",
+		"warehouse_id",
+		"payload",
+		"attempts",
+		"last_error",
+		"created_at",
+	).From("outbox_events").
+		Where(squirrel.Eq{"published_at": nil}).
+		Where(squirrel.Or{
+			squirrel.Eq{"claimed_until": nil},
+			squirrel.LtOrEq{"claimed_until": now},
+		})
+
+	if maxAttempts > 0 {
+		selector = selector.Where(squirrel.Lt{"attempts": maxAttempts})
+	}
+
+	query, args, err := selector.
+		OrderBy("id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+
+	if err != nil {
+		return eventsData, err
+	}
+
+	query = or.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, or.sql).Query(query, args...)
+	if err != nil {
+		return eventsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			eventData domain.Event
+			payload   string
+			lastError sql.NullString
+		)
+
+		if err := rows.Scan(
+			&eventData.ID,
+			&eventData.Type,
+			&eventData.EntityID,
+			&eventData.WarehouseID,
+			&payload,
+			&eventData.Attempts,
+			&lastError,
+			&eventData.CreatedAt,
+		); err != nil {
+			return eventsData, err
+		}
+
+		eventData.Payload = json.RawMessage(payload)
+		eventData.LastError = lastError.String
+		eventsData = append(eventsData, eventData)
+	}
+
+	return eventsData, nil
+}
+
+func (or *outboxRepository) Claim(ctx context.Context, eventIDs []int64, until time.Time) error {
+	return or.setClaim(ctx, eventIDs, until)
+}
+
+func (or *outboxRepository) Release(ctx context.Context, eventIDs []int64) error {
+	return or.setClaim(ctx, eventIDs, nil)
+}
+
+func (or *outboxRepository) setClaim(ctx context.Context, eventIDs []int64, until interface{}) error {
+	query, args, err := squirrel.Update("outbox_events").
+		Set("claimed_until", until).
+		Where(squirrel.Eq{"id": eventIDs}).
+		ToSql()
+
+	if err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	query = or.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, or.sql).Exec(query, args...); err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (or *outboxRepository) MarkPublished(ctx context.Context, eventID int64, publishedAt time.Time) error {
+	query, args, err := squirrel.Update("outbox_events").
+		Set("published_at", publishedAt).
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", nil).
+		Set("claimed_until", nil).
+		Where(squirrel.Eq{"id": eventID}).
+		ToSql()
+
+	if err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	query = or.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, or.sql).Exec(query, args...); err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (or *outboxRepository) MarkFailed(ctx context.Context, eventID int64, reason string) error {
+	query, args, err := squirrel.Update("outbox_events").
+		Set("attempts", squirrel.Expr("attempts + 1")).
+		Set("last_error", reason).
+		Set("claimed_until", nil).
+		Where(squirrel.Eq{"id": eventID}).
+		ToSql()
+
+	if err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	query = or.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, or.sql).Exec(query, args...); err != nil {
+		or.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
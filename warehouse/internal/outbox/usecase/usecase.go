@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/bus"
+	"github.com/sirupsen/logrus"
+)
+
+type outboxUsecase struct {
+	logger *logrus.Logger
+	config domain.OutboxConfig
+	outbox domain.OutboxRepository
+	tx     domain.Transactor
+	local  bus.Publisher
+	sinks  []bus.Publisher
+}
+
+// NewUsecase publishes events to local, the in-process bus, inside the
+// transaction claiming them and to sinks, which reach over the network, after
+// it committed
+func NewUsecase(logger *logrus.Logger, config domain.OutboxConfig, outbox domain.OutboxRepository, tx domain.Transactor, local bus.Publisher, sinks ...bus.Publisher) domain.OutboxUsecase {
+	if config.Interval <= 0 {
+		config.Interval = 2 * time.Second
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.Lease <= 0 {
+		config.Lease = 5 * time.Minute
+	}
+
+	return &outboxUsecase{
+		logger: logger,
+		config: config,
+		outbox: outbox,
+		tx:     tx,
+		local:  local,
+		sinks:  sinks,
+	}
+}
+
+// Dispatch publishes pending events oldest first. The in-process bus takes
+// them in the short transaction claiming them, so what its handlers write
+// commits with the claim, and the network sinks after that transaction so a
+// slow sink holds no rows. An event is only marked published once every sink
+// took it and a failure releases the rest of the batch for the next run, so
+// consumers see events in order and at least once. In-process handlers may be
+// given an event again and must be idempotent.
+func (uc *outboxUsecase) Dispatch(ctx context.Context) (int, error) {
+	var (
+		published int
+		claimed   []domain.Event
+	)
+
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		events, err := uc.outbox.SelectPending(ctx, now, uc.config.BatchSize, uc.config.MaxAttempts)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			if err := uc.publish(ctx, event, uc.local); err != nil {
+				uc.logger.Warnf("outbox event %d (%s) attempt %d failed: %v", event.ID, event.Type, event.Attempts+1, err)
+				if err := uc.outbox.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+					return err
+				}
+				break
+			}
+			claimed = append(claimed, event)
+		}
+
+		if len(claimed) < 1 {
+			return nil
+		}
+		return uc.outbox.Claim(ctx, eventIDs(claimed), now.Add(uc.config.Lease))
+	})
+	if err != nil {
+		return published, err
+	}
+
+	for i, event := range claimed {
+		if err := uc.publish(ctx, event, uc.sinks...); err != nil {
+			uc.logger.Warnf("outbox event %d (%s) attempt %d failed: %v", event.ID, event.Type, event.Attempts+1, err)
+			if err := uc.outbox.MarkFailed(ctx, event.ID, err.Error()); err != nil {
+				return published, err
+			}
+			if rest := claimed[i+1:]; len(rest) > 0 {
+				return published, uc.outbox.Release(ctx, eventIDs(rest))
+			}
+			return published, nil
+		}
+
+		if err := uc.outbox.MarkPublished(ctx, event.ID, time.Now()); err != nil {
+			return published, err
+		}
+		published++
+	}
+
+	return published, nil
+}
+
+func (uc *outboxUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := uc.Dispatch(ctx)
+			if err != nil {
+				uc.logger.Errorln(err)
+				continue
+			}
+			if published > 0 {
+				uc.logger.Debugf("outbox published %d events", published)
+			}
+		}
+	}
+}
+
+func (uc *outboxUsecase) publish(ctx context.Context, event domain.Event, sinks ...bus.Publisher) error {
+	value, err := json.Marshal(event.EventResponse())
+	if err != nil {
+		return err
+	}
+
+	msg := bus.Message{
+		Topic: event.Type,
+		Key:   strconv.FormatInt(event.EntityID, 10),
+		Value: value,
+		Headers: map[string]string{
+			"X-Event-ID": strconv.FormatInt(event.ID, 10),
+		},
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func eventIDs(events []domain.Event) []int64 {
+	ids := make([]int64, 0, len(events))
+	for _, event := range events {
+		ids = append(ids, event.ID)
+	}
+	return ids
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/bus"
+	"github.com/sirupsen/logrus"
+)
+
+type fakeOutbox struct {
+	pending   []domain.Event
+	claimed   []int64
+	released  []int64
+	published []int64
+	failed    []int64
+}
+
+func (f *fakeOutbox) Create(ctx context.Context, data domain.EventDataParameter) error {
+	return nil
+}
+
+func (f *fakeOutbox) SelectPending(ctx context.Context, now time.Time, limit, maxAttempts int) ([]domain.Event, error) {
+	return f.pending, nil
+}
+
+func (f *fakeOutbox) Claim(ctx context.Context, eventIDs []int64, until time.Time) error {
+	f.claimed = append(f.claimed, eventIDs...)
+	return nil
+}
+
+func (f *fakeOutbox) Release(ctx context.Context, eventIDs []int64) error {
+	f.released = append(f.released, eventIDs...)
+	return nil
+}
+
+func (f *fakeOutbox) MarkPublished(ctx context.Context, eventID int64, publishedAt time.Time) error {
+	f.published = append(f.published, eventID)
+	return nil
+}
+
+func (f *fakeOutbox) MarkFailed(ctx context.Context, eventID int64, reason string) error {
+	f.failed = append(f.failed, eventID)
+	return nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// failingSink passes messages on to next until it sees the event failOn
+type failingSink struct {
+	failOn string
+	next   bus.Publisher
+}
+
+func (s failingSink) Publish(ctx context.Context, msg bus.Message) error {
+	if msg.Headers["X-Event-ID"] == s.failOn {
+		return errors.New("sink unavailable")
+	}
+	return s.next.Publish(ctx, msg)
+}
+
+func TestDispatch(t *testing.T) {
+	pending := []domain.Event{
+		{ID: 1, Type: "sku.created", EntityID: 10},
+		{ID: 2, Type: "sku.updated", EntityID: 10},
+		{ID: 3, Type: "bin.created", EntityID: 20},
+		{ID: 4, Type: "bin.updated", EntityID: 20},
+	}
+
+	tests := []struct {
+		name          string
+		localFailOn   string
+		sinkFailOn    string
+		wantPublished int
+		wantLocal     []string
+		wantSink      []string
+		wantClaimed   []int64
+		wantMarked    []int64
+		wantFailed    []int64
+		wantReleased  []int64
+	}{
+		{
+			name:          "every event in order",
+			wantPublished: 4,
+			wantLocal:     []string{"1", "2", "3", "4"},
+			wantSink:      []string{"1", "2", "3", "4"},
+			wantClaimed:   []int64{1, 2, 3, 4},
+			wantMarked:    []int64{1, 2, 3, 4},
+		},
+		{
+			name:          "local failure stops the batch",
+			localFailOn:   "2",
+			wantPublished: 1,
+			wantLocal:     []string{"1"},
+			wantSink:      []string{"1"},
+			wantClaimed:   []int64{1},
+			wantMarked:    []int64{1},
+			wantFailed:    []int64{2},
+		},
+		{
+			name:          "sink failure releases the rest",
+			sinkFailOn:    "3",
+			wantPublished: 2,
+			wantLocal:     []string{"1", "2", "3", "4"},
+			wantSink:      []string{"1", "2"},
+			wantClaimed:   []int64{1, 2, 3, 4},
+			wantMarked:    []int64{1, 2},
+			wantFailed:    []int64{3},
+			wantReleased:  []int64{4},
+		},
+		{
+			name:          "sink failure on the last event",
+			sinkFailOn:    "4",
+			wantPublished: 3,
+			wantLocal:     []string{"1", "2", "3", "4"},
+			wantSink:      []string{"1", "2", "3"},
+			wantClaimed:   []int64{1, 2, 3, 4},
+			wantMarked:    []int64{1, 2, 3},
+			wantFailed:    []int64{4},
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outbox := &fakeOutbox{pending: pending}
+			local, sink := bus.NewMemory(), bus.NewMemory()
+
+			uc := NewUsecase(logger, domain.OutboxConfig{}, outbox, fakeTx{}, failingSink{tt.localFailOn, local}, failingSink{tt.sinkFailOn, sink})
+			published, err := uc.Dispatch(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if published != tt.wantPublished {
+				t.Errorf("published = %d, want %d", published, tt.wantPublished)
+			}
+			if got := eventIDHeaders(local.Messages()); !reflect.DeepEqual(got, tt.wantLocal) {
+				t.Errorf("local bus got %v, want %v", got, tt.wantLocal)
+			}
+			if got := eventIDHeaders(sink.Messages()); !reflect.DeepEqual(got, tt.wantSink) {
+				t.Errorf("sink got %v, want %v", got, tt.wantSink)
+			}
+			if !reflect.DeepEqual(outbox.claimed, tt.wantClaimed) {
+				t.Errorf("claimed = %v, want %v", outbox.claimed, tt.wantClaimed)
+			}
+			if !reflect.DeepEqual(outbox.published, tt.wantMarked) {
+				t.Errorf("marked published = %v, want %v", outbox.published, tt.wantMarked)
+			}
+			if !reflect.DeepEqual(outbox.failed, tt.wantFailed) {
+				t.Errorf("marked failed = %v, want %v", outbox.failed, tt.wantFailed)
+			}
+			if !reflect.DeepEqual(outbox.released, tt.wantReleased) {
+				t.Errorf("released = %v, want %v", outbox.released, tt.wantReleased)
+			}
+		})
+	}
+}
+
+func eventIDHeaders(messages []bus.Message) []string {
+	var ids []string
+	for _, msg := range messages {
+		ids = append(ids, msg.Headers["X-Event-ID"])
+	}
+	return ids
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (sr *scanRepository) Get(ctx context.Context, scanID int64) (domain.BarcodeScan, error) {
+	var (
+		scanData domain.BarcodeScan
+	)
+
+	query, args, err := squirrel.Select(
+		"id",
+		"user_id",
+		"device_id",
+		"warehouse_id",
+		"image_hash",
+		"image_key",
+		"decoder",
+		"detections",
+		"results",
+		"error",
+		"created_at",
+	).From("barcode_scans").Where(
+		squirrel.Eq{"id": scanID},
+	).ToSql()
+
+	if err != nil {
+		return scanData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	row := sqltx.From(ctx, sr.sql).QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return scanData, err
+	}
+
+	return scanBarcodeScan(row)
+}
+
+func (sr *scanRepository) Select(ctx context.Context, params domain.BarcodeScanQueryParameter) ([]domain.BarcodeScan, error) {
+	var (
+		scansData []domain.BarcodeScan
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"user_id",
+		"device_id",
+		"warehouse_id",
+		"image_hash",
+		"image_key",
+		"decoder",
+		"detections",
+		"results",
+		"error",
+		"created_at",
+	).From("barcode_scans")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return scansData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, sr.sql).Query(query, args...)
+	if err != nil {
+		return scansData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		scanData, err := scanBarcodeScan(rows)
+		if err != nil {
+			return scansData, err
+		}
+
+		scansData = append(scansData, scanData)
+	}
+
+	return scansData, nil
+}
+
+func (sr *scanRepository) Create(ctx context.Context, data domain.BarcodeScanDataParameter) (domain.BarcodeScan, error) {
+	var (
+		scanData    domain.BarcodeScan
+		warehouseID sql.NullInt64
+		t           = time.Now()
+	)
+
+	detections, err := json.Marshal(data.Detections)
+	if err != nil {
+		return scanData, err
+	}
+
+	results, err := json.Marshal(data.Results)
+	if err != nil {
+		return scanData, err
+	}
+
+	if data.WarehouseID > 0 {
+		warehouseID = sql.NullInt64{Int64: data.WarehouseID, Valid: true}
+	}
+
+	query, args, err := squirrel.Insert("barcode_scans").Columns(
+		"user_id",
+		"device_id",
+		"warehouse_id",
+		"image_hash",
+		"image_key",
+		"decoder",
+		"detections",
+		"results",
+		"error",
+		"created_at",
+	).Values(
+		data.UserID,
+		data.DeviceID,
+		warehouseID,
+		data.ImageHash,
+		data.ImageKey,
+		data.Decoder,
+		detections,
+		results,
+		data.Error,
+		t,
+	).ToSql()
+
+	if err != nil {
+		sr.logger.Errorln(err)
+		return scanData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, sr.sql).Exec(query, args...)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return scanData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		sr.logger.Errorln(err)
+		return scanData, err
+	}
+
+	scanData, err = sr.Get(ctx, lastInserted)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return scanData, err
+	}
+
+	return scanData, nil
+}
+
+func scanBarcodeScan(row scanner) (domain.BarcodeScan, error) {
+	var (
+		scanData    domain.BarcodeScan
+		warehouseID sql.NullInt64
+		detections  []byte
+		results     []byte
+	)
+
+	err := row.Scan(
+		&scanData.ID,
+		&scanData.UserID,
+		&scanData.DeviceID,
+		&warehouseID,
+		&scanData.ImageHash,
+		&scanData.ImageKey,
+		&scanData.Decoder,
+		&detections,
+		&results,
+		&scanData.Error,
+		&scanData.CreatedAt,
+	)
+	if err != nil {
+		return scanData, err
+	}
+
+	scanData.WarehouseID = warehouseID.Int64
+	if err := json.Unmarshal(detections, &scanData.Detections); err != nil {
+		return scanData, err
+	}
+	if err := json.Unmarshal(results, &scanData.Results); err != nil {
+		return scanData, err
+	}
+
+	return scanData, nil
+}
@@ -0,0 +1,274 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	serial    domain.SerialNumberUsecase
+	bin       domain.BinUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, serial domain.SerialNumberUsecase, bin domain.BinUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		serial:    serial,
+		bin:       bin,
+		validator: validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/sku/{id}/serials", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/sku/{id}/serials", httpInstance.Create).Methods("POST")
+	router.HandleFunc("/serial/{id}", httpInstance.Get).Methods("GET")
+	router.HandleFunc("/serial/{id}/move", httpInstance.Move).Methods("POST")
+}
+
+func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	var (
+		serialID int64
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		serialID = id
+	}
+
+	response, err := h.serial.Get(r.Context(), serialID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Serial Number, Make sure you find correct Serial Number")
+		return
+	}
+
+	if err := h.authorizeBin(r, domain.PermSerialRead, response.BinID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID      int64
+		queryParam domain.SerialNumberQueryParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	// Only list units in the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermSerialRead, queryParam.WarehouseID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.SerialNumberResponse{})
+		return
+	}
+	queryParam.WarehouseID = warehouseIDs
+
+	responses, err := h.serial.Select(r.Context(), skuID, queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID      int64
+		createData domain.SerialNumberDataParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	if err := h.authorizeBin(r, domain.PermSerialWrite, &createData.BinID); err != nil {
+		h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+		return
+	}
+
+	response, err := h.serial.Create(r.Context(), skuID, createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating Serial Number")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Move(w http.ResponseWriter, r *http.Request) {
+	var (
+		serialID int64
+		moveData domain.SerialNumberMoveParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		serialID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &moveData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&moveData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	// The unit leaves one bin and may land in a bin of another warehouse
+	current, err := h.serial.Get(r.Context(), serialID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Serial Number, Make sure you find correct Serial Number")
+		return
+	}
+
+	if err := h.authorizeBin(r, domain.PermSerialWrite, current.BinID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	if moveData.BinID > 0 {
+		if err := h.authorizeBin(r, domain.PermSerialWrite, &moveData.BinID); err != nil {
+			h.responseError(w, err, "Cannot find Bin, Make sure you find correct Bin")
+			return
+		}
+	}
+
+	response, err := h.serial.Move(r.Context(), serialID, version, moveData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Moving Serial Number")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+// authorizeBin checks perm in the warehouse the bin is stored in, units
+// without a bin need perm in every warehouse
+func (h *httpDelivery) authorizeBin(r *http.Request, perm domain.Permission, binID *int64) error {
+	if binID == nil {
+		return domain.Authorize(r.Context(), perm, 0)
+	}
+
+	binData, err := h.bin.Get(r.Context(), *binID)
+	if err != nil {
+		return err
+	}
+
+	return domain.Authorize(r.Context(), perm, binData.WarehouseID)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrSKUNotSerialized):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "SKU is not serialized")
+	case errors.Is(err, domain.ErrInvalidSerialTransition):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, "Serial Number cannot move to this status")
+	case errors.Is(err, domain.ErrSerialBinRequired):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Bin is required for this status")
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/jmoiron/sqlx"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (sr *serialRepository) Get(serialID int64) (domain.SerialNumber, error) {
+	query, args, err := squirrel.Select(
+		"id",
+		"sku_id",
+		"serial",
+		"status",
+		"bin_id",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("serial_numbers").Where(
+		squirrel.Eq{"id": serialID},
+	).ToSql()
+
+	if err != nil {
+		return domain.SerialNumber{}, err
+	}
+
+	query = sr.sql.Rebind(query)
+	row := sr.sql.QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.SerialNumber{}, err
+	}
+
+	return scanSerialNumber(row)
+}
+
+func (sr *serialRepository) Select(params domain.SerialNumberQueryParameter) ([]domain.SerialNumber, error) {
+	var (
+		serialsData []domain.SerialNumber
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"sku_id",
+		"serial",
+		"status",
+		"bin_id",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("serial_numbers")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return serialsData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	rows, err := sr.sql.Query(query, args...)
+	if err != nil {
+		return serialsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		serialData, err := scanSerialNumber(rows)
+		if err != nil {
+			return serialsData, err
+		}
+
+		serialsData = append(serialsData, serialData)
+	}
+
+	return serialsData, nil
+}
+
+// Create registers the unit in stock and opens its history with that event
+func (sr *serialRepository) Create(skuID int64, data domain.SerialNumberDataParameter) (domain.SerialNumber, error) {
+	var (
+		serialData domain.SerialNumber
+		t          = time.Now()
+	)
+
+	tx, err := sr.sql.Beginx()
+	if err != nil {
+		return serialData, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := squirrel.Insert("serial_numbers").Columns(
+		"sku_id",
+		"serial",
+		"status",
+		"bin_id",
+		"created_at",
+		"updated_at",
+	).Values(
+		skuID,
+		data.Serial,
+		domain.SerialStatusInStock,
+		data.BinID,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	result, err := tx.Exec(tx.Rebind(query), args...)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	if err := insertEvent(tx, lastInserted, domain.SerialStatusInStock, data.BinID, data.Note, t); err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return serialData, err
+	}
+
+	serialData, err = sr.Get(lastInserted)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	return serialData, nil
+}
+
+// Move changes the status of the unit and appends the change to its history
+func (sr *serialRepository) Move(serialID, version int64, data domain.SerialNumberMoveParameter) (domain.SerialNumber, error) {
+	var (
+		serialData domain.SerialNumber
+		t          = time.Now()
+	)
+
+	tx, err := sr.sql.Beginx()
+	if err != nil {
+		return serialData, err
+	}
+	defer tx.Rollback()
+
+	query, args, err := squirrel.Update("serial_numbers").
+		Set("status", data.Status).
+		Set("bin_id", nullableID(data.BinID)).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", t).
+		Where(squirrel.Eq{"id": serialID, "version": version}).
+		ToSql()
+	if err != nil {
+		return serialData, err
+	}
+
+	result, err := tx.Exec(tx.Rebind(query), args...)
+	if err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	if err := sr.checkVersion(result, serialID); err != nil {
+		return serialData, err
+	}
+
+	if err := insertEvent(tx, serialID, data.Status, data.BinID, data.Note, t); err != nil {
+		sr.logger.Errorln(err)
+		return serialData, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return serialData, err
+	}
+
+	serialData, err = sr.Get(serialID)
+	if err != nil {
+		return serialData, err
+	}
+
+	return serialData, nil
+}
+
+func (sr *serialRepository) checkVersion(result sql.Result, serialID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := sr.Get(serialID)
+		return err
+	})
+}
+
+func (sr *serialRepository) History(serialID int64) ([]domain.SerialNumberEvent, error) {
+	var (
+		eventsData []domain.SerialNumberEvent
+	)
+
+	query, args, err := squirrel.Select(
+		"id",
+		"serial_number_id",
+		"status",
+		"bin_id",
+		"note",
+		"created_at",
+	).From("serial_number_events").Where(
+		squirrel.Eq{"serial_number_id": serialID},
+	).OrderBy("created_at ASC", "id ASC").ToSql()
+
+	if err != nil {
+		return eventsData, err
+	}
+
+	query = sr.sql.Rebind(query)
+	rows, err := sr.sql.Query(query, args...)
+	if err != nil {
+		return eventsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			eventData domain.SerialNumberEvent
+			binID     sql.NullInt64
+		)
+
+		if err := rows.Scan(
+			&eventData.ID,
+			&eventData.SerialNumberID,
+			&eventData.Status,
+			&binID,
+			&eventData.Note,
+			&eventData.CreatedAt,
+		); err != nil {
+			return eventsData, err
+		}
+
+		if binID.Valid {
+			eventData.BinID = &binID.Int64
+		}
+
+		eventsData = append(eventsData, eventData)
+	}
+
+	return eventsData, nil
+}
+
+func insertEvent(tx *sqlx.Tx, serialID int64, status string, binID int64, note string, t time.Time) error {
+	query, args, err := squirrel.Insert("serial_number_events").Columns(
+		"serial_number_id",
+		"status",
+		"bin_id",
+		"note",
+		"created_at",
+	).Values(
+		serialID,
+		status,
+		nullableID(binID),
+		note,
+		t,
+	).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(tx.Rebind(query), args...)
+	return err
+}
+
+func scanSerialNumber(row scanner) (domain.SerialNumber, error) {
+	var (
+		serialData domain.SerialNumber
+		binID      sql.NullInt64
+	)
+
+	err := row.Scan(
+		&serialData.ID,
+		&serialData.SKUID,
+		&serialData.Serial,
+		&serialData.Status,
+		&binID,
+		&serialData.Version,
+		&serialData.CreatedAt,
+		&serialData.UpdatedAt,
+	)
+	if err != nil {
+		return serialData, err
+	}
+
+	if binID.Valid {
+		serialData.BinID = &binID.Int64
+	}
+
+	return serialData, nil
+}
+
+// Units that left the shelf have no bin
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
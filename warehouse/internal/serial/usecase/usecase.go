@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type serialUsecase struct {
+	logger *logrus.Logger
+	sku    domain.SKURepository
+	bin    domain.BinRepository
+	serial domain.SerialNumberRepository
+}
+
+func NewUsecase(logger *logrus.Logger, sku domain.SKURepository, bin domain.BinRepository, serial domain.SerialNumberRepository) domain.SerialNumberUsecase {
+	return &serialUsecase{
+		logger: logger,
+		sku:    sku,
+		bin:    bin,
+		serial: serial,
+	}
+}
+
+// Get returns the unit with its full history
+func (uc *serialUsecase) Get(ctx context.Context, serialID int64) (domain.SerialNumberResponse, error) {
+	var (
+		serialResponse domain.SerialNumberResponse
+	)
+
+	serialData, err := uc.serial.Get(serialID)
+	if err != nil {
+		return serialResponse, err
+	}
+
+	eventsData, err := uc.serial.History(serialID)
+	if err != nil {
+		return serialResponse, err
+	}
+
+	serialResponse = serialData.SerialNumberResponse()
+	for _, event := range eventsData {
+		serialResponse.History = append(serialResponse.History, event.SerialNumberEventResponse())
+	}
+
+	return serialResponse, nil
+}
+
+func (uc *serialUsecase) Select(ctx context.Context, skuID int64, params domain.SerialNumberQueryParameter) ([]domain.SerialNumberResponse, error) {
+	var (
+		serialResponses = []domain.SerialNumberResponse{}
+	)
+
+	if _, err := uc.sku.Get(ctx, skuID); err != nil {
+		return serialResponses, err
+	}
+
+	params.SKUID = []int64{skuID}
+	serialsData, err := uc.serial.Select(params)
+	if err != nil {
+		return serialResponses, err
+	}
+
+	for _, serial := range serialsData {
+		serialResponses = append(serialResponses, serial.SerialNumberResponse())
+	}
+
+	return serialResponses, nil
+}
+
+func (uc *serialUsecase) Create(ctx context.Context, skuID int64, data domain.SerialNumberDataParameter) (domain.SerialNumberResponse, error) {
+	var (
+		serialResponse domain.SerialNumberResponse
+	)
+
+	skuData, err := uc.sku.Get(ctx, skuID)
+	if err != nil {
+		return serialResponse, err
+	}
+	if !skuData.Serialized {
+		return serialResponse, domain.ErrSKUNotSerialized
+	}
+
+	if _, err := uc.bin.Get(ctx, data.BinID); err != nil {
+		return serialResponse, err
+	}
+
+	serialData, err := uc.serial.Create(skuID, data)
+	if err != nil {
+		return serialResponse, err
+	}
+
+	return uc.Get(ctx, serialData.ID)
+}
+
+func (uc *serialUsecase) Move(ctx context.Context, serialID, version int64, data domain.SerialNumberMoveParameter) (domain.SerialNumberResponse, error) {
+	var (
+		serialResponse domain.SerialNumberResponse
+	)
+
+	serialData, err := uc.serial.Get(serialID)
+	if err != nil {
+		return serialResponse, err
+	}
+
+	if err := data.Validate(serialData.Status); err != nil {
+		return serialResponse, err
+	}
+
+	// Picked and shipped units are no longer in a bin
+	if data.Status == domain.SerialStatusPicked || data.Status == domain.SerialStatusShipped {
+		data.BinID = 0
+	} else if _, err := uc.bin.Get(ctx, data.BinID); err != nil {
+		return serialResponse, err
+	}
+
+	if _, err := uc.serial.Move(serialID, version, data); err != nil {
+		return serialResponse, err
+	}
+
+	return uc.Get(ctx, serialID)
+}
@@ -2,12 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/mergepatch"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -16,21 +18,25 @@ import (
 type httpDelivery struct {
 	logger    *logrus.Logger
 	sku       domain.SKUUsecase
+	warehouse domain.WarehouseUsecase
 	validator *validator.Validate
 }
 
-func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, sku domain.SKUUsecase) {
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, sku domain.SKUUsecase, warehouse domain.WarehouseUsecase) {
 	httpInstance := &httpDelivery{
 		logger:    logger,
 		sku:       sku,
+		warehouse: warehouse,
 		validator: validator.New(),
 	}
 
-	// Bind with given router
+	// Bind with given router. A SKU belongs to the warehouse its wh_code names,
+	// one naming no warehouse needs a grant not scoped to a warehouse
 	router.HandleFunc("/sku", httpInstance.Select).Methods("GET")
 	router.HandleFunc("/sku", httpInstance.Create).Methods("POST")
 	router.HandleFunc("/sku/{id}", httpInstance.Get).Methods("GET")
 	router.HandleFunc("/sku/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/sku/{id}", httpInstance.Patch).Methods("PATCH")
 	router.HandleFunc("/sku/{id}", httpInstance.Delete).Methods("DELETE")
 }
 
@@ -52,12 +58,18 @@ func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
 		skuID = id
 	}
 
-	response, err := h.sku.Get(skuID)
+	response, err := h.sku.Get(r.Context(), skuID)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find SKU, Make sure you find correct SKU")
 		return
 	}
 
+	if err := h.authorizeWarehouse(r, domain.PermSKURead, response.WHCode); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusOK, response)
 }
 
@@ -72,7 +84,19 @@ func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responses, err := h.sku.Select(queryParam)
+	// Only list SKUs of the warehouses the caller may see
+	whCodes, ok, err := domain.ScopeWarehouseNames(r.Context(), domain.PermSKURead, queryParam.WHCode, h.warehouse.Names)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.SKUResponse{})
+		return
+	}
+	queryParam.WHCode = whCodes
+
+	responses, err := h.sku.Select(r.Context(), queryParam)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query SKU")
 		return
@@ -102,12 +126,18 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.sku.Create(createData)
+	if err := h.authorizeWarehouse(r, domain.PermSKUWrite, createData.WHCode); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.sku.Create(r.Context(), createData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Creating SKU")
+		h.responseError(w, err, "An Error Occured When Creating SKU")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -130,6 +160,17 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		skuID = id
 	}
 
+	if err := h.authorizeSKU(r, domain.PermSKUWrite, skuID); err != nil {
+		h.responseError(w, err, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -146,12 +187,95 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.sku.Update(skuID, updateData)
+	// Moving a SKU needs the permission in the target warehouse too
+	if err := h.authorizeWarehouse(r, domain.PermSKUWrite, updateData.WHCode); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.sku.Update(r.Context(), skuID, version, updateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating SKU")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+// Patch applies a JSON merge patch to the sku, only the fields it names
+// are validated and only the ones it changes are written
+func (h *httpDelivery) Patch(w http.ResponseWriter, r *http.Request) {
+	var (
+		skuID     int64
+		patchData domain.SKUDataParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		skuID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if !mergepatch.Accepts(r.Header.Get("Content-Type")) {
+		httpcommon.ResponseJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergepatch.ContentType)
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	current, err := h.sku.Get(r.Context(), skuID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	if err := h.authorizeWarehouse(r, domain.PermSKUWrite, current.WHCode); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	result, err := mergepatch.Merge(current.SKUDataParameter(), bodyData, &patchData)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Apply Merge Patch")
+		return
+	}
+
+	if err := mergepatch.Validate(h.validator, &patchData, result.Supplied); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	// Moving a SKU needs the permission in the target warehouse too
+	if err := h.authorizeWarehouse(r, domain.PermSKUWrite, patchData.WHCode); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.sku.Patch(r.Context(), skuID, version, patchData, result.Changed)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Updating SKU")
+		h.responseError(w, err, "An Error Occured When Updating SKU")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -173,9 +297,80 @@ func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
 		skuID = id
 	}
 
-	if resp, err := h.sku.Delete(skuID); err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Delete SKU")
+	if err := h.authorizeSKU(r, domain.PermSKUDelete, skuID); err != nil {
+		h.responseError(w, err, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.sku.Delete(r.Context(), skuID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete SKU")
 	} else {
 		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
 	}
 }
+
+// authorizeSKU checks perm in the warehouse the sku belongs to
+func (h *httpDelivery) authorizeSKU(r *http.Request, perm domain.Permission, skuID int64) error {
+	skuData, err := h.sku.Get(r.Context(), skuID)
+	if err != nil {
+		return err
+	}
+
+	return h.authorizeWarehouse(r, perm, skuData.WHCode)
+}
+
+// authorizeWarehouse checks perm in the warehouse named whCode, a code naming
+// no warehouse needs a grant not scoped to one
+func (h *httpDelivery) authorizeWarehouse(r *http.Request, perm domain.Permission, whCode string) error {
+	var (
+		warehouseID int64
+	)
+
+	warehouses, err := h.warehouse.Select(r.Context(), domain.WarehouseQueryParameter{Name: []string{whCode}})
+	if err != nil {
+		return err
+	}
+	if len(warehouses) > 0 {
+		warehouseID = warehouses[0].ID
+	}
+
+	return domain.Authorize(r.Context(), perm, warehouseID)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	var (
+		conflict *domain.ConflictError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrInvalidBaseUoM),
+		errors.Is(err, domain.ErrDuplicatePackLevel),
+		errors.Is(err, domain.ErrInvalidPackQuantity),
+		errors.Is(err, domain.ErrHandlingNoBin):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrHandlingTemp),
+		errors.Is(err, domain.ErrHandlingHazmat),
+		errors.Is(err, domain.ErrHandlingFragile),
+		errors.Is(err, domain.ErrHandlingStackable):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.As(err, &conflict):
+		httpcommon.ResponseJSONErrorDetail(w, http.StatusConflict, conflict.Error(), conflict.ConflictResponse())
+	default:
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
@@ -1,15 +1,21 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqlerr"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
 )
 
-func (wr *skuRepository) Get(skuID int64) (domain.SKU, error) {
+func (wr *skuRepository) Get(ctx context.Context, skuID int64) (domain.SKU, error) {
 	var (
-		skuData domain.SKU
+		skuData     domain.SKU
+		commodityID sql.NullInt64
 	)
 
 	query, args, err := squirrel.Select(
@@ -18,7 +24,11 @@ func (wr *skuRepository) Get(skuID int64) (domain.SKU, error) {
 		"wh_code",
 		"bin_code",
 		"zone_id",
+		"serialized",
+		"base_uom",
+		"commodity_id",
 		"name",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("skus").Where(
@@ -30,7 +40,7 @@ func (wr *skuRepository) Get(skuID int64) (domain.SKU, error) {
 	}
 
 	query = wr.sql.Rebind(query)
-	row := wr.sql.QueryRow(query, args...)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
 	if err := row.Err(); err != nil {
 		return skuData, err
 	}
@@ -41,18 +51,29 @@ func (wr *skuRepository) Get(skuID int64) (domain.SKU, error) {
 		&skuData.WHCode,
 		&skuData.BinCode,
 		&skuData.ZoneID,
+		&skuData.Serialized,
+		&skuData.BaseUoM,
+		&commodityID,
 		&skuData.Name,
+		&skuData.Version,
 		&skuData.CreatedAt,
 		&skuData.UpdatedAt,
 	)
 	if err != nil {
 		return skuData, err
 	}
+	skuData.CommodityID = commodityID.Int64
+
+	packs, err := wr.selectPacks(ctx, []int64{skuData.ID})
+	if err != nil {
+		return skuData, err
+	}
+	skuData.Packs = packs[skuData.ID]
 
 	return skuData, nil
 }
 
-func (wr *skuRepository) Select(params domain.SKUQueryParameter) ([]domain.SKU, error) {
+func (wr *skuRepository) Select(ctx context.Context, params domain.SKUQueryParameter) ([]domain.SKU, error) {
 	var (
 		skusData []domain.SKU
 	)
@@ -63,7 +84,11 @@ func (wr *skuRepository) Select(params domain.SKUQueryParameter) ([]domain.SKU,
 		"wh_code",
 		"bin_code",
 		"zone_id",
+		"serialized",
+		"base_uom",
+		"commodity_id",
 		"name",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("skus")
@@ -75,33 +100,60 @@ func (wr *skuRepository) Select(params domain.SKUQueryParameter) ([]domain.SKU,
 	}
 
 	query = wr.sql.Rebind(query)
-	rows, err := wr.sql.Query(query, args...)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
 	if err != nil {
 		return skusData, err
 	}
 
+	defer rows.Close()
+
 	for rows.Next() {
-		var skuData domain.SKU
+		var (
+			skuData     domain.SKU
+			commodityID sql.NullInt64
+		)
 		if err := rows.Scan(
 			&skuData.ID,
 			&skuData.SKU,
 			&skuData.WHCode,
 			&skuData.BinCode,
 			&skuData.ZoneID,
+			&skuData.Serialized,
+			&skuData.BaseUoM,
+			&commodityID,
 			&skuData.Name,
+			&skuData.Version,
 			&skuData.CreatedAt,
 			&skuData.UpdatedAt,
 		); err != nil {
 			return skusData, err
 		}
+		skuData.CommodityID = commodityID.Int64
 
 		skusData = append(skusData, skuData)
 	}
 
+	if len(skusData) < 1 {
+		return skusData, nil
+	}
+
+	skuIDs := make([]int64, 0, len(skusData))
+	for _, skuData := range skusData {
+		skuIDs = append(skuIDs, skuData.ID)
+	}
+
+	packs, err := wr.selectPacks(ctx, skuIDs)
+	if err != nil {
+		return skusData, err
+	}
+	for i := range skusData {
+		skusData[i].Packs = packs[skusData[i].ID]
+	}
+
 	return skusData, nil
 }
 
-func (wr *skuRepository) Create(data domain.SKUDataParameter) (domain.SKU, error) {
+func (wr *skuRepository) Create(ctx context.Context, data domain.SKUDataParameter) (domain.SKU, error) {
 	var (
 		skuData domain.SKU
 		t       = time.Now()
@@ -112,7 +164,11 @@ func (wr *skuRepository) Create(data domain.SKUDataParameter) (domain.SKU, error
 		"wh_code",
 		"bin_code",
 		"zone_id",
+		"serialized",
+		"base_uom",
+		"commodity_id",
 		"name",
+		"version",
 		"created_at",
 		"updated_at",
 	).Values(
@@ -120,7 +176,11 @@ func (wr *skuRepository) Create(data domain.SKUDataParameter) (domain.SKU, error
 		data.WHCode,
 		data.BinCode,
 		data.ZoneID,
+		data.Serialized,
+		data.BaseUoM,
+		nullableID(data.CommodityID),
 		data.Name,
+		1,
 		t, t,
 	).ToSql()
 
@@ -129,29 +189,36 @@ func (wr *skuRepository) Create(data domain.SKUDataParameter) (domain.SKU, error
 		return skuData, err
 	}
 
-	query = wr.sql.Rebind(query)
-	result, err := wr.sql.Exec(query, args...)
-	if err != nil {
-		wr.logger.Errorln(err)
-		return skuData, err
-	}
+	// The SKU and its packs are written together
+	err = sqltx.Run(ctx, wr.sql, func(ctx context.Context) error {
+		tx := sqltx.From(ctx, wr.sql)
 
-	lastInserted, err := result.LastInsertId()
-	if err != nil {
-		wr.logger.Errorln(err)
-		return skuData, err
-	}
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+
+		lastInserted, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		if err := replacePacks(tx, lastInserted, data.Packs); err != nil {
+			return err
+		}
 
-	skuData, err = wr.Get(lastInserted)
+		skuData, err = wr.Get(ctx, lastInserted)
+		return err
+	})
 	if err != nil {
 		wr.logger.Errorln(err)
-		return skuData, err
+		return skuData, wr.conflict(ctx, err, data.SKU)
 	}
 
 	return skuData, nil
 }
 
-func (wr *skuRepository) Update(skuID int64, data domain.SKUDataParameter) (domain.SKU, error) {
+func (wr *skuRepository) Update(ctx context.Context, skuID, version int64, data domain.SKUDataParameter) (domain.SKU, error) {
 	var (
 		skuData domain.SKU
 	)
@@ -162,38 +229,257 @@ func (wr *skuRepository) Update(skuID int64, data domain.SKUDataParameter) (doma
 		Set("wh_code", data.WHCode).
 		Set("bin_code", data.BinCode).
 		Set("zone_id", data.ZoneID).
+		Set("serialized", data.Serialized).
+		Set("base_uom", data.BaseUoM).
+		Set("commodity_id", nullableID(data.CommodityID)).
+		Set("version", squirrel.Expr("version + 1")).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": skuID}).
+		Where(squirrel.Eq{"id": skuID, "version": version}).
 		ToSql()
 	if err != nil {
 		return skuData, err
 	}
 
-	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	err = sqltx.Run(ctx, wr.sql, func(ctx context.Context) error {
+		tx := sqltx.From(ctx, wr.sql)
+
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+
+		if err := wr.checkVersion(ctx, result, skuID); err != nil {
+			return err
+		}
+
+		if err := replacePacks(tx, skuID, data.Packs); err != nil {
+			return err
+		}
+
+		skuData, err = wr.Get(ctx, skuID)
+		return err
+	})
 	if err != nil {
-		return skuData, err
+		return skuData, wr.conflict(ctx, err, data.SKU)
+	}
+
+	return skuData, nil
+}
+
+// Patch writes only the given fields of data, named by their JSON field
+func (wr *skuRepository) Patch(ctx context.Context, skuID, version int64, data domain.SKUDataParameter, fields []string) (domain.SKU, error) {
+	var (
+		skuData domain.SKU
+		packs   bool
+	)
+
+	columns := map[string]interface{}{
+		"name":         data.Name,
+		"sku":          data.SKU,
+		"wh_code":      data.WHCode,
+		"bin_code":     data.BinCode,
+		"zone_id":      data.ZoneID,
+		"serialized":   data.Serialized,
+		"base_uom":     data.BaseUoM,
+		"commodity_id": nullableID(data.CommodityID),
+	}
+
+	updater := squirrel.Update("skus")
+	for _, field := range fields {
+		if field == "packs" {
+			packs = true
+			continue
+		}
+
+		value, ok := columns[field]
+		if !ok {
+			return skuData, fmt.Errorf("sku field %q cannot be patched", field)
+		}
+		updater = updater.Set(field, value)
 	}
 
-	skuData, err = wr.Get(skuID)
+	query, args, err := updater.
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": skuID, "version": version}).
+		ToSql()
 	if err != nil {
 		return skuData, err
 	}
 
+	err = sqltx.Run(ctx, wr.sql, func(ctx context.Context) error {
+		tx := sqltx.From(ctx, wr.sql)
+
+		result, err := tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return err
+		}
+
+		if err := wr.checkVersion(ctx, result, skuID); err != nil {
+			return err
+		}
+
+		if packs {
+			if err := replacePacks(tx, skuID, data.Packs); err != nil {
+				return err
+			}
+		}
+
+		skuData, err = wr.Get(ctx, skuID)
+		return err
+	})
+	if err != nil {
+		return skuData, wr.conflict(ctx, err, data.SKU)
+	}
+
 	return skuData, nil
 }
 
-func (wr *skuRepository) Delete(skuID int64) error {
-	query, args, err := squirrel.Delete("skus").Where(squirrel.Eq{"id": skuID}).ToSql()
+func (wr *skuRepository) Delete(ctx context.Context, skuID, version int64) error {
+	query, args, err := squirrel.Delete("skus").Where(squirrel.Eq{"id": skuID, "version": version}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		return err
 	}
 
+	return wr.checkVersion(ctx, result, skuID)
+}
+
+// selectPacks returns the packs of every given SKU, smallest level first
+func (wr *skuRepository) selectPacks(ctx context.Context, skuIDs []int64) (map[int64][]domain.SKUPack, error) {
+	var (
+		packsData = make(map[int64][]domain.SKUPack)
+	)
+
+	query, args, err := squirrel.Select(
+		"sku_id",
+		"level",
+		"quantity",
+		"weight_kg",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+	).From("sku_packs").Where(
+		squirrel.Eq{"sku_id": skuIDs},
+	).OrderBy("sku_id ASC", "quantity ASC").ToSql()
+
+	if err != nil {
+		return packsData, err
+	}
+
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
+	if err != nil {
+		return packsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var packData domain.SKUPack
+		if err := rows.Scan(
+			&packData.SKUID,
+			&packData.Level,
+			&packData.Quantity,
+			&packData.WeightKg,
+			&packData.LengthMM,
+			&packData.WidthMM,
+			&packData.HeightMM,
+		); err != nil {
+			return packsData, err
+		}
+
+		packsData[packData.SKUID] = append(packsData[packData.SKUID], packData)
+	}
+
+	return packsData, nil
+}
+
+func replacePacks(tx sqltx.Executor, skuID int64, packs []domain.SKUPackDataParameter) error {
+	query, args, err := squirrel.Delete("sku_packs").Where(squirrel.Eq{"sku_id": skuID}).ToSql()
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if _, err := tx.Exec(tx.Rebind(query), args...); err != nil {
+		return err
+	}
+
+	if len(packs) < 1 {
+		return nil
+	}
+
+	inserter := squirrel.Insert("sku_packs").Columns(
+		"sku_id",
+		"level",
+		"quantity",
+		"weight_kg",
+		"length_mm",
+		"width_mm",
+		"height_mm",
+	)
+	for _, pack := range packs {
+		inserter = inserter.Values(
+			skuID,
+			pack.Level,
+			pack.Quantity,
+			pack.WeightKg,
+			pack.LengthMM,
+			pack.WidthMM,
+			pack.HeightMM,
+		)
+	}
+
+	query, args, err = inserter.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(tx.Rebind(query), args...)
+	return err
+}
+
+// SKUs without commodity store NULL
+func nullableID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id > 0}
+}
+
+func (wr *skuRepository) checkVersion(ctx context.Context, result sql.Result, skuID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(ctx, skuID)
+		return err
+	})
+}
+
+// conflict turns a duplicate key error into a ConflictError naming the sku
+// that already holds the code, other errors pass through
+func (wr *skuRepository) conflict(ctx context.Context, err error, sku string) error {
+	if !sqlerr.IsDuplicateKey(err) {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"sku": sku,
+	}
+
+	query, args, qErr := squirrel.Select("id").From("skus").Where(squirrel.Eq(fields)).Limit(1).ToSql()
+	if qErr != nil {
+		return err
+	}
+
+	conflict := &domain.ConflictError{
+		Entity: "sku",
+		Fields: fields,
+	}
+
+	query = wr.sql.Rebind(query)
+	if qErr := sqltx.From(ctx, wr.sql).QueryRow(query, args...).Scan(&conflict.ExistingID); qErr != nil {
+		wr.logger.Errorln(qErr)
+	}
+
+	return conflict
 }
@@ -1,28 +1,47 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	// Most SKUs a single bin is expected to be assigned
+	binSKUsLimit = 100
+)
+
 type skuUsecase struct {
-	logger *logrus.Logger
-	sku    domain.SKURepository
+	logger    *logrus.Logger
+	sku       domain.SKURepository
+	commodity domain.CommodityRepository
+	warehouse domain.WarehouseRepository
+	bin       domain.BinRepository
+	tx        domain.Transactor
+	audit     domain.AuditRepository
+	outbox    domain.OutboxRepository
 }
 
-func NewUsecase(logger *logrus.Logger, sku domain.SKURepository) domain.SKUUsecase {
+func NewUsecase(logger *logrus.Logger, sku domain.SKURepository, commodity domain.CommodityRepository, warehouse domain.WarehouseRepository, bin domain.BinRepository, tx domain.Transactor, audit domain.AuditRepository, outbox domain.OutboxRepository) domain.SKUUsecase {
 	return &skuUsecase{
-		logger: logger,
-		sku:    sku,
+		logger:    logger,
+		sku:       sku,
+		commodity: commodity,
+		warehouse: warehouse,
+		bin:       bin,
+		tx:        tx,
+		audit:     audit,
+		outbox:    outbox,
 	}
 }
 
-func (uc *skuUsecase) Get(skuID int64) (domain.SKUResponse, error) {
+func (uc *skuUsecase) Get(ctx context.Context, skuID int64) (domain.SKUResponse, error) {
 	var (
 		skuResponse domain.SKUResponse
 	)
 
-	warehouseData, err := uc.sku.Get(skuID)
+	warehouseData, err := uc.sku.Get(ctx, skuID)
 	if err != nil {
 		return skuResponse, err
 	}
@@ -31,12 +50,12 @@ func (uc *skuUsecase) Get(skuID int64) (domain.SKUResponse, error) {
 	return skuResponse, nil
 }
 
-func (uc *skuUsecase) Select(params domain.SKUQueryParameter) ([]domain.SKUResponse, error) {
+func (uc *skuUsecase) Select(ctx context.Context, params domain.SKUQueryParameter) ([]domain.SKUResponse, error) {
 	var (
 		skuResponses = []domain.SKUResponse{}
 	)
 
-	skusData, err := uc.sku.Select(params)
+	skusData, err := uc.sku.Select(ctx, params)
 	if err != nil {
 		return skuResponses, err
 	}
@@ -48,36 +67,135 @@ func (uc *skuUsecase) Select(params domain.SKUQueryParameter) ([]domain.SKURespo
 	return skuResponses, nil
 }
 
-func (uc *skuUsecase) Create(data domain.SKUDataParameter) (domain.SKUResponse, error) {
+func (uc *skuUsecase) Create(ctx context.Context, data domain.SKUDataParameter) (domain.SKUResponse, error) {
 	var (
 		skuResponse domain.SKUResponse
 	)
 
-	skuData, err := uc.sku.Create(data)
-	if err != nil {
+	if err := validateUoM(&data); err != nil {
 		return skuResponse, err
 	}
 
-	skuResponse = skuData.SKUResponse()
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.checkHandling(ctx, 0, data); err != nil {
+			return err
+		}
+
+		skuData, err := uc.sku.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		skuResponse = skuData.SKUResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntitySKU, skuData.ID, 0, domain.AuditActionCreate, nil, skuResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventSKUCreated, skuData.ID, 0, skuResponse))
+	})
+	if err != nil {
+		return domain.SKUResponse{}, err
+	}
+
 	return skuResponse, nil
 }
 
-func (uc *skuUsecase) Update(skuID int64, data domain.SKUDataParameter) (domain.SKUResponse, error) {
+func (uc *skuUsecase) Update(ctx context.Context, skuID, version int64, data domain.SKUDataParameter) (domain.SKUResponse, error) {
+	return uc.update(ctx, skuID, version, data, nil)
+}
+
+// Patch writes only the given fields of data, with no fields it only checks
+// the version and returns the sku as it is
+func (uc *skuUsecase) Patch(ctx context.Context, skuID, version int64, data domain.SKUDataParameter, fields []string) (domain.SKUResponse, error) {
+	if len(fields) > 0 {
+		return uc.update(ctx, skuID, version, data, fields)
+	}
+
+	response, err := uc.Get(ctx, skuID)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Version != version {
+		return domain.SKUResponse{}, domain.ErrVersionConflict
+	}
+
+	return response, nil
+}
+
+// update writes every field of data when fields is nil
+func (uc *skuUsecase) update(ctx context.Context, skuID, version int64, data domain.SKUDataParameter, fields []string) (domain.SKUResponse, error) {
 	var (
 		skuResponse domain.SKUResponse
 	)
 
-	skuData, err := uc.sku.Update(skuID, data)
-	if err != nil {
+	if err := validateUoM(&data); err != nil {
 		return skuResponse, err
 	}
 
-	skuResponse = skuData.SKUResponse()
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.checkHandling(ctx, skuID, data); err != nil {
+			return err
+		}
+
+		before, err := uc.sku.Get(ctx, skuID)
+		if err != nil {
+			return err
+		}
+
+		var skuData domain.SKU
+		if fields == nil {
+			skuData, err = uc.sku.Update(ctx, skuID, version, data)
+		} else {
+			skuData, err = uc.sku.Patch(ctx, skuID, version, data, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		skuResponse = skuData.SKUResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntitySKU, skuID, 0, domain.AuditActionUpdate, before.SKUResponse(), skuResponse)); err != nil {
+			return err
+		}
+
+		if err := uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventSKUUpdated, skuID, 0, skuResponse)); err != nil {
+			return err
+		}
+
+		if before.WHCode == skuData.WHCode && before.BinCode == skuData.BinCode {
+			return nil
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventSKURelocated, skuID, 0, domain.SKURelocatedPayload{
+			SKU:  skuResponse,
+			From: domain.SKULocation{WHCode: before.WHCode, BinCode: before.BinCode},
+			To:   domain.SKULocation{WHCode: skuData.WHCode, BinCode: skuData.BinCode},
+		}))
+	})
+	if err != nil {
+		return domain.SKUResponse{}, err
+	}
+
 	return skuResponse, nil
 }
 
-func (uc *skuUsecase) Delete(skuID int64) (domain.GenericResponse, error) {
-	err := uc.sku.Delete(skuID)
+func (uc *skuUsecase) Delete(ctx context.Context, skuID, version int64) (domain.GenericResponse, error) {
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.sku.Get(ctx, skuID)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.sku.Delete(ctx, skuID, version); err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntitySKU, skuID, 0, domain.AuditActionDelete, before.SKUResponse(), nil)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventSKUDeleted, skuID, 0, before.SKUResponse()))
+	})
 	if err != nil {
 		return domain.GenericResponse{}, err
 	}
@@ -86,3 +204,70 @@ func (uc *skuUsecase) Delete(skuID int64) (domain.GenericResponse, error) {
 		Success: true,
 	}, nil
 }
+
+// validateUoM fills in the default unit and checks the pack hierarchy
+func validateUoM(data *domain.SKUDataParameter) error {
+	if len(data.BaseUoM) < 1 {
+		data.BaseUoM = domain.DefaultBaseUoM
+	}
+
+	return domain.ValidatePacks(data.BaseUoM, data.Packs)
+}
+
+// checkHandling applies the handling rules of the SKU commodity to the bin it
+// is assigned to. The wh_code of a SKU is the name of its warehouse and the
+// bin_code the name of a bin in it, goods with handling rules must name both.
+func (uc *skuUsecase) checkHandling(ctx context.Context, skuID int64, data domain.SKUDataParameter) error {
+	binData, found, err := uc.findBin(ctx, data.WHCode, data.BinCode)
+	if err != nil {
+		return err
+	}
+	if !found {
+		commoditiesData, err := domain.LoadCommodities(ctx, uc.commodity, []int64{data.CommodityID})
+		if err != nil {
+			return err
+		}
+		if commoditiesData[data.CommodityID].HasHandlingRules() {
+			return domain.ErrHandlingNoBin
+		}
+		return nil
+	}
+
+	neighboursData, err := uc.sku.Select(ctx, domain.SKUQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: binSKUsLimit},
+		WHCode:          []string{data.WHCode},
+		BinCode:         []string{data.BinCode},
+	})
+	if err != nil {
+		return err
+	}
+
+	var neighbourIDs []int64
+	for _, neighbour := range neighboursData {
+		if neighbour.ID != skuID {
+			neighbourIDs = append(neighbourIDs, neighbour.CommodityID)
+		}
+	}
+
+	return domain.CheckBinHandling(ctx, uc.commodity, data.CommodityID, binData, neighbourIDs)
+}
+
+// findBin resolves the codes of a SKU, bin names repeat across warehouses
+func (uc *skuUsecase) findBin(ctx context.Context, whCode, binCode string) (domain.Bin, bool, error) {
+	warehousesData, err := uc.warehouse.Select(ctx, domain.WarehouseQueryParameter{
+		Name: []string{whCode},
+	})
+	if err != nil || len(warehousesData) < 1 {
+		return domain.Bin{}, false, err
+	}
+
+	binsData, err := uc.bin.Select(ctx, domain.BinQueryParameter{
+		WarehouseID: []int64{warehousesData[0].ID},
+		Name:        []string{binCode},
+	})
+	if err != nil || len(binsData) < 1 {
+		return domain.Bin{}, false, err
+	}
+
+	return binsData[0], true, nil
+}
@@ -0,0 +1,224 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger     *logrus.Logger
+	skuBarcode domain.SKUBarcodeUsecase
+	validator  *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, skuBarcode domain.SKUBarcodeUsecase) {
+	httpInstance := &httpDelivery{
+		logger:     logger,
+		skuBarcode: skuBarcode,
+		validator:  validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/sku/{id}/barcodes", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/sku/{id}/barcodes", httpInstance.Create).Methods("POST")
+	router.HandleFunc("/sku/{id}/barcodes/{barcode_id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/sku/{id}/barcodes/{barcode_id}", httpInstance.Delete).Methods("DELETE")
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.SKUBarcodeQueryParameter
+	)
+
+	if err := domain.AuthorizeAny(r.Context(), domain.PermSKURead); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	skuID, err := pathID(r, "id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	responses, err := h.skuBarcode.Select(r.Context(), skuID, queryParam)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find SKU, Make sure you find correct SKU")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var (
+		createData domain.SKUBarcodeDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	skuID, err := pathID(r, "id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !h.readBody(w, r, &createData) {
+		return
+	}
+
+	response, err := h.skuBarcode.Create(r.Context(), skuID, createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating SKU Barcode")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
+	var (
+		updateData domain.SKUBarcodeDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	skuID, err := pathID(r, "id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	barcodeID, err := pathID(r, "barcode_id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if !h.readBody(w, r, &updateData) {
+		return
+	}
+
+	response, err := h.skuBarcode.Update(r.Context(), skuID, barcodeID, version, updateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating SKU Barcode")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := domain.Authorize(r.Context(), domain.PermSKUWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	skuID, err := pathID(r, "id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	barcodeID, err := pathID(r, "barcode_id")
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.skuBarcode.Delete(r.Context(), skuID, barcodeID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete SKU Barcode")
+	} else {
+		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
+	}
+}
+
+// readBody decodes and validates the request body, answering the request itself on failure
+func (h *httpDelivery) readBody(w http.ResponseWriter, r *http.Request, data *domain.SKUBarcodeDataParameter) bool {
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return false
+	}
+
+	if err := json.Unmarshal(bodyData, data); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return false
+	}
+
+	if err := h.validator.Struct(data); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return false
+	}
+
+	return true
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrSKUBarcodeNotFound):
+		httpcommon.ResponseJSONError(w, http.StatusNotFound, "Cannot find SKU Barcode, Make sure you find correct SKU Barcode")
+	case errors.Is(err, domain.ErrInvalidPackLevel):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Each Barcode must have a Quantity of 1")
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
+
+func pathID(r *http.Request, key string) (int64, error) {
+	vars := mux.Vars(r)
+	if id, ok := vars[key]; !ok {
+		return 0, errors.New("Invalid ID")
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return 0, errors.New("ID Must be a number")
+		}
+		return id, nil
+	}
+}
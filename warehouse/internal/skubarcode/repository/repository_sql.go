@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+func (wr *skuBarcodeRepository) Get(barcodeID int64) (domain.SKUBarcode, error) {
+	var (
+		barcodeData domain.SKUBarcode
+	)
+
+	query, args, err := squirrel.Select(
+		"id",
+		"sku_id",
+		"barcode",
+		"type",
+		"pack_level",
+		"quantity",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("sku_barcodes").Where(
+		squirrel.Eq{"id": barcodeID},
+	).ToSql()
+
+	if err != nil {
+		return barcodeData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	row := wr.sql.QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return barcodeData, err
+	}
+
+	err = row.Scan(
+		&barcodeData.ID,
+		&barcodeData.SKUID,
+		&barcodeData.Barcode,
+		&barcodeData.Type,
+		&barcodeData.PackLevel,
+		&barcodeData.Quantity,
+		&barcodeData.Version,
+		&barcodeData.CreatedAt,
+		&barcodeData.UpdatedAt,
+	)
+	if err != nil {
+		return barcodeData, err
+	}
+
+	return barcodeData, nil
+}
+
+func (wr *skuBarcodeRepository) Select(params domain.SKUBarcodeQueryParameter) ([]domain.SKUBarcode, error) {
+	var (
+		barcodesData []domain.SKUBarcode
+	)
+
+	selector := squirrel.Select(
+		"id",
+		"sku_id",
+		"barcode",
+		"type",
+		"pack_level",
+		"quantity",
+		"version",
+		"created_at",
+		"updated_at",
+	).From("sku_barcodes")
+	selector = params.BuildSQLQuery(selector)
+	query, args, err := selector.ToSql()
+
+	if err != nil {
+		return barcodesData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	rows, err := wr.sql.Query(query, args...)
+	if err != nil {
+		return barcodesData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var barcodeData domain.SKUBarcode
+		if err := rows.Scan(
+			&barcodeData.ID,
+			&barcodeData.SKUID,
+			&barcodeData.Barcode,
+			&barcodeData.Type,
+			&barcodeData.PackLevel,
+			&barcodeData.Quantity,
+			&barcodeData.Version,
+			&barcodeData.CreatedAt,
+			&barcodeData.UpdatedAt,
+		); err != nil {
+			return barcodesData, err
+		}
+
+		barcodesData = append(barcodesData, barcodeData)
+	}
+
+	return barcodesData, nil
+}
+
+func (wr *skuBarcodeRepository) Create(skuID int64, data domain.SKUBarcodeDataParameter) (domain.SKUBarcode, error) {
+	var (
+		barcodeData domain.SKUBarcode
+		t           = time.Now()
+	)
+
+	query, args, err := squirrel.Insert("sku_barcodes").Columns(
+		"sku_id",
+		"barcode",
+		"type",
+		"pack_level",
+		"quantity",
+		"created_at",
+		"updated_at",
+	).Values(
+		skuID,
+		data.Barcode,
+		data.Type,
+		data.PackLevel,
+		data.Quantity,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		wr.logger.Errorln(err)
+		return barcodeData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := wr.sql.Exec(query, args...)
+	if err != nil {
+		wr.logger.Errorln(err)
+		return barcodeData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		wr.logger.Errorln(err)
+		return barcodeData, err
+	}
+
+	barcodeData, err = wr.Get(lastInserted)
+	if err != nil {
+		wr.logger.Errorln(err)
+		return barcodeData, err
+	}
+
+	return barcodeData, nil
+}
+
+func (wr *skuBarcodeRepository) Update(barcodeID, version int64, data domain.SKUBarcodeDataParameter) (domain.SKUBarcode, error) {
+	var (
+		barcodeData domain.SKUBarcode
+	)
+
+	query, args, err := squirrel.Update("sku_barcodes").
+		Set("barcode", data.Barcode).
+		Set("type", data.Type).
+		Set("pack_level", data.PackLevel).
+		Set("quantity", data.Quantity).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": barcodeID, "version": version}).
+		ToSql()
+	if err != nil {
+		return barcodeData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := wr.sql.Exec(query, args...)
+	if err != nil {
+		return barcodeData, err
+	}
+
+	if err := wr.checkVersion(result, barcodeID); err != nil {
+		return barcodeData, err
+	}
+
+	barcodeData, err = wr.Get(barcodeID)
+	if err != nil {
+		return barcodeData, err
+	}
+
+	return barcodeData, nil
+}
+
+func (wr *skuBarcodeRepository) Delete(barcodeID, version int64) error {
+	query, args, err := squirrel.Delete("sku_barcodes").Where(squirrel.Eq{"id": barcodeID, "version": version}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := wr.sql.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+
+	return wr.checkVersion(result, barcodeID)
+}
+
+func (wr *skuBarcodeRepository) checkVersion(result sql.Result, barcodeID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(barcodeID)
+		return err
+	})
+}
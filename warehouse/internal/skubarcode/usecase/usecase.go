@@ -0,0 +1,125 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+type skuBarcodeUsecase struct {
+	logger     *logrus.Logger
+	sku        domain.SKURepository
+	skuBarcode domain.SKUBarcodeRepository
+}
+
+func NewUsecase(logger *logrus.Logger, sku domain.SKURepository, skuBarcode domain.SKUBarcodeRepository) domain.SKUBarcodeUsecase {
+	return &skuBarcodeUsecase{
+		logger:     logger,
+		sku:        sku,
+		skuBarcode: skuBarcode,
+	}
+}
+
+func (uc *skuBarcodeUsecase) Select(ctx context.Context, skuID int64, params domain.SKUBarcodeQueryParameter) ([]domain.SKUBarcodeResponse, error) {
+	var (
+		barcodeResponses = []domain.SKUBarcodeResponse{}
+	)
+
+	if _, err := uc.sku.Get(ctx, skuID); err != nil {
+		return barcodeResponses, err
+	}
+
+	params.SKUID = []int64{skuID}
+	barcodesData, err := uc.skuBarcode.Select(params)
+	if err != nil {
+		return barcodeResponses, err
+	}
+
+	for _, barcode := range barcodesData {
+		barcodeResponses = append(barcodeResponses, barcode.SKUBarcodeResponse())
+	}
+
+	return barcodeResponses, nil
+}
+
+func (uc *skuBarcodeUsecase) Create(ctx context.Context, skuID int64, data domain.SKUBarcodeDataParameter) (domain.SKUBarcodeResponse, error) {
+	var (
+		barcodeResponse domain.SKUBarcodeResponse
+	)
+
+	if err := validatePackLevel(data); err != nil {
+		return barcodeResponse, err
+	}
+
+	if _, err := uc.sku.Get(ctx, skuID); err != nil {
+		return barcodeResponse, err
+	}
+
+	barcodeData, err := uc.skuBarcode.Create(skuID, data)
+	if err != nil {
+		return barcodeResponse, err
+	}
+
+	barcodeResponse = barcodeData.SKUBarcodeResponse()
+	return barcodeResponse, nil
+}
+
+func (uc *skuBarcodeUsecase) Update(ctx context.Context, skuID int64, barcodeID, version int64, data domain.SKUBarcodeDataParameter) (domain.SKUBarcodeResponse, error) {
+	var (
+		barcodeResponse domain.SKUBarcodeResponse
+	)
+
+	if err := validatePackLevel(data); err != nil {
+		return barcodeResponse, err
+	}
+
+	if err := uc.checkOwner(skuID, barcodeID); err != nil {
+		return barcodeResponse, err
+	}
+
+	barcodeData, err := uc.skuBarcode.Update(barcodeID, version, data)
+	if err != nil {
+		return barcodeResponse, err
+	}
+
+	barcodeResponse = barcodeData.SKUBarcodeResponse()
+	return barcodeResponse, nil
+}
+
+func (uc *skuBarcodeUsecase) Delete(ctx context.Context, skuID int64, barcodeID, version int64) (domain.GenericResponse, error) {
+	if err := uc.checkOwner(skuID, barcodeID); err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	err := uc.skuBarcode.Delete(barcodeID, version)
+	if err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	return domain.GenericResponse{
+		Success: true,
+	}, nil
+}
+
+// checkOwner makes sure a barcode is only changed through the SKU it belongs to
+func (uc *skuBarcodeUsecase) checkOwner(skuID int64, barcodeID int64) error {
+	barcodeData, err := uc.skuBarcode.Get(barcodeID)
+	if err != nil {
+		return err
+	}
+
+	if barcodeData.SKUID != skuID {
+		return domain.ErrSKUBarcodeNotFound
+	}
+
+	return nil
+}
+
+func validatePackLevel(data domain.SKUBarcodeDataParameter) error {
+	if data.PackLevel == domain.PackLevelEach && data.Quantity != 1 {
+		return domain.ErrInvalidPackLevel
+	}
+
+	return nil
+}
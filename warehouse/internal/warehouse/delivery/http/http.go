@@ -2,12 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/mergepatch"
 	"github.com/go-playground/validator"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
@@ -31,6 +33,7 @@ func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, warehouse domain
 	router.HandleFunc("/warehouse", httpInstance.Create).Methods("POST")
 	router.HandleFunc("/warehouse/{id}", httpInstance.Get).Methods("GET")
 	router.HandleFunc("/warehouse/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/warehouse/{id}", httpInstance.Patch).Methods("PATCH")
 	router.HandleFunc("/warehouse/{id}", httpInstance.Delete).Methods("DELETE")
 }
 
@@ -52,12 +55,18 @@ func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
 		warehouseID = id
 	}
 
-	response, err := h.warehouse.Get(warehouseID)
+	if err := domain.Authorize(r.Context(), domain.PermWarehouseRead, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	response, err := h.warehouse.Get(r.Context(), warehouseID)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find warehouse, Make sure you find correct warehouse")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusOK, response)
 }
 
@@ -72,7 +81,19 @@ func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	responses, err := h.warehouse.Select(queryParam)
+	// Only list the warehouses the caller may see
+	warehouseIDs, ok, err := domain.ScopeWarehouses(r.Context(), domain.PermWarehouseRead, queryParam.ID)
+	if err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+	if !ok {
+		httpcommon.ResponseJSON(w, http.StatusOK, []domain.WarehouseResponse{})
+		return
+	}
+	queryParam.ID = warehouseIDs
+
+	responses, err := h.warehouse.Select(r.Context(), queryParam)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Query Warehouses")
 		return
@@ -86,6 +107,12 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		createData domain.WarehouseDataParameter
 	)
 
+	// New warehouses are not covered by any scoped grant yet
+	if err := domain.Authorize(r.Context(), domain.PermWarehouseWrite, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -102,12 +129,13 @@ func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.warehouse.Create(createData)
+	response, err := h.warehouse.Create(r.Context(), createData)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Creating Warehouse")
+		h.responseError(w, err, "An Error Occured When Creating Warehouse")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -130,6 +158,17 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		warehouseID = id
 	}
 
+	if err := domain.Authorize(r.Context(), domain.PermWarehouseWrite, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
 	bodyData, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
@@ -146,12 +185,83 @@ func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.warehouse.Update(warehouseID, updateData)
+	response, err := h.warehouse.Update(r.Context(), warehouseID, version, updateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating Warehouse")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+// Patch applies a JSON merge patch to the warehouse, only the fields it names
+// are validated and only the ones it changes are written
+func (h *httpDelivery) Patch(w http.ResponseWriter, r *http.Request) {
+	var (
+		warehouseID int64
+		patchData   domain.WarehouseDataParameter
+	)
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		warehouseID = id
+	}
+
+	if err := domain.Authorize(r.Context(), domain.PermWarehouseWrite, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if !mergepatch.Accepts(r.Header.Get("Content-Type")) {
+		httpcommon.ResponseJSONError(w, http.StatusUnsupportedMediaType, "Content-Type must be "+mergepatch.ContentType)
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	current, err := h.warehouse.Get(r.Context(), warehouseID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Warehouse, Make sure you find correct Warehouse")
+		return
+	}
+
+	result, err := mergepatch.Merge(current.WarehouseDataParameter(), bodyData, &patchData)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Apply Merge Patch")
+		return
+	}
+
+	if err := mergepatch.Validate(h.validator, &patchData, result.Supplied); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.warehouse.Patch(r.Context(), warehouseID, version, patchData, result.Changed)
 	if err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "An Error Occured When Updating Warehouse")
+		h.responseError(w, err, "An Error Occured When Updating Warehouse")
 		return
 	}
 
+	httpcommon.SetETag(w, response.Version)
 	httpcommon.ResponseJSON(w, http.StatusCreated, response)
 }
 
@@ -173,9 +283,43 @@ func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
 		warehouseID = id
 	}
 
-	if resp, err := h.warehouse.Delete(warehouseID); err != nil {
-		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Delete Warehouse")
+	if err := domain.Authorize(r.Context(), domain.PermWarehouseDelete, warehouseID); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.warehouse.Delete(r.Context(), warehouseID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Warehouse")
 	} else {
 		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
 	}
 }
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	var (
+		conflict *domain.ConflictError
+	)
+
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.As(err, &conflict):
+		httpcommon.ResponseJSONErrorDetail(w, http.StatusConflict, conflict.Error(), conflict.ConflictResponse())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
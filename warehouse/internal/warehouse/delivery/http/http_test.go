@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+func TestResponseErrorConflict(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	h := &httpDelivery{logger: logger}
+
+	conflict := &domain.ConflictError{
+		Entity:     "warehouse",
+		Fields:     map[string]interface{}{"name": "Jakarta"},
+		ExistingID: 3,
+	}
+
+	rec := httptest.NewRecorder()
+	h.responseError(rec, fmt.Errorf("create: %w", conflict), "Cannot Create Warehouse")
+
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	var body struct {
+		Error struct {
+			Code   int                    `json:"code"`
+			Detail map[string]interface{} `json:"detail"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"entity":      "warehouse",
+		"fields":      map[string]interface{}{"name": "Jakarta"},
+		"existing_id": float64(3),
+	}
+	if body.Error.Code != http.StatusConflict || !reflect.DeepEqual(body.Error.Detail, want) {
+		t.Errorf("body = %s, want the conflict detail %v", rec.Body, want)
+	}
+}
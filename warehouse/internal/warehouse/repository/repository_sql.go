@@ -1,13 +1,18 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqlerr"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
 )
 
-func (wr *warehouseRepository) Get(warehouseID int64) (domain.Warehouse, error) {
+func (wr *warehouseRepository) Get(ctx context.Context, warehouseID int64) (domain.Warehouse, error) {
 	var (
 		warehouseData domain.Warehouse
 	)
@@ -17,6 +22,7 @@ func (wr *warehouseRepository) Get(warehouseID int64) (domain.Warehouse, error)
 		"name",
 		"latitude",
 		"longitude",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("warehouses").Where(
@@ -28,7 +34,7 @@ func (wr *warehouseRepository) Get(warehouseID int64) (domain.Warehouse, error)
 	}
 
 	query = wr.sql.Rebind(query)
-	row := wr.sql.QueryRow(query, args...)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
 	if err := row.Err(); err != nil {
 		return warehouseData, err
 	}
@@ -38,6 +44,7 @@ func (wr *warehouseRepository) Get(warehouseID int64) (domain.Warehouse, error)
 		&warehouseData.Name,
 		&warehouseData.Latitude,
 		&warehouseData.Longitude,
+		&warehouseData.Version,
 		&warehouseData.CreatedAt,
 		&warehouseData.UpdatedAt,
 	)
@@ -48,7 +55,7 @@ func (wr *warehouseRepository) Get(warehouseID int64) (domain.Warehouse, error)
 	return warehouseData, nil
 }
 
-func (wr *warehouseRepository) Select(params domain.WarehouseQueryParameter) ([]domain.Warehouse, error) {
+func (wr *warehouseRepository) Select(ctx context.Context, params domain.WarehouseQueryParameter) ([]domain.Warehouse, error) {
 	var (
 		warehousesData []domain.Warehouse
 	)
@@ -58,6 +65,7 @@ func (wr *warehouseRepository) Select(params domain.WarehouseQueryParameter) ([]
 		"name",
 		"latitude",
 		"longitude",
+		"version",
 		"created_at",
 		"updated_at",
 	).From("warehouses")
@@ -69,7 +77,7 @@ func (wr *warehouseRepository) Select(params domain.WarehouseQueryParameter) ([]
 	}
 
 	query = wr.sql.Rebind(query)
-	rows, err := wr.sql.Query(query, args...)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
 	if err != nil {
 		return warehousesData, err
 	}
@@ -81,6 +89,7 @@ func (wr *warehouseRepository) Select(params domain.WarehouseQueryParameter) ([]
 			&warehouseData.Name,
 			&warehouseData.Latitude,
 			&warehouseData.Longitude,
+			&warehouseData.Version,
 			&warehouseData.CreatedAt,
 			&warehouseData.UpdatedAt,
 		); err != nil {
@@ -93,7 +102,7 @@ func (wr *warehouseRepository) Select(params domain.WarehouseQueryParameter) ([]
 	return warehousesData, nil
 }
 
-func (wr *warehouseRepository) Create(data domain.WarehouseDataParameter) (domain.Warehouse, error) {
+func (wr *warehouseRepository) Create(ctx context.Context, data domain.WarehouseDataParameter) (domain.Warehouse, error) {
 	var (
 		warehouseData domain.Warehouse
 		t             = time.Now()
@@ -103,12 +112,14 @@ func (wr *warehouseRepository) Create(data domain.WarehouseDataParameter) (domai
 		"name",
 		"latitude",
 		"longitude",
+		"version",
 		"created_at",
 		"updated_at",
 	).Values(
 		data.Name,
 		data.Latitude,
 		data.Longitude,
+		1,
 		t, t,
 	).ToSql()
 
@@ -117,9 +128,9 @@ func (wr *warehouseRepository) Create(data domain.WarehouseDataParameter) (domai
 	}
 
 	query = wr.sql.Rebind(query)
-	result, err := wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
-		return warehouseData, err
+		return warehouseData, wr.conflict(ctx, err, data.Name)
 	}
 
 	lastInserted, err := result.LastInsertId()
@@ -127,7 +138,7 @@ func (wr *warehouseRepository) Create(data domain.WarehouseDataParameter) (domai
 		return warehouseData, err
 	}
 
-	warehouseData, err = wr.Get(lastInserted)
+	warehouseData, err = wr.Get(ctx, lastInserted)
 	if err != nil {
 		return warehouseData, err
 	}
@@ -135,7 +146,7 @@ func (wr *warehouseRepository) Create(data domain.WarehouseDataParameter) (domai
 	return warehouseData, nil
 }
 
-func (wr *warehouseRepository) Update(warehouseID int64, data domain.WarehouseDataParameter) (domain.Warehouse, error) {
+func (wr *warehouseRepository) Update(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter) (domain.Warehouse, error) {
 	var (
 		warehouseData domain.Warehouse
 	)
@@ -144,20 +155,73 @@ func (wr *warehouseRepository) Update(warehouseID int64, data domain.WarehouseDa
 		Set("name", data.Name).
 		Set("latitude", data.Latitude).
 		Set("longitude", data.Longitude).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": warehouseID, "version": version}).
+		ToSql()
+	if err != nil {
+		return warehouseData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		return warehouseData, wr.conflict(ctx, err, data.Name)
+	}
+
+	if err := wr.checkVersion(ctx, result, warehouseID); err != nil {
+		return warehouseData, err
+	}
+
+	warehouseData, err = wr.Get(ctx, warehouseID)
+	if err != nil {
+		return warehouseData, err
+	}
+
+	return warehouseData, nil
+}
+
+// Patch writes only the given fields of data, named by their JSON field
+func (wr *warehouseRepository) Patch(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter, fields []string) (domain.Warehouse, error) {
+	var (
+		warehouseData domain.Warehouse
+	)
+
+	columns := map[string]interface{}{
+		"name":      data.Name,
+		"latitude":  data.Latitude,
+		"longitude": data.Longitude,
+	}
+
+	updater := squirrel.Update("warehouses")
+	for _, field := range fields {
+		value, ok := columns[field]
+		if !ok {
+			return warehouseData, fmt.Errorf("warehouse field %q cannot be patched", field)
+		}
+		updater = updater.Set(field, value)
+	}
+
+	query, args, err := updater.
+		Set("version", squirrel.Expr("version + 1")).
 		Set("updated_at", time.Now()).
-		Where(squirrel.Eq{"id": warehouseID}).
+		Where(squirrel.Eq{"id": warehouseID, "version": version}).
 		ToSql()
 	if err != nil {
 		return warehouseData, err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
+		return warehouseData, wr.conflict(ctx, err, data.Name)
+	}
+
+	if err := wr.checkVersion(ctx, result, warehouseID); err != nil {
 		return warehouseData, err
 	}
 
-	warehouseData, err = wr.Get(warehouseID)
+	warehouseData, err = wr.Get(ctx, warehouseID)
 	if err != nil {
 		return warehouseData, err
 	}
@@ -165,17 +229,53 @@ func (wr *warehouseRepository) Update(warehouseID int64, data domain.WarehouseDa
 	return warehouseData, nil
 }
 
-func (wr *warehouseRepository) Delete(warehouseID int64) error {
-	query, args, err := squirrel.Delete("warehouses").Where(squirrel.Eq{"id": warehouseID}).ToSql()
+func (wr *warehouseRepository) Delete(ctx context.Context, warehouseID, version int64) error {
+	query, args, err := squirrel.Delete("warehouses").Where(squirrel.Eq{"id": warehouseID, "version": version}).ToSql()
 	if err != nil {
 		return err
 	}
 
 	query = wr.sql.Rebind(query)
-	_, err = wr.sql.Exec(query, args...)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return wr.checkVersion(ctx, result, warehouseID)
+}
+
+func (wr *warehouseRepository) checkVersion(ctx context.Context, result sql.Result, warehouseID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(ctx, warehouseID)
+		return err
+	})
+}
+
+// conflict turns a duplicate key error into a ConflictError naming the warehouse
+// that already holds the name, other errors pass through
+func (wr *warehouseRepository) conflict(ctx context.Context, err error, name string) error {
+	if !sqlerr.IsDuplicateKey(err) {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"name": name,
+	}
+
+	query, args, qErr := squirrel.Select("id").From("warehouses").Where(squirrel.Eq(fields)).Limit(1).ToSql()
+	if qErr != nil {
+		return err
+	}
+
+	conflict := &domain.ConflictError{
+		Entity: "warehouse",
+		Fields: fields,
+	}
+
+	query = wr.sql.Rebind(query)
+	if qErr := sqltx.From(ctx, wr.sql).QueryRow(query, args...).Scan(&conflict.ExistingID); qErr != nil {
+		wr.logger.Errorln(qErr)
+	}
+
+	return conflict
 }
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltest"
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+)
+
+func TestConflict(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}
+	want := &domain.ConflictError{
+		Entity:     "warehouse",
+		Fields:     map[string]interface{}{"name": "Jakarta"},
+		ExistingID: 3,
+	}
+
+	tests := []struct {
+		name       string
+		err        error
+		lookupErr  error
+		want       error
+		wantLookup bool
+	}{
+		{"not a duplicate", sql.ErrConnDone, nil, sql.ErrConnDone, false},
+		{"duplicate", duplicate, nil, want, true},
+		{"wrapped duplicate", fmt.Errorf("insert: %w", duplicate), nil, want, true},
+		{"lookup fails", duplicate, sql.ErrConnDone, &domain.ConflictError{Entity: want.Entity, Fields: want.Fields}, true},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.Driver{
+				Columns: []string{"id"},
+				Rows:    [][]driver.Value{{int64(3)}},
+				Err:     tt.lookupErr,
+			}
+			db := fake.DB()
+			defer db.Close()
+			repo := &warehouseRepository{logger: logger, sql: db}
+
+			err := repo.conflict(context.Background(), tt.err, "Jakarta")
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("err = %#v, want %#v", err, tt.want)
+			}
+			if tt.want != tt.err && !errors.Is(err, domain.ErrConflict) {
+				t.Errorf("err is not domain.ErrConflict")
+			}
+
+			if !tt.wantLookup {
+				if len(fake.Statements) > 0 {
+					t.Errorf("looked up the existing record of %v", tt.err)
+				}
+				return
+			}
+			if len(fake.Statements) != 1 {
+				t.Fatalf("ran %d statements, want 1", len(fake.Statements))
+			}
+			if lookup := fake.Statements[0]; !strings.Contains(lookup.Query, "FROM warehouses") || !reflect.DeepEqual(lookup.Args, []driver.Value{"Jakarta"}) {
+				t.Errorf("lookup = %s %v", lookup.Query, lookup.Args)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"context"
+
 	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
 	"github.com/sirupsen/logrus"
 )
@@ -9,29 +11,35 @@ type warehouseUsecase struct {
 	logger    *logrus.Logger
 	warehouse domain.WarehouseRepository
 	bin       domain.BinRepository
+	tx        domain.Transactor
+	audit     domain.AuditRepository
+	outbox    domain.OutboxRepository
 }
 
-func NewUsecase(logger *logrus.Logger, warehouse domain.WarehouseRepository, bin domain.BinRepository) domain.WarehouseUsecase {
+func NewUsecase(logger *logrus.Logger, warehouse domain.WarehouseRepository, bin domain.BinRepository, tx domain.Transactor, audit domain.AuditRepository, outbox domain.OutboxRepository) domain.WarehouseUsecase {
 	return &warehouseUsecase{
 		logger:    logger,
 		warehouse: warehouse,
 		bin:       bin,
+		tx:        tx,
+		audit:     audit,
+		outbox:    outbox,
 	}
 }
 
-func (uc *warehouseUsecase) Get(warehouseID int64) (domain.WarehouseResponse, error) {
+func (uc *warehouseUsecase) Get(ctx context.Context, warehouseID int64) (domain.WarehouseResponse, error) {
 	var (
 		warehouseResponse domain.WarehouseResponse
 		binsResponse      []domain.BinResponse
 	)
 
-	warehouseData, err := uc.warehouse.Get(warehouseID)
+	warehouseData, err := uc.warehouse.Get(ctx, warehouseID)
 	if err != nil {
 		return warehouseResponse, err
 	}
 
 	// Fetch Bins Data
-	binsData, err := uc.bin.GetByWarehouseID(warehouseID)
+	binsData, err := uc.bin.GetByWarehouseID(ctx, warehouseID)
 	if err != nil {
 		return warehouseResponse, err
 	}
@@ -45,12 +53,12 @@ func (uc *warehouseUsecase) Get(warehouseID int64) (domain.WarehouseResponse, er
 	return warehouseResponse, nil
 }
 
-func (uc *warehouseUsecase) Select(params domain.WarehouseQueryParameter) ([]domain.WarehouseResponse, error) {
+func (uc *warehouseUsecase) Select(ctx context.Context, params domain.WarehouseQueryParameter) ([]domain.WarehouseResponse, error) {
 	var (
 		warehouseResponses = []domain.WarehouseResponse{}
 	)
 
-	warehousesData, err := uc.warehouse.Select(params)
+	warehousesData, err := uc.warehouse.Select(ctx, params)
 	if err != nil {
 		return warehouseResponses, err
 	}
@@ -62,36 +70,127 @@ func (uc *warehouseUsecase) Select(params domain.WarehouseQueryParameter) ([]dom
 	return warehouseResponses, nil
 }
 
-func (uc *warehouseUsecase) Create(data domain.WarehouseDataParameter) (domain.WarehouseResponse, error) {
+func (uc *warehouseUsecase) Names(ctx context.Context, warehouseIDs []int64) ([]string, error) {
+	var (
+		names []string
+	)
+
+	warehousesData, err := uc.warehouse.Select(ctx, domain.WarehouseQueryParameter{
+		PaginationQuery: domain.PaginationQuery{Limit: int64(len(warehouseIDs))},
+		ID:              warehouseIDs,
+	})
+	if err != nil {
+		return names, err
+	}
+
+	for _, warehouse := range warehousesData {
+		names = append(names, warehouse.Name)
+	}
+
+	return names, nil
+}
+
+func (uc *warehouseUsecase) Create(ctx context.Context, data domain.WarehouseDataParameter) (domain.WarehouseResponse, error) {
 	var (
 		warehouseResponse domain.WarehouseResponse
 	)
 
-	warehouseData, err := uc.warehouse.Create(data)
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		warehouseData, err := uc.warehouse.Create(ctx, data)
+		if err != nil {
+			return err
+		}
+
+		warehouseResponse = warehouseData.WarehouseResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityWarehouse, warehouseData.ID, warehouseData.ID, domain.AuditActionCreate, nil, warehouseResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventWarehouseCreated, warehouseData.ID, warehouseData.ID, warehouseResponse))
+	})
 	if err != nil {
-		return warehouseResponse, err
+		return domain.WarehouseResponse{}, err
 	}
 
-	warehouseResponse = warehouseData.WarehouseResponse()
 	return warehouseResponse, nil
 }
 
-func (uc *warehouseUsecase) Update(warehouseID int64, data domain.WarehouseDataParameter) (domain.WarehouseResponse, error) {
+func (uc *warehouseUsecase) Update(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter) (domain.WarehouseResponse, error) {
+	return uc.update(ctx, warehouseID, version, data, nil)
+}
+
+// Patch writes only the given fields of data, with no fields it only checks
+// the version and returns the warehouse as it is
+func (uc *warehouseUsecase) Patch(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter, fields []string) (domain.WarehouseResponse, error) {
+	if len(fields) > 0 {
+		return uc.update(ctx, warehouseID, version, data, fields)
+	}
+
+	response, err := uc.Get(ctx, warehouseID)
+	if err != nil {
+		return response, err
+	}
+
+	if response.Version != version {
+		return domain.WarehouseResponse{}, domain.ErrVersionConflict
+	}
+
+	return response, nil
+}
+
+// update writes every field of data when fields is nil
+func (uc *warehouseUsecase) update(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter, fields []string) (domain.WarehouseResponse, error) {
 	var (
 		warehouseResponse domain.WarehouseResponse
 	)
 
-	warehouseData, err := uc.warehouse.Update(warehouseID, data)
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.warehouse.Get(ctx, warehouseID)
+		if err != nil {
+			return err
+		}
+
+		var warehouseData domain.Warehouse
+		if fields == nil {
+			warehouseData, err = uc.warehouse.Update(ctx, warehouseID, version, data)
+		} else {
+			warehouseData, err = uc.warehouse.Patch(ctx, warehouseID, version, data, fields)
+		}
+		if err != nil {
+			return err
+		}
+
+		warehouseResponse = warehouseData.WarehouseResponse()
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityWarehouse, warehouseID, warehouseData.ID, domain.AuditActionUpdate, before.WarehouseResponse(), warehouseResponse)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventWarehouseUpdated, warehouseID, warehouseData.ID, warehouseResponse))
+	})
 	if err != nil {
-		return warehouseResponse, err
+		return domain.WarehouseResponse{}, err
 	}
 
-	warehouseResponse = warehouseData.WarehouseResponse()
 	return warehouseResponse, nil
 }
 
-func (uc *warehouseUsecase) Delete(warehouseID int64) (domain.GenericResponse, error) {
-	err := uc.warehouse.Delete(warehouseID)
+func (uc *warehouseUsecase) Delete(ctx context.Context, warehouseID, version int64) (domain.GenericResponse, error) {
+	err := uc.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := uc.warehouse.Get(ctx, warehouseID)
+		if err != nil {
+			return err
+		}
+
+		if err := uc.warehouse.Delete(ctx, warehouseID, version); err != nil {
+			return err
+		}
+
+		if err := uc.audit.Create(ctx, domain.NewAuditDataParameter(ctx, domain.AuditEntityWarehouse, warehouseID, before.ID, domain.AuditActionDelete, before.WarehouseResponse(), nil)); err != nil {
+			return err
+		}
+
+		return uc.outbox.Create(ctx, domain.NewEventDataParameter(domain.EventWarehouseDeleted, warehouseID, before.ID, before.WarehouseResponse()))
+	})
 	if err != nil {
 		return domain.GenericResponse{}, err
 	}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errAuditFailed = errors.New("audit insert failed")
+)
+
+// fakeStore is the database behind the fake repositories, fakeTx restores it
+// when the function it runs fails
+type fakeStore struct {
+	warehouses map[int64]domain.Warehouse
+	audits     []domain.AuditDataParameter
+	events     []domain.EventDataParameter
+	failAudit  bool
+}
+
+type fakeTx struct {
+	store *fakeStore
+}
+
+func (f fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := *f.store
+	saved.warehouses = make(map[int64]domain.Warehouse, len(f.store.warehouses))
+	for id, warehouse := range f.store.warehouses {
+		saved.warehouses[id] = warehouse
+	}
+
+	if err := fn(ctx); err != nil {
+		*f.store = saved
+		return err
+	}
+	return nil
+}
+
+type fakeWarehouses struct {
+	domain.WarehouseRepository
+	store *fakeStore
+}
+
+func (f fakeWarehouses) Get(ctx context.Context, warehouseID int64) (domain.Warehouse, error) {
+	warehouse, found := f.store.warehouses[warehouseID]
+	if !found {
+		return domain.Warehouse{}, sql.ErrNoRows
+	}
+	return warehouse, nil
+}
+
+func (f fakeWarehouses) Create(ctx context.Context, data domain.WarehouseDataParameter) (domain.Warehouse, error) {
+	warehouse := domain.Warehouse{
+		ID:        int64(len(f.store.warehouses) + 1),
+		Name:      data.Name,
+		Latitude:  data.Latitude,
+		Longitude: data.Longitude,
+		Version:   1,
+	}
+	f.store.warehouses[warehouse.ID] = warehouse
+	return warehouse, nil
+}
+
+func (f fakeWarehouses) Update(ctx context.Context, warehouseID, version int64, data domain.WarehouseDataParameter) (domain.Warehouse, error) {
+	warehouse, err := f.Get(ctx, warehouseID)
+	if err != nil {
+		return warehouse, err
+	}
+	if warehouse.Version != version {
+		return domain.Warehouse{}, domain.ErrVersionConflict
+	}
+
+	warehouse.Name = data.Name
+	warehouse.Latitude = data.Latitude
+	warehouse.Longitude = data.Longitude
+	warehouse.Version++
+	f.store.warehouses[warehouseID] = warehouse
+	return warehouse, nil
+}
+
+func (f fakeWarehouses) Delete(ctx context.Context, warehouseID, version int64) error {
+	if _, err := f.Get(ctx, warehouseID); err != nil {
+		return err
+	}
+	delete(f.store.warehouses, warehouseID)
+	return nil
+}
+
+type fakeAudit struct {
+	store *fakeStore
+}
+
+func (f fakeAudit) Create(ctx context.Context, data domain.AuditDataParameter) error {
+	if f.store.failAudit {
+		return errAuditFailed
+	}
+	f.store.audits = append(f.store.audits, data)
+	return nil
+}
+
+func (f fakeAudit) Select(ctx context.Context, params domain.AuditQueryParameter) ([]domain.AuditEntry, error) {
+	return nil, nil
+}
+
+type fakeOutbox struct {
+	domain.OutboxRepository
+	store *fakeStore
+}
+
+func (f fakeOutbox) Create(ctx context.Context, data domain.EventDataParameter) error {
+	f.store.events = append(f.store.events, data)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	existing := domain.Warehouse{ID: 1, Name: "Jakarta", Latitude: -6.2, Longitude: 106.8, Version: 1}
+	changed := domain.WarehouseDataParameter{Name: "Bandung", Latitude: -6.9, Longitude: 107.6}
+
+	updated := existing
+	updated.Name = changed.Name
+	updated.Latitude = changed.Latitude
+	updated.Longitude = changed.Longitude
+	updated.Version = 2
+
+	created := domain.Warehouse{ID: 2, Name: changed.Name, Latitude: changed.Latitude, Longitude: changed.Longitude, Version: 1}
+
+	tests := []struct {
+		name       string
+		write      func(ctx context.Context, uc domain.WarehouseUsecase) error
+		failAudit  bool
+		wantErr    error
+		wantAction string
+		wantBefore interface{}
+		wantAfter  interface{}
+		// wantStored is the warehouse 1 holds afterwards, nil once deleted
+		wantStored *domain.Warehouse
+	}{
+		{
+			name: "create",
+			write: func(ctx context.Context, uc domain.WarehouseUsecase) error {
+				_, err := uc.Create(ctx, changed)
+				return err
+			},
+			wantAction: domain.AuditActionCreate,
+			wantAfter:  created.WarehouseResponse(),
+			wantStored: &existing,
+		},
+		{
+			name: "update",
+			write: func(ctx context.Context, uc domain.WarehouseUsecase) error {
+				_, err := uc.Update(ctx, 1, 1, changed)
+				return err
+			},
+			wantAction: domain.AuditActionUpdate,
+			wantBefore: existing.WarehouseResponse(),
+			wantAfter:  updated.WarehouseResponse(),
+			wantStored: &updated,
+		},
+		{
+			name: "delete",
+			write: func(ctx context.Context, uc domain.WarehouseUsecase) error {
+				_, err := uc.Delete(ctx, 1, 1)
+				return err
+			},
+			wantAction: domain.AuditActionDelete,
+			wantBefore: existing.WarehouseResponse(),
+		},
+		{
+			name: "failed audit rolls the update back",
+			write: func(ctx context.Context, uc domain.WarehouseUsecase) error {
+				_, err := uc.Update(ctx, 1, 1, changed)
+				return err
+			},
+			failAudit:  true,
+			wantErr:    errAuditFailed,
+			wantStored: &existing,
+		},
+		{
+			name: "failed audit rolls the delete back",
+			write: func(ctx context.Context, uc domain.WarehouseUsecase) error {
+				_, err := uc.Delete(ctx, 1, 1)
+				return err
+			},
+			failAudit:  true,
+			wantErr:    errAuditFailed,
+			wantStored: &existing,
+		},
+	}
+
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeStore{
+				warehouses: map[int64]domain.Warehouse{existing.ID: existing},
+				failAudit:  tt.failAudit,
+			}
+			uc := NewUsecase(logger, fakeWarehouses{store: store}, nil, fakeTx{store: store}, fakeAudit{store: store}, fakeOutbox{store: store})
+
+			err := tt.write(context.Background(), uc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+
+			stored, found := store.warehouses[existing.ID]
+			if tt.wantStored == nil && found {
+				t.Errorf("warehouse %d still stored", existing.ID)
+			}
+			if tt.wantStored != nil && stored != *tt.wantStored {
+				t.Errorf("stored = %+v, want %+v", stored, *tt.wantStored)
+			}
+
+			if tt.wantErr != nil {
+				if len(store.audits) > 0 || len(store.events) > 0 {
+					t.Errorf("rolled back write left %d audits and %d events", len(store.audits), len(store.events))
+				}
+				return
+			}
+
+			if len(store.audits) != 1 {
+				t.Fatalf("got %d audits, want 1", len(store.audits))
+			}
+			audit := store.audits[0]
+			if audit.EntityType != domain.AuditEntityWarehouse || audit.Action != tt.wantAction {
+				t.Errorf("audit = %s %s, want %s %s", audit.EntityType, audit.Action, domain.AuditEntityWarehouse, tt.wantAction)
+			}
+			if !reflect.DeepEqual(audit.Before, tt.wantBefore) {
+				t.Errorf("before = %+v, want %+v", audit.Before, tt.wantBefore)
+			}
+			if !reflect.DeepEqual(audit.After, tt.wantAfter) {
+				t.Errorf("after = %+v, want %+v", audit.After, tt.wantAfter)
+			}
+		})
+	}
+}
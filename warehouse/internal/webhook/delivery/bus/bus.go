@@ -0,0 +1,42 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/bus"
+	"github.com/sirupsen/logrus"
+)
+
+type busDelivery struct {
+	logger  *logrus.Logger
+	webhook domain.WebhookUsecase
+}
+
+// NewBusDelivery queues every event published on the bus for the matching
+// webhook subscriptions. The outbox dispatcher publishes inside its
+// transaction, so deliveries are queued together with marking the event
+// published.
+func NewBusDelivery(eventBus *bus.Bus, logger *logrus.Logger, webhook domain.WebhookUsecase) {
+	busInstance := &busDelivery{
+		logger:  logger,
+		webhook: webhook,
+	}
+
+	// Bind with given bus
+	eventBus.Subscribe("*", busInstance.Enqueue)
+}
+
+func (b *busDelivery) Enqueue(ctx context.Context, msg bus.Message) error {
+	var (
+		event domain.EventResponse
+	)
+
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		b.logger.Errorln(err)
+		return err
+	}
+
+	return b.webhook.Enqueue(ctx, event)
+}
@@ -0,0 +1,344 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+	"github.com/go-playground/validator"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+type httpDelivery struct {
+	logger    *logrus.Logger
+	webhook   domain.WebhookUsecase
+	validator *validator.Validate
+}
+
+func NewHTTPDelivery(router *mux.Router, logger *logrus.Logger, webhook domain.WebhookUsecase) {
+	httpInstance := &httpDelivery{
+		logger:    logger,
+		webhook:   webhook,
+		validator: validator.New(),
+	}
+
+	// Bind with given router
+	router.HandleFunc("/webhooks", httpInstance.Select).Methods("GET")
+	router.HandleFunc("/webhooks", httpInstance.Create).Methods("POST")
+	router.HandleFunc("/webhooks/dead-letters", httpInstance.DeadLetters).Methods("GET")
+	router.HandleFunc("/webhooks/deliveries/{id}/redeliver", httpInstance.Redeliver).Methods("POST")
+	router.HandleFunc("/webhooks/{id}", httpInstance.Get).Methods("GET")
+	router.HandleFunc("/webhooks/{id}", httpInstance.Update).Methods("PUT")
+	router.HandleFunc("/webhooks/{id}", httpInstance.Delete).Methods("DELETE")
+	router.HandleFunc("/webhooks/{id}/deliveries", httpInstance.Deliveries).Methods("GET")
+}
+
+func (h *httpDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	var (
+		subscriptionID int64
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		subscriptionID = id
+	}
+
+	response, err := h.webhook.Get(r.Context(), subscriptionID)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot find Webhook, Make sure you find correct Webhook")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) Select(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.WebhookQueryParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	responses, err := h.webhook.Select(r.Context(), queryParam)
+	if err != nil {
+		h.responseError(w, err, "Cannot Query Webhook")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var (
+		createData domain.WebhookSubscriptionDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&createData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.webhook.Create(r.Context(), createData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Creating Webhook")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Update(w http.ResponseWriter, r *http.Request) {
+	var (
+		subscriptionID int64
+		updateData     domain.WebhookSubscriptionDataParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		subscriptionID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	bodyData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Cannot Read Body")
+		return
+	}
+
+	if err := json.Unmarshal(bodyData, &updateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Unable to Unmarshal JSON")
+		return
+	}
+
+	if err := h.validator.Struct(&updateData); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Validation Failure, Try Again")
+		return
+	}
+
+	response, err := h.webhook.Update(r.Context(), subscriptionID, version, updateData)
+	if err != nil {
+		h.responseError(w, err, "An Error Occured When Updating Webhook")
+		return
+	}
+
+	httpcommon.SetETag(w, response.Version)
+	httpcommon.ResponseJSON(w, http.StatusCreated, response)
+}
+
+func (h *httpDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	var (
+		subscriptionID int64
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		subscriptionID = id
+	}
+
+	version, err := httpcommon.IfMatch(r)
+	if err != nil {
+		h.responseError(w, err, "Invalid If-Match")
+		return
+	}
+
+	if resp, err := h.webhook.Delete(r.Context(), subscriptionID, version); err != nil {
+		h.responseError(w, err, "Unable to Delete Webhook")
+	} else {
+		httpcommon.ResponseJSON(w, http.StatusCreated, resp)
+	}
+}
+
+// Deliveries is the delivery log of one subscription
+func (h *httpDelivery) Deliveries(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.WebhookDeliveryQueryParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		queryParam.SubscriptionID = []int64{id}
+	}
+
+	responses, err := h.webhook.SelectDeliveries(r.Context(), queryParam)
+	if err != nil {
+		h.responseError(w, err, "Cannot Query Webhook Deliveries")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+// DeadLetters lists deliveries of every subscription that ran out of attempts
+func (h *httpDelivery) DeadLetters(w http.ResponseWriter, r *http.Request) {
+	var (
+		queryParam domain.WebhookDeliveryQueryParameter
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	// Parse Query Parameter
+	if err := queryParam.Parse(r.URL.Query()); err != nil {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid Query")
+		return
+	}
+	queryParam.Status = []string{domain.WebhookDeliveryDead}
+
+	responses, err := h.webhook.SelectDeliveries(r.Context(), queryParam)
+	if err != nil {
+		h.responseError(w, err, "Cannot Query Webhook Dead Letters")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, responses)
+}
+
+func (h *httpDelivery) Redeliver(w http.ResponseWriter, r *http.Request) {
+	var (
+		deliveryID int64
+	)
+
+	if err := domain.Authorize(r.Context(), domain.PermWebhookManage, 0); err != nil {
+		h.responseError(w, err, "Not Allowed")
+		return
+	}
+
+	vars := mux.Vars(r)
+	if id, ok := vars["id"]; !ok {
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, "Invalid ID")
+		return
+	} else {
+		id, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			httpcommon.ResponseJSONError(w, http.StatusBadRequest, "ID Must be a number")
+			return
+		}
+		deliveryID = id
+	}
+
+	response, err := h.webhook.Redeliver(r.Context(), deliveryID)
+	if err != nil {
+		h.responseError(w, err, "Unable to Redeliver Webhook")
+		return
+	}
+
+	httpcommon.ResponseJSON(w, http.StatusOK, response)
+}
+
+func (h *httpDelivery) responseError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrUnauthenticated):
+		httpcommon.ResponseJSONError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		httpcommon.ResponseJSONError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrUnknownEventType):
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionRequired):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionRequired, err.Error())
+	case errors.Is(err, httpcommon.ErrPreconditionFailed),
+		errors.Is(err, domain.ErrVersionConflict):
+		httpcommon.ResponseJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, domain.ErrWebhookDeliveryConflict):
+		httpcommon.ResponseJSONError(w, http.StatusConflict, err.Error())
+	default:
+		h.logger.Errorln(err)
+		httpcommon.ResponseJSONError(w, http.StatusBadRequest, message)
+	}
+}
@@ -0,0 +1,471 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltx"
+)
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+var (
+	subscriptionColumns = []string{
+		"id",
+		"url",
+		"event_types",
+		"secret",
+		"active",
+		"version",
+		"created_by",
+		"created_at",
+		"updated_at",
+	}
+
+	deliveryColumns = []string{
+		"id",
+		"subscription_id",
+		"event_id",
+		"event_type",
+		"payload",
+		"status",
+		"attempts",
+		"response_code",
+		"last_error",
+		"next_attempt_at",
+		"delivered_at",
+		"created_at",
+		"updated_at",
+	}
+)
+
+func (wr *webhookRepository) Get(ctx context.Context, subscriptionID int64) (domain.WebhookSubscription, error) {
+	query, args, err := squirrel.Select(subscriptionColumns...).From("webhook_subscriptions").Where(
+		squirrel.Eq{"id": subscriptionID},
+	).ToSql()
+
+	if err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	query = wr.sql.Rebind(query)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	return scanSubscription(row)
+}
+
+func (wr *webhookRepository) Select(ctx context.Context, params domain.WebhookQueryParameter) ([]domain.WebhookSubscription, error) {
+	selector := squirrel.Select(subscriptionColumns...).From("webhook_subscriptions")
+	selector = params.BuildSQLQuery(selector)
+	return wr.selectSubscriptions(ctx, selector)
+}
+
+func (wr *webhookRepository) SelectActive(ctx context.Context) ([]domain.WebhookSubscription, error) {
+	selector := squirrel.Select(subscriptionColumns...).From("webhook_subscriptions").
+		Where(squirrel.Eq{"active": true}).
+		OrderBy("id")
+	return wr.selectSubscriptions(ctx, selector)
+}
+
+func (wr *webhookRepository) Create(ctx context.Context, data domain.WebhookSubscriptionDataParameter) (domain.WebhookSubscription, error) {
+	var (
+		subscriptionData domain.WebhookSubscription
+		t                = time.Now()
+	)
+
+	query, args, err := squirrel.Insert("webhook_subscriptions").Columns(
+		"url",
+		"event_types",
+		"secret",
+		"active",
+		"created_by",
+		"created_at",
+		"updated_at",
+	).Values(
+		data.URL,
+		strings.Join(data.EventTypes, ","),
+		data.Secret,
+		data.Active == nil || *data.Active,
+		data.CreatedBy,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		wr.logger.Errorln(err)
+		return subscriptionData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		wr.logger.Errorln(err)
+		return subscriptionData, err
+	}
+
+	lastInserted, err := result.LastInsertId()
+	if err != nil {
+		wr.logger.Errorln(err)
+		return subscriptionData, err
+	}
+
+	return wr.Get(ctx, lastInserted)
+}
+
+func (wr *webhookRepository) Update(ctx context.Context, subscriptionID, version int64, data domain.WebhookSubscriptionDataParameter) (domain.WebhookSubscription, error) {
+	updater := squirrel.Update("webhook_subscriptions").
+		Set("url", data.URL).
+		Set("event_types", strings.Join(data.EventTypes, ",")).
+		Set("version", squirrel.Expr("version + 1")).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": subscriptionID, "version": version})
+
+	// Leaving active out keeps the subscription as it was
+	if data.Active != nil {
+		updater = updater.Set("active", *data.Active)
+	}
+
+	query, args, err := updater.ToSql()
+	if err != nil {
+		wr.logger.Errorln(err)
+		return domain.WebhookSubscription{}, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		wr.logger.Errorln(err)
+		return domain.WebhookSubscription{}, err
+	}
+
+	if err := wr.checkVersion(ctx, result, subscriptionID); err != nil {
+		return domain.WebhookSubscription{}, err
+	}
+
+	return wr.Get(ctx, subscriptionID)
+}
+
+func (wr *webhookRepository) Delete(ctx context.Context, subscriptionID, version int64) error {
+	return sqltx.Run(ctx, wr.sql, func(ctx context.Context) error {
+		// The subscription goes first so a stale version leaves its
+		// deliveries alone
+		deleters := []squirrel.DeleteBuilder{
+			squirrel.Delete("webhook_subscriptions").Where(squirrel.Eq{"id": subscriptionID, "version": version}),
+			squirrel.Delete("webhook_deliveries").Where(squirrel.Eq{"subscription_id": subscriptionID}),
+		}
+
+		for i, deleter := range deleters {
+			query, args, err := deleter.ToSql()
+			if err != nil {
+				wr.logger.Errorln(err)
+				return err
+			}
+
+			query = wr.sql.Rebind(query)
+			result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+			if err != nil {
+				wr.logger.Errorln(err)
+				return err
+			}
+
+			if i == 0 {
+				if err := wr.checkVersion(ctx, result, subscriptionID); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (wr *webhookRepository) checkVersion(ctx context.Context, result sql.Result, subscriptionID int64) error {
+	return domain.CheckVersion(result, func() error {
+		_, err := wr.Get(ctx, subscriptionID)
+		return err
+	})
+}
+
+func (wr *webhookRepository) GetDelivery(ctx context.Context, deliveryID int64) (domain.WebhookDelivery, error) {
+	query, args, err := squirrel.Select(deliveryColumns...).From("webhook_deliveries").Where(
+		squirrel.Eq{"id": deliveryID},
+	).ToSql()
+
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	query = wr.sql.Rebind(query)
+	row := sqltx.From(ctx, wr.sql).QueryRow(query, args...)
+	if err := row.Err(); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return scanDelivery(row)
+}
+
+func (wr *webhookRepository) SelectDeliveries(ctx context.Context, params domain.WebhookDeliveryQueryParameter) ([]domain.WebhookDelivery, error) {
+	selector := squirrel.Select(deliveryColumns...).From("webhook_deliveries")
+	selector = params.BuildSQLQuery(selector)
+	return wr.selectDeliveries(ctx, selector)
+}
+
+func (wr *webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]domain.WebhookDelivery, error) {
+	var (
+		deliveriesData []domain.WebhookDelivery
+	)
+
+	// Rows stay locked only while they are claimed, never while being sent
+	err := sqltx.Run(ctx, wr.sql, func(ctx context.Context) error {
+		selector := squirrel.Select(deliveryColumns...).From("webhook_deliveries").
+			Where(squirrel.Eq{"status": domain.WebhookDeliveryPending}).
+			Where(squirrel.LtOrEq{"next_attempt_at": now}).
+			OrderBy("id").
+			Limit(uint64(limit)).
+			Suffix("FOR UPDATE SKIP LOCKED")
+
+		var err error
+		deliveriesData, err = wr.selectDeliveries(ctx, selector)
+		if err != nil || len(deliveriesData) < 1 {
+			return err
+		}
+
+		ids := make([]int64, 0, len(deliveriesData))
+		for _, delivery := range deliveriesData {
+			ids = append(ids, delivery.ID)
+		}
+
+		query, args, err := squirrel.Update("webhook_deliveries").
+			Set("next_attempt_at", now.Add(lease)).
+			Where(squirrel.Eq{"id": ids}).
+			ToSql()
+		if err != nil {
+			return err
+		}
+
+		query = wr.sql.Rebind(query)
+		_, err = sqltx.From(ctx, wr.sql).Exec(query, args...)
+		return err
+	})
+	if err != nil {
+		wr.logger.Errorln(err)
+		return nil, err
+	}
+
+	return deliveriesData, nil
+}
+
+func (wr *webhookRepository) CreateDelivery(ctx context.Context, data domain.WebhookDeliveryDataParameter) error {
+	t := time.Now()
+
+	query, args, err := squirrel.Insert("webhook_deliveries").Options("IGNORE").Columns(
+		"subscription_id",
+		"event_id",
+		"event_type",
+		"payload",
+		"status",
+		"next_attempt_at",
+		"created_at",
+		"updated_at",
+	).Values(
+		data.SubscriptionID,
+		data.EventID,
+		data.EventType,
+		string(data.Payload),
+		domain.WebhookDeliveryPending,
+		data.NextAttemptAt,
+		t, t,
+	).ToSql()
+
+	if err != nil {
+		wr.logger.Errorln(err)
+		return err
+	}
+
+	query = wr.sql.Rebind(query)
+	if _, err := sqltx.From(ctx, wr.sql).Exec(query, args...); err != nil {
+		wr.logger.Errorln(err)
+		return err
+	}
+
+	return nil
+}
+
+func (wr *webhookRepository) UpdateDelivery(ctx context.Context, deliveryID int64, attempts int, data domain.WebhookDeliveryAttempt) (domain.WebhookDelivery, error) {
+	var (
+		lastError sql.NullString
+	)
+
+	if len(data.LastError) > 0 {
+		lastError = sql.NullString{String: data.LastError, Valid: true}
+	}
+
+	query, args, err := squirrel.Update("webhook_deliveries").
+		Set("status", data.Status).
+		Set("attempts", data.Attempts).
+		Set("response_code", data.ResponseCode).
+		Set("last_error", lastError).
+		Set("next_attempt_at", data.NextAttemptAt).
+		Set("delivered_at", data.DeliveredAt).
+		Set("updated_at", time.Now()).
+		Where(squirrel.Eq{"id": deliveryID, "attempts": attempts}).
+		ToSql()
+
+	if err != nil {
+		wr.logger.Errorln(err)
+		return domain.WebhookDelivery{}, err
+	}
+
+	query = wr.sql.Rebind(query)
+	result, err := sqltx.From(ctx, wr.sql).Exec(query, args...)
+	if err != nil {
+		wr.logger.Errorln(err)
+		return domain.WebhookDelivery{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	deliveryData, err := wr.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return deliveryData, err
+	}
+	if affected < 1 {
+		return deliveryData, domain.ErrWebhookDeliveryConflict
+	}
+
+	return deliveryData, nil
+}
+
+func (wr *webhookRepository) selectSubscriptions(ctx context.Context, selector squirrel.SelectBuilder) ([]domain.WebhookSubscription, error) {
+	var (
+		subscriptionsData []domain.WebhookSubscription
+	)
+
+	query, args, err := selector.ToSql()
+	if err != nil {
+		return subscriptionsData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
+	if err != nil {
+		return subscriptionsData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscriptionData, err := scanSubscription(rows)
+		if err != nil {
+			return subscriptionsData, err
+		}
+
+		subscriptionsData = append(subscriptionsData, subscriptionData)
+	}
+
+	return subscriptionsData, nil
+}
+
+func (wr *webhookRepository) selectDeliveries(ctx context.Context, selector squirrel.SelectBuilder) ([]domain.WebhookDelivery, error) {
+	var (
+		deliveriesData []domain.WebhookDelivery
+	)
+
+	query, args, err := selector.ToSql()
+	if err != nil {
+		return deliveriesData, err
+	}
+
+	query = wr.sql.Rebind(query)
+	rows, err := sqltx.From(ctx, wr.sql).Query(query, args...)
+	if err != nil {
+		return deliveriesData, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		deliveryData, err := scanDelivery(rows)
+		if err != nil {
+			return deliveriesData, err
+		}
+
+		deliveriesData = append(deliveriesData, deliveryData)
+	}
+
+	return deliveriesData, nil
+}
+
+func scanSubscription(row scanner) (domain.WebhookSubscription, error) {
+	var (
+		subscriptionData domain.WebhookSubscription
+		eventTypes       string
+	)
+
+	err := row.Scan(
+		&subscriptionData.ID,
+		&subscriptionData.URL,
+		&eventTypes,
+		&subscriptionData.Secret,
+		&subscriptionData.Active,
+		&subscriptionData.Version,
+		&subscriptionData.CreatedBy,
+		&subscriptionData.CreatedAt,
+		&subscriptionData.UpdatedAt,
+	)
+	if err != nil {
+		return subscriptionData, err
+	}
+
+	if len(eventTypes) > 0 {
+		subscriptionData.EventTypes = strings.Split(eventTypes, ",")
+	}
+
+	return subscriptionData, nil
+}
+
+func scanDelivery(row scanner) (domain.WebhookDelivery, error) {
+	var (
+		deliveryData domain.WebhookDelivery
+		payload      string
+		lastError    sql.NullString
+		deliveredAt  sql.NullTime
+	)
+
+	err := row.Scan(
+		&deliveryData.ID,
+		&deliveryData.SubscriptionID,
+		&deliveryData.EventID,
+		&deliveryData.EventType,
+		&payload,
+		&deliveryData.Status,
+		&deliveryData.Attempts,
+		&deliveryData.ResponseCode,
+		&lastError,
+		&deliveryData.NextAttemptAt,
+		&deliveredAt,
+		&deliveryData.CreatedAt,
+		&deliveryData.UpdatedAt,
+	)
+	if err != nil {
+		return deliveryData, err
+	}
+
+	deliveryData.Payload = json.RawMessage(payload)
+	deliveryData.LastError = lastError.String
+	if deliveredAt.Valid {
+		deliveryData.DeliveredAt = &deliveredAt.Time
+	}
+
+	return deliveryData, nil
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+)
+
+const (
+	// Enough of a failed response to tell why it failed
+	maxErrorBodyBytes = 512
+)
+
+// send posts the event with its signature, any response but a 2xx fails the
+// delivery
+func (uc *webhookUsecase) send(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery, now time.Time) (int, error) {
+	if uc.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, uc.config.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(domain.WebhookEventHeader, delivery.EventType)
+	req.Header.Set(domain.WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(domain.WebhookTimestampHeader, timestamp)
+	req.Header.Set(domain.WebhookSignatureHeader, "sha256="+sign(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := uc.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return resp.StatusCode, fmt.Errorf("responded %d: %s", resp.StatusCode, body)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/gojektech/heimdall/v6"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	secretBytes = 32
+)
+
+type webhookUsecase struct {
+	logger  *logrus.Logger
+	config  domain.WebhookConfig
+	webhook domain.WebhookRepository
+	client  heimdall.Doer
+	backoff heimdall.Backoff
+}
+
+func NewUsecase(logger *logrus.Logger, cfg domain.WebhookConfig, webhook domain.WebhookRepository, client heimdall.Doer) domain.WebhookUsecase {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5 * time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 50
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 8
+	}
+	if cfg.Lease <= 0 {
+		// Deliveries of a batch are sent one after another
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		cfg.Lease = time.Duration(cfg.BatchSize) * timeout
+	}
+	if cfg.Backoff.Initial <= 0 {
+		cfg.Backoff.Initial = 10 * time.Second
+	}
+	if cfg.Backoff.Max <= 0 {
+		cfg.Backoff.Max = time.Hour
+	}
+	if cfg.Backoff.Factor < 1 {
+		cfg.Backoff.Factor = 2
+	}
+
+	return &webhookUsecase{
+		logger:  logger,
+		config:  cfg,
+		webhook: webhook,
+		client:  client,
+		backoff: heimdall.NewExponentialBackoff(cfg.Backoff.Initial, cfg.Backoff.Max, cfg.Backoff.Factor, cfg.Backoff.Jitter),
+	}
+}
+
+func (uc *webhookUsecase) Get(ctx context.Context, subscriptionID int64) (domain.WebhookSubscriptionResponse, error) {
+	subscriptionData, err := uc.webhook.Get(ctx, subscriptionID)
+	if err != nil {
+		return domain.WebhookSubscriptionResponse{}, err
+	}
+
+	return subscriptionData.WebhookSubscriptionResponse(), nil
+}
+
+func (uc *webhookUsecase) Select(ctx context.Context, params domain.WebhookQueryParameter) ([]domain.WebhookSubscriptionResponse, error) {
+	var (
+		subscriptionResponses = []domain.WebhookSubscriptionResponse{}
+	)
+
+	subscriptionsData, err := uc.webhook.Select(ctx, params)
+	if err != nil {
+		return subscriptionResponses, err
+	}
+
+	for _, subscription := range subscriptionsData {
+		subscriptionResponses = append(subscriptionResponses, subscription.WebhookSubscriptionResponse())
+	}
+
+	return subscriptionResponses, nil
+}
+
+func (uc *webhookUsecase) Create(ctx context.Context, data domain.WebhookSubscriptionDataParameter) (domain.WebhookSubscriptionCreatedResponse, error) {
+	var (
+		subscriptionResponse domain.WebhookSubscriptionCreatedResponse
+	)
+
+	if err := data.ValidateEventTypes(); err != nil {
+		return subscriptionResponse, err
+	}
+
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return subscriptionResponse, err
+	}
+	data.Secret = hex.EncodeToString(secret)
+	data.CreatedBy = domain.ActorFromContext(ctx)
+
+	subscriptionData, err := uc.webhook.Create(ctx, data)
+	if err != nil {
+		return subscriptionResponse, err
+	}
+
+	subscriptionResponse.WebhookSubscriptionResponse = subscriptionData.WebhookSubscriptionResponse()
+	subscriptionResponse.Secret = subscriptionData.Secret
+	return subscriptionResponse, nil
+}
+
+func (uc *webhookUsecase) Update(ctx context.Context, subscriptionID, version int64, data domain.WebhookSubscriptionDataParameter) (domain.WebhookSubscriptionResponse, error) {
+	if err := data.ValidateEventTypes(); err != nil {
+		return domain.WebhookSubscriptionResponse{}, err
+	}
+
+	if _, err := uc.webhook.Get(ctx, subscriptionID); err != nil {
+		return domain.WebhookSubscriptionResponse{}, err
+	}
+
+	subscriptionData, err := uc.webhook.Update(ctx, subscriptionID, version, data)
+	if err != nil {
+		return domain.WebhookSubscriptionResponse{}, err
+	}
+
+	return subscriptionData.WebhookSubscriptionResponse(), nil
+}
+
+func (uc *webhookUsecase) Delete(ctx context.Context, subscriptionID, version int64) (domain.GenericResponse, error) {
+	if _, err := uc.webhook.Get(ctx, subscriptionID); err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	if err := uc.webhook.Delete(ctx, subscriptionID, version); err != nil {
+		return domain.GenericResponse{}, err
+	}
+
+	return domain.GenericResponse{
+		Success: true,
+	}, nil
+}
+
+func (uc *webhookUsecase) SelectDeliveries(ctx context.Context, params domain.WebhookDeliveryQueryParameter) ([]domain.WebhookDeliveryResponse, error) {
+	var (
+		deliveryResponses = []domain.WebhookDeliveryResponse{}
+	)
+
+	deliveriesData, err := uc.webhook.SelectDeliveries(ctx, params)
+	if err != nil {
+		return deliveryResponses, err
+	}
+
+	for _, delivery := range deliveriesData {
+		deliveryResponses = append(deliveryResponses, delivery.WebhookDeliveryResponse())
+	}
+
+	return deliveryResponses, nil
+}
+
+func (uc *webhookUsecase) Redeliver(ctx context.Context, deliveryID int64) (domain.WebhookDeliveryResponse, error) {
+	deliveryData, err := uc.webhook.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDeliveryResponse{}, err
+	}
+
+	subscriptionData, err := uc.webhook.Get(ctx, deliveryData.SubscriptionID)
+	if err != nil {
+		return domain.WebhookDeliveryResponse{}, err
+	}
+
+	attempt := uc.attempt(ctx, subscriptionData, deliveryData)
+	// A failed redelivery is not retried, it stays in the dead letters
+	if attempt.Status == domain.WebhookDeliveryPending {
+		attempt.Status = domain.WebhookDeliveryDead
+	}
+
+	deliveryData, err = uc.webhook.UpdateDelivery(ctx, deliveryID, deliveryData.Attempts, attempt)
+	if err != nil {
+		return domain.WebhookDeliveryResponse{}, err
+	}
+
+	return deliveryData.WebhookDeliveryResponse(), nil
+}
+
+func (uc *webhookUsecase) Enqueue(ctx context.Context, event domain.EventResponse) error {
+	subscriptionsData, err := uc.webhook.SelectActive(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptionsData {
+		if !subscription.Matches(event.Type) {
+			continue
+		}
+
+		if err := uc.webhook.CreateDelivery(ctx, domain.WebhookDeliveryDataParameter{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+			NextAttemptAt:  time.Now(),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deliver claims due deliveries for a lease and sends them outside of any
+// transaction, a slow subscriber holds neither rows nor a connection. A
+// delivery whose lease ran out mid send may be sent twice, receivers tell
+// them apart by the delivery header.
+func (uc *webhookUsecase) Deliver(ctx context.Context) (int, error) {
+	var (
+		delivered int
+	)
+
+	deliveriesData, err := uc.webhook.ClaimDueDeliveries(ctx, time.Now(), uc.config.Lease, uc.config.BatchSize)
+	if err != nil {
+		return delivered, err
+	}
+
+	subscriptions := make(map[int64]domain.WebhookSubscription)
+	for _, delivery := range deliveriesData {
+		subscription, ok := subscriptions[delivery.SubscriptionID]
+		if !ok {
+			subscription, err = uc.webhook.Get(ctx, delivery.SubscriptionID)
+			if err != nil {
+				return delivered, err
+			}
+			subscriptions[delivery.SubscriptionID] = subscription
+		}
+
+		var attempt domain.WebhookDeliveryAttempt
+		if subscription.Active {
+			attempt = uc.attempt(ctx, subscription, delivery)
+		} else {
+			attempt = domain.WebhookDeliveryAttempt{
+				Status:        domain.WebhookDeliveryDead,
+				Attempts:      delivery.Attempts,
+				LastError:     "subscription is inactive",
+				NextAttemptAt: delivery.NextAttemptAt,
+			}
+		}
+
+		if _, err := uc.webhook.UpdateDelivery(ctx, delivery.ID, delivery.Attempts, attempt); err != nil {
+			if err == domain.ErrWebhookDeliveryConflict {
+				uc.logger.Warnf("webhook delivery %d was attempted again before its attempt %d was recorded", delivery.ID, attempt.Attempts)
+				continue
+			}
+			return delivered, err
+		}
+		if attempt.Status == domain.WebhookDeliveryDelivered {
+			delivered++
+		}
+	}
+
+	return delivered, nil
+}
+
+func (uc *webhookUsecase) Run(ctx context.Context) {
+	ticker := time.NewTicker(uc.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			delivered, err := uc.Deliver(ctx)
+			if err != nil {
+				uc.logger.Errorln(err)
+				continue
+			}
+			if delivered > 0 {
+				uc.logger.Debugf("webhooks delivered %d events", delivered)
+			}
+		}
+	}
+}
+
+// attempt sends the delivery once, failures are retried with an exponential
+// backoff until MaxAttempts and then go to the dead letters
+func (uc *webhookUsecase) attempt(ctx context.Context, subscription domain.WebhookSubscription, delivery domain.WebhookDelivery) domain.WebhookDeliveryAttempt {
+	now := time.Now()
+	attempt := domain.WebhookDeliveryAttempt{
+		Attempts:      delivery.Attempts + 1,
+		NextAttemptAt: delivery.NextAttemptAt,
+	}
+
+	code, err := uc.send(ctx, subscription, delivery, now)
+	attempt.ResponseCode = code
+	if err == nil {
+		attempt.Status = domain.WebhookDeliveryDelivered
+		attempt.DeliveredAt = &now
+		return attempt
+	}
+
+	uc.logger.Warnf("webhook delivery %d to subscription %d attempt %d failed: %v", delivery.ID, subscription.ID, attempt.Attempts, err)
+	attempt.LastError = err.Error()
+	if attempt.Attempts >= uc.config.MaxAttempts {
+		attempt.Status = domain.WebhookDeliveryDead
+		return attempt
+	}
+
+	attempt.Status = domain.WebhookDeliveryPending
+	attempt.NextAttemptAt = now.Add(uc.backoff.Next(attempt.Attempts - 1))
+	return attempt
+}
@@ -0,0 +1,99 @@
+package barcodegen
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	SymbologyCode128 = "code128"
+	SymbologyEAN13   = "ean13"
+	SymbologyQR      = "qr"
+)
+
+var (
+	ErrUnsupportedSymbology = errors.New("unsupported symbology")
+	ErrInvalidData          = errors.New("data cannot be encoded with this symbology")
+	ErrDataTooLong          = errors.New("data too long for this symbology")
+)
+
+// Symbol is an encoded barcode as a grid of modules, linear symbologies are
+// one module high and are stretched to the wanted bar height when drawn.
+type Symbol struct {
+	Symbology string
+	// Text is what should be printed in human readable form
+	Text      string
+	Width     int
+	Height    int
+	QuietZone int
+	modules   []bool
+}
+
+func newSymbol(symbology, text string, width, height, quietZone int) *Symbol {
+	return &Symbol{
+		Symbology: symbology,
+		Text:      text,
+		Width:     width,
+		Height:    height,
+		QuietZone: quietZone,
+		modules:   make([]bool, width*height),
+	}
+}
+
+func (s *Symbol) Dark(x, y int) bool {
+	if x < 0 || y < 0 || x >= s.Width || y >= s.Height {
+		return false
+	}
+	return s.modules[y*s.Width+x]
+}
+
+func (s *Symbol) set(x, y int, dark bool) {
+	s.modules[y*s.Width+x] = dark
+}
+
+func (s *Symbol) Linear() bool {
+	return s.Height == 1
+}
+
+// Bars groups a linear symbol into runs of dark modules as (start, width) pairs
+func (s *Symbol) Bars() [][2]int {
+	var bars [][2]int
+
+	for x := 0; x < s.Width; {
+		if !s.Dark(x, 0) {
+			x++
+			continue
+		}
+
+		start := x
+		for x < s.Width && s.Dark(x, 0) {
+			x++
+		}
+		bars = append(bars, [2]int{start, x - start})
+	}
+
+	return bars
+}
+
+// Encode builds a symbol for data using the named symbology
+func Encode(symbology, data string) (*Symbol, error) {
+	switch strings.ToLower(symbology) {
+	case SymbologyCode128:
+		return EncodeCode128(data)
+	case SymbologyEAN13:
+		return EncodeEAN13(data)
+	case SymbologyQR:
+		return EncodeQR(data)
+	}
+
+	return nil, ErrUnsupportedSymbology
+}
+
+func fromPattern(symbology, text, pattern string, quietZone int) *Symbol {
+	symbol := newSymbol(symbology, text, len(pattern), 1, quietZone)
+	for x, c := range pattern {
+		symbol.set(x, 0, c == '1')
+	}
+
+	return symbol
+}
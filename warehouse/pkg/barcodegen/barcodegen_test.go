@@ -0,0 +1,232 @@
+package barcodegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// code128Values reads a linear Code 128 symbol back into its values
+func code128Values(t *testing.T, symbol *Symbol) []int {
+	var (
+		values []int
+		widths strings.Builder
+	)
+
+	for x := 0; x < symbol.Width; {
+		start, dark := x, symbol.Dark(x, 0)
+		for x < symbol.Width && symbol.Dark(x, 0) == dark {
+			x++
+		}
+		widths.WriteByte(byte('0' + x - start))
+	}
+
+	pattern := widths.String()
+	for len(pattern) > 0 {
+		size := 6
+		if len(pattern) == 7 {
+			size = 7
+		}
+		value := -1
+		for v, p := range code128Patterns {
+			if p == pattern[:size] {
+				value = v
+				break
+			}
+		}
+		if value < 0 {
+			t.Fatalf("unknown pattern %s", pattern[:size])
+		}
+		values = append(values, value)
+		pattern = pattern[size:]
+	}
+
+	return values
+}
+
+func TestEAN13CheckDigit(t *testing.T) {
+	tests := []struct {
+		digits string
+		want   int
+	}{
+		{"400638133393", 1},
+		{"590123412345", 7},
+		{"012345678901", 2},
+		{"000000000000", 0},
+		{"4006381333931", 1},
+	}
+
+	for _, tt := range tests {
+		if got := EAN13CheckDigit(tt.digits); got != tt.want {
+			t.Errorf("EAN13CheckDigit(%s) = %d, want %d", tt.digits, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeEAN13(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantText string
+		wantErr  error
+	}{
+		{"completes check digit", "400638133393", "4006381333931", nil},
+		{"keeps valid check digit", "4006381333931", "4006381333931", nil},
+		{"wrong check digit", "4006381333932", "", ErrInvalidData},
+		{"too short", "12345", "", ErrInvalidData},
+		{"not digits", "40063813339A", "", ErrInvalidData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, err := EncodeEAN13(tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if symbol.Text != tt.wantText {
+				t.Errorf("text = %s, want %s", symbol.Text, tt.wantText)
+			}
+			// Guards, six left digits, the centre guard and six right digits
+			if symbol.Width != 95 || !symbol.Linear() {
+				t.Errorf("size = %dx%d, want 95x1", symbol.Width, symbol.Height)
+			}
+		})
+	}
+}
+
+func TestEncodeCode128(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []int
+		wantErr error
+	}{
+		{"code set B", "PJJ123C", []int{code128StartB, 48, 42, 42, 17, 18, 19, 35, 55, code128Stop}, nil},
+		{"code set C", "1234", []int{code128StartC, 12, 34, 82, code128Stop}, nil},
+		{"odd digits use code set B", "123", []int{code128StartB, 17, 18, 19, 8, code128Stop}, nil},
+		{"empty", "", nil, ErrInvalidData},
+		{"not printable", "A\tB", nil, ErrInvalidData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, err := EncodeCode128(tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			got := code128Values(t, symbol)
+			if len(got) != len(tt.want) {
+				t.Fatalf("values = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("values = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// Version 1-M codewords of HELLO WORLD in alphanumeric mode
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	got := reedSolomonRemainder(data, reedSolomonDivisor(len(want)))
+	if !bytes.Equal(got, want) {
+		t.Errorf("remainder = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	// Format information of level M for masks 0 to 7
+	formats := map[int]bool{
+		0x5412: true, 0x5125: true, 0x5E7C: true, 0x5B4B: true,
+		0x45F9: true, 0x40CE: true, 0x4F97: true, 0x4AA0: true,
+	}
+
+	tests := []struct {
+		name     string
+		data     string
+		wantSize int
+		wantErr  error
+	}{
+		{"version 1", "WH-01", 21, nil},
+		{"version 2", "WH-01-A-02-03-04-05-06-07", 25, nil},
+		{"version 10", strings.Repeat("x", 200), 57, nil},
+		{"too long", strings.Repeat("x", 300), 0, ErrDataTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol, err := EncodeQR(tt.data)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if symbol.Width != tt.wantSize || symbol.Height != tt.wantSize {
+				t.Fatalf("size = %dx%d, want %dx%d", symbol.Width, symbol.Height, tt.wantSize, tt.wantSize)
+			}
+
+			// Both copies of the format bits must agree and be valid for level M
+			var first, second int
+			for i := 0; i < 15; i++ {
+				var a, b bool
+				switch {
+				case i <= 5:
+					a = symbol.Dark(8, i)
+				case i == 6:
+					a = symbol.Dark(8, 7)
+				case i == 7:
+					a = symbol.Dark(8, 8)
+				case i == 8:
+					a = symbol.Dark(7, 8)
+				default:
+					a = symbol.Dark(14-i, 8)
+				}
+				if i < 8 {
+					b = symbol.Dark(tt.wantSize-1-i, 8)
+				} else {
+					b = symbol.Dark(8, tt.wantSize-15+i)
+				}
+				if a {
+					first |= 1 << uint(i)
+				}
+				if b {
+					second |= 1 << uint(i)
+				}
+			}
+			if first != second || !formats[first] {
+				t.Errorf("format bits = %#x and %#x", first, second)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		symbology string
+		data      string
+		wantErr   error
+	}{
+		{"QR", "WH-01", nil},
+		{"code128", "WH-01", nil},
+		{"ean13", "400638133393", nil},
+		{"pdf417", "WH-01", ErrUnsupportedSymbology},
+	}
+
+	for _, tt := range tests {
+		if _, err := Encode(tt.symbology, tt.data); err != tt.wantErr {
+			t.Errorf("Encode(%s) err = %v, want %v", tt.symbology, err, tt.wantErr)
+		}
+	}
+}
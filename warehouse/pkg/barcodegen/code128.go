@@ -0,0 +1,80 @@
+package barcodegen
+
+import (
+	"strings"
+)
+
+const (
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// Bar and space widths of every Code 128 value, the last entry is the stop
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// EncodeCode128 uses code set C for purely numeric data of even length, which
+// halves the symbol width, and code set B for any other printable ASCII.
+func EncodeCode128(data string) (*Symbol, error) {
+	var values []int
+
+	if len(data) < 1 {
+		return nil, ErrInvalidData
+	}
+
+	if isDigits(data) && len(data)%2 == 0 {
+		values = append(values, code128StartC)
+		for i := 0; i < len(data); i += 2 {
+			values = append(values, int(data[i]-'0')*10+int(data[i+1]-'0'))
+		}
+	} else {
+		values = append(values, code128StartB)
+		for i := 0; i < len(data); i++ {
+			c := data[i]
+			if c < 32 || c > 127 {
+				return nil, ErrInvalidData
+			}
+			values = append(values, int(c)-32)
+		}
+	}
+
+	checksum := values[0]
+	for i, v := range values[1:] {
+		checksum += (i + 1) * v
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var pattern strings.Builder
+	for _, v := range values {
+		for i, w := range code128Patterns[v] {
+			module := "1"
+			if i%2 == 1 {
+				module = "0"
+			}
+			pattern.WriteString(strings.Repeat(module, int(w-'0')))
+		}
+	}
+
+	return fromPattern(SymbologyCode128, data, pattern.String(), 10), nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
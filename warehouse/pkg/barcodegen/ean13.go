@@ -0,0 +1,64 @@
+package barcodegen
+
+import (
+	"strings"
+)
+
+var (
+	ean13L = []string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	ean13G = []string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	ean13R = []string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+
+	// The first digit is not drawn, it is carried by the L/G parity of the left half
+	ean13Parity = []string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EAN13CheckDigit computes the check digit of the first 12 digits
+func EAN13CheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < 12 && i < len(digits); i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return (10 - sum%10) % 10
+}
+
+// EncodeEAN13 accepts 12 digits, completing the check digit, or 13 digits
+// whose check digit must be correct.
+func EncodeEAN13(data string) (*Symbol, error) {
+	if !isDigits(data) || (len(data) != 12 && len(data) != 13) {
+		return nil, ErrInvalidData
+	}
+
+	check := EAN13CheckDigit(data)
+	if len(data) == 13 && int(data[12]-'0') != check {
+		return nil, ErrInvalidData
+	}
+	if len(data) == 12 {
+		data += string(rune('0' + check))
+	}
+
+	var pattern strings.Builder
+	parity := ean13Parity[data[0]-'0']
+
+	pattern.WriteString("101")
+	for i := 1; i <= 6; i++ {
+		d := data[i] - '0'
+		if parity[i-1] == 'L' {
+			pattern.WriteString(ean13L[d])
+		} else {
+			pattern.WriteString(ean13G[d])
+		}
+	}
+	pattern.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		pattern.WriteString(ean13R[data[i]-'0'])
+	}
+	pattern.WriteString("101")
+
+	return fromPattern(SymbologyEAN13, data, pattern.String(), 11), nil
+}
@@ -0,0 +1,479 @@
+package barcodegen
+
+// QR codes are generated in byte mode with error correction level M, versions
+// 1 to 10 are enough for anything that fits on a warehouse label.
+
+type qrVersion struct {
+	ecPerBlock int
+	// Data codewords of each block, short blocks come first
+	blocks    []int
+	alignment []int
+}
+
+var qrVersions = []qrVersion{
+	{},
+	{ecPerBlock: 10, blocks: []int{16}},
+	{ecPerBlock: 16, blocks: []int{28}, alignment: []int{6, 18}},
+	{ecPerBlock: 26, blocks: []int{44}, alignment: []int{6, 22}},
+	{ecPerBlock: 18, blocks: []int{32, 32}, alignment: []int{6, 26}},
+	{ecPerBlock: 24, blocks: []int{43, 43}, alignment: []int{6, 30}},
+	{ecPerBlock: 16, blocks: []int{27, 27, 27, 27}, alignment: []int{6, 34}},
+	{ecPerBlock: 18, blocks: []int{31, 31, 31, 31}, alignment: []int{6, 22, 38}},
+	{ecPerBlock: 22, blocks: []int{38, 38, 39, 39}, alignment: []int{6, 24, 42}},
+	{ecPerBlock: 22, blocks: []int{36, 36, 36, 37, 37}, alignment: []int{6, 26, 46}},
+	{ecPerBlock: 26, blocks: []int{43, 43, 43, 43, 44}, alignment: []int{6, 28, 50}},
+}
+
+const (
+	qrMaxVersion = 10
+	// Error correction level M is encoded as 00 in the format bits
+	qrFormatLevelM = 0
+)
+
+type qrCode struct {
+	*Symbol
+	function []bool
+}
+
+func (v qrVersion) dataCodewords() int {
+	total := 0
+	for _, b := range v.blocks {
+		total += b
+	}
+	return total
+}
+
+// EncodeQR picks the smallest version that fits data and the mask with the
+// lowest penalty, as the specification recommends.
+func EncodeQR(data string) (*Symbol, error) {
+	version := 0
+	for v := 1; v <= qrMaxVersion; v++ {
+		if qrDataBits(v, len(data)) <= qrVersions[v].dataCodewords()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrDataTooLong
+	}
+
+	size := version*4 + 17
+	qr := &qrCode{
+		Symbol:   newSymbol(SymbologyQR, data, size, size, 4),
+		function: make([]bool, size*size),
+	}
+
+	qr.drawFunctionPatterns(version)
+	qr.drawCodewords(qrInterleave(version, qrDataCodewords(version, []byte(data))))
+
+	bestMask, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		qr.applyMask(mask)
+		qr.drawFormatBits(mask)
+		penalty := qr.penalty()
+		if bestPenalty < 0 || penalty < bestPenalty {
+			bestMask, bestPenalty = mask, penalty
+		}
+		// Masks are XOR, applying it again restores the data
+		qr.applyMask(mask)
+	}
+
+	qr.applyMask(bestMask)
+	qr.drawFormatBits(bestMask)
+
+	return qr.Symbol, nil
+}
+
+func qrDataBits(version, length int) int {
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	return 4 + countBits + length*8
+}
+
+func qrDataCodewords(version int, data []byte) []byte {
+	var (
+		capacity = qrVersions[version].dataCodewords() * 8
+		bits     []bool
+	)
+
+	appendBits := func(value, length int) {
+		for i := length - 1; i >= 0; i-- {
+			bits = append(bits, (value>>uint(i))&1 == 1)
+		}
+	}
+
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+
+	// Byte mode indicator, character count and the data itself
+	appendBits(0x4, 4)
+	appendBits(len(data), countBits)
+	for _, b := range data {
+		appendBits(int(b), 8)
+	}
+
+	// Terminator, then pad to a whole byte
+	terminator := capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	appendBits(0, terminator)
+	if len(bits)%8 != 0 {
+		appendBits(0, 8-len(bits)%8)
+	}
+
+	codewords := make([]byte, 0, capacity/8)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << uint(7-j)
+			}
+		}
+		codewords = append(codewords, b)
+	}
+
+	// Fill the remaining capacity with the alternating pad bytes
+	for pad := byte(0xEC); len(codewords) < capacity/8; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+
+	return codewords
+}
+
+func qrInterleave(version int, data []byte) []byte {
+	var (
+		info     = qrVersions[version]
+		divisor  = reedSolomonDivisor(info.ecPerBlock)
+		blocks   [][]byte
+		ecBlocks [][]byte
+		result   []byte
+		offset   int
+		maxBlock int
+	)
+
+	for _, length := range info.blocks {
+		block := data[offset : offset+length]
+		offset += length
+
+		blocks = append(blocks, block)
+		ecBlocks = append(ecBlocks, reedSolomonRemainder(block, divisor))
+		if length > maxBlock {
+			maxBlock = length
+		}
+	}
+
+	for i := 0; i < maxBlock; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < info.ecPerBlock; i++ {
+		for _, ec := range ecBlocks {
+			result = append(result, ec[i])
+		}
+	}
+
+	return result
+}
+
+func (qr *qrCode) setFunction(x, y int, dark bool) {
+	qr.set(x, y, dark)
+	qr.function[y*qr.Width+x] = true
+}
+
+func (qr *qrCode) drawFunctionPatterns(version int) {
+	size := qr.Width
+
+	// Timing patterns
+	for i := 0; i < size; i++ {
+		qr.setFunction(6, i, i%2 == 0)
+		qr.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns with their separators
+	for _, center := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x < 0 || y < 0 || x >= size || y >= size {
+					continue
+				}
+				distance := maxInt(absInt(dx), absInt(dy))
+				qr.setFunction(x, y, distance != 2 && distance != 4)
+			}
+		}
+	}
+
+	// Alignment patterns, except where they would overlap a finder
+	positions := qrVersions[version].alignment
+	last := len(positions) - 1
+	for i, cy := range positions {
+		for j, cx := range positions {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					qr.setFunction(cx+dx, cy+dy, maxInt(absInt(dx), absInt(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// Reserve the format areas, the real bits are drawn once a mask is chosen
+	qr.drawFormatBits(0)
+
+	if version >= 7 {
+		remainder := version
+		for i := 0; i < 12; i++ {
+			remainder = (remainder << 1) ^ ((remainder >> 11) * 0x1F25)
+		}
+		bits := version<<12 | remainder
+
+		for i := 0; i < 18; i++ {
+			dark := (bits>>uint(i))&1 == 1
+			a, b := size-11+i%3, i/3
+			qr.setFunction(a, b, dark)
+			qr.setFunction(b, a, dark)
+		}
+	}
+}
+
+func (qr *qrCode) drawFormatBits(mask int) {
+	size := qr.Width
+
+	data := qrFormatLevelM<<3 | mask
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = (remainder << 1) ^ ((remainder >> 9) * 0x537)
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool {
+		return (bits>>uint(i))&1 == 1
+	}
+
+	// Copy around the top left finder
+	for i := 0; i <= 5; i++ {
+		qr.setFunction(8, i, bit(i))
+	}
+	qr.setFunction(8, 7, bit(6))
+	qr.setFunction(8, 8, bit(7))
+	qr.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		qr.setFunction(14-i, 8, bit(i))
+	}
+
+	// Second copy split between the other two finders
+	for i := 0; i < 8; i++ {
+		qr.setFunction(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		qr.setFunction(8, size-15+i, bit(i))
+	}
+	qr.setFunction(8, size-8, true)
+}
+
+func (qr *qrCode) drawCodewords(codewords []byte) {
+	size := qr.Width
+	i := 0
+
+	// Zig-zag in two module wide columns from the bottom right, skipping timing
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+
+				if qr.function[y*size+x] || i >= len(codewords)*8 {
+					continue
+				}
+				qr.set(x, y, (codewords[i>>3]>>uint(7-i&7))&1 == 1)
+				i++
+			}
+		}
+	}
+}
+
+func (qr *qrCode) applyMask(mask int) {
+	size := qr.Width
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qr.function[y*size+x] {
+				continue
+			}
+
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+
+			if invert {
+				qr.set(x, y, !qr.Dark(x, y))
+			}
+		}
+	}
+}
+
+// penalty scores a masked symbol with the four rules of the specification
+func (qr *qrCode) penalty() int {
+	var (
+		size    = qr.Width
+		penalty = 0
+		dark    = 0
+	)
+
+	line := func(get func(i int) bool) {
+		run := 1
+		for i := 1; i <= size; i++ {
+			if i < size && get(i) == get(i-1) {
+				run++
+				continue
+			}
+			if run >= 5 {
+				penalty += 3 + run - 5
+			}
+			run = 1
+		}
+
+		// Finder like 1:1:3:1:1 patterns with four light modules on a side
+		pattern := []bool{true, false, true, true, true, false, true}
+		for i := 0; i+7 <= size; i++ {
+			match := true
+			for k, p := range pattern {
+				if get(i+k) != p {
+					match = false
+					break
+				}
+			}
+			if !match {
+				continue
+			}
+
+			lightBefore, lightAfter := true, true
+			for k := 1; k <= 4; k++ {
+				if i-k >= 0 && get(i-k) {
+					lightBefore = false
+				}
+				if i+6+k < size && get(i+6+k) {
+					lightAfter = false
+				}
+			}
+			if lightBefore || lightAfter {
+				penalty += 40
+			}
+		}
+	}
+
+	for y := 0; y < size; y++ {
+		y := y
+		line(func(i int) bool { return qr.Dark(i, y) })
+	}
+	for x := 0; x < size; x++ {
+		x := x
+		line(func(i int) bool { return qr.Dark(x, i) })
+	}
+
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if qr.Dark(x, y) {
+				dark++
+			}
+			if x+1 < size && y+1 < size {
+				c := qr.Dark(x, y)
+				if c == qr.Dark(x+1, y) && c == qr.Dark(x, y+1) && c == qr.Dark(x+1, y+1) {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	percent := dark * 100 / (size * size)
+	penalty += absInt(percent-50) / 5 * 10
+
+	return penalty
+}
+
+func reedSolomonDivisor(degree int) []byte {
+	divisor := make([]byte, degree)
+	divisor[degree-1] = 1
+
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range divisor {
+			divisor[j] = gfMultiply(divisor[j], root)
+			if j+1 < len(divisor) {
+				divisor[j] ^= divisor[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+
+	return divisor
+}
+
+func reedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range divisor {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+
+	return result
+}
+
+// gfMultiply multiplies in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z int
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+
+	return byte(z)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
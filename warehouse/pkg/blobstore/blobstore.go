@@ -0,0 +1,91 @@
+package blobstore
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	ErrInvalidKey = errors.New("invalid blob key")
+	ErrNotFound   = errors.New("blob not found")
+)
+
+type Config struct {
+	Enabled bool
+	Dir     string
+}
+
+// Store keeps opaque blobs by key, keys may contain "/" to group blobs
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+}
+
+type localStore struct {
+	dir string
+}
+
+// NewLocal stores blobs as plain files below dir, creating it when missing
+func NewLocal(dir string) (Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+
+	return &localStore{
+		dir: dir,
+	}, nil
+}
+
+func (s *localStore) Put(key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	// Write aside and rename so readers never see half written blobs
+	tempFile, err := ioutil.TempFile(filepath.Dir(path), ".blob")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tempFile.Name(), path)
+}
+
+func (s *localStore) Get(key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+func (s *localStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || strings.Contains(key, "..") || clean == "/" {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.dir, clean), nil
+}
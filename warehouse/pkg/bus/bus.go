@@ -0,0 +1,94 @@
+package bus
+
+import (
+	"context"
+	"path"
+	"sync"
+)
+
+// Message is what sinks publish, it maps onto a Kafka record or a NATS
+// message: the topic is the subject, the key picks the partition.
+type Message struct {
+	Topic   string
+	Key     string
+	Value   []byte
+	Headers map[string]string
+}
+
+// Publisher is a sink for messages, a broker client only needs to implement
+// this to receive outbox events
+type Publisher interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+type Handler func(ctx context.Context, msg Message) error
+
+type subscription struct {
+	pattern string
+	handler Handler
+}
+
+// Bus delivers messages to handlers in the same process, in the order they
+// subscribed. Patterns use path.Match, "bin.*" receives every bin event.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+func New() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(pattern string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions = append(b.subscriptions, subscription{pattern: pattern, handler: handler})
+}
+
+// Publish stops at the first handler that fails, so the message is retried
+func (b *Bus) Publish(ctx context.Context, msg Message) error {
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if ok, _ := path.Match(sub.pattern, msg.Topic); !ok {
+			continue
+		}
+		if err := sub.handler(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Memory stands in for Kafka or NATS in tests, it keeps every message it is
+// given and is not meant for long running processes
+type Memory struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// Messages returns what was published so far, oldest first
+func (m *Memory) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := make([]Message, len(m.messages))
+	copy(messages, m.messages)
+	return messages
+}
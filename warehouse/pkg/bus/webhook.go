@@ -0,0 +1,50 @@
+package bus
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gojektech/heimdall/v6"
+)
+
+// Webhook posts the value of every message to a URL, anything but a 2xx
+// response is a failed publish
+type Webhook struct {
+	client heimdall.Doer
+	url    string
+}
+
+func NewWebhook(client heimdall.Doer, url string) *Webhook {
+	return &Webhook{
+		client: client,
+		url:    url,
+	}
+}
+
+func (w *Webhook) Publish(ctx context.Context, msg Message) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(msg.Value))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Topic", msg.Topic)
+	req.Header.Set("X-Event-Key", msg.Key)
+	for k, v := range msg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s responded %d", w.url, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator"
+	"github.com/spf13/viper"
+)
+
+const (
+	// Fields tagged secret:"true" are masked by Redact
+	secretTag  = "secret"
+	secretMask = "******"
+
+	// FOO_FILE names a file holding the value of FOO, as mounted by Docker
+	// and Kubernetes secrets
+	fileSuffix = "_FILE"
+)
+
+// Errors lists every invalid field of a config, not just the first one
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid config: " + strings.Join(e, "; ")
+}
+
+// Load reads the config file when there is one, then overrides it with the
+// environment and secret files. Every field of out can be set from
+// <PREFIX>_<PATH>, e.g. WAREHOUSE_SQL_PASSWORD for SQL.Password, or read from
+// the file named by WAREHOUSE_SQL_PASSWORD_FILE.
+func Load(v *viper.Viper, prefix string, out interface{}) error {
+	if err := v.ReadInConfig(); err != nil {
+		// Running from the environment alone is fine
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+
+	replacer := strings.NewReplacer(".", "_")
+	for _, key := range Keys(out) {
+		env := strings.ToUpper(prefix + "_" + replacer.Replace(key))
+		if err := v.BindEnv(key, env); err != nil {
+			return err
+		}
+
+		if path, ok := os.LookupEnv(env + fileSuffix); ok {
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("cannot read %s%s: %w", env, fileSuffix, err)
+			}
+			v.Set(key, strings.TrimSpace(string(content)))
+		}
+	}
+
+	return v.Unmarshal(out)
+}
+
+// Keys returns the dotted path of every leaf field of a config struct
+func Keys(cfg interface{}) []string {
+	return keys(reflect.TypeOf(cfg), "")
+}
+
+func keys(t reflect.Type, prefix string) []string {
+	var (
+		result []string
+	)
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+
+		key := prefix + field.Name
+		if field.Type.Kind() == reflect.Struct {
+			result = append(result, keys(field.Type, key+".")...)
+			continue
+		}
+		result = append(result, key)
+	}
+
+	return result
+}
+
+// Validator is implemented by config structs with rules the validate tags
+// cannot express, such as a field required by a field of its parent
+type Validator interface {
+	Validate() error
+}
+
+// Validate checks the validate tags of a config, then the Validate method of
+// every struct in it implementing Validator, and reports every failure
+func Validate(cfg interface{}) error {
+	var (
+		result Errors
+	)
+
+	err := validator.New().Struct(cfg)
+	if err != nil {
+		var fieldErrors validator.ValidationErrors
+		if !errors.As(err, &fieldErrors) {
+			return err
+		}
+		result = append(result, fieldMessages(fieldErrors)...)
+	}
+
+	result = append(result, validateStructs(reflect.ValueOf(cfg), "")...)
+	if len(result) > 0 {
+		return result
+	}
+
+	return nil
+}
+
+func fieldMessages(fieldErrors validator.ValidationErrors) []string {
+	var (
+		result []string
+	)
+
+	for _, fieldError := range fieldErrors {
+		// Namespace starts with the root type name, which means nothing to
+		// whoever sets the environment
+		field := fieldError.Namespace()
+		if i := strings.Index(field, "."); i >= 0 {
+			field = field[i+1:]
+		}
+
+		rule := fieldError.Tag()
+		if len(fieldError.Param()) > 0 {
+			rule += "=" + fieldError.Param()
+		}
+		result = append(result, field+" "+rule)
+	}
+
+	return result
+}
+
+func validateStructs(v reflect.Value, prefix string) []string {
+	var (
+		result []string
+	)
+
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return result
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return result
+	}
+
+	if checker, ok := v.Interface().(Validator); ok {
+		if err := checker.Validate(); err != nil {
+			message := err.Error()
+			if len(prefix) > 0 {
+				message = prefix + "." + message
+			}
+			result = append(result, message)
+		}
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		path := field.Name
+		if len(prefix) > 0 {
+			path = prefix + "." + path
+		}
+		result = append(result, validateStructs(v.Field(i), path)...)
+	}
+
+	return result
+}
+
+// Redact returns a copy of a config with its secrets masked, safe to log or
+// print. Empty secrets stay empty so a missing one is still visible.
+func Redact(cfg interface{}) interface{} {
+	v := reflect.ValueOf(cfg)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	redacted := reflect.New(v.Type()).Elem()
+	redacted.Set(v)
+	redact(redacted)
+	return redacted.Interface()
+}
+
+func redact(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if len(field.PkgPath) > 0 {
+				continue
+			}
+
+			if field.Tag.Get(secretTag) == "true" && v.Field(i).Kind() == reflect.String {
+				if v.Field(i).Len() > 0 {
+					v.Field(i).SetString(secretMask)
+				}
+				continue
+			}
+			redact(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+
+		// The copy shares its backing array with the original
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		for i := 0; i < copied.Len(); i++ {
+			redact(copied.Index(i))
+		}
+		v.Set(copied)
+	}
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/internal/domain"
+	"github.com/spf13/viper"
+)
+
+type testSQLConfig struct {
+	Host     string `validate:"required"`
+	Port     int    `validate:"min=1"`
+	Password string `secret:"true"`
+}
+
+type testHookConfig struct {
+	Primary string
+	Replica string
+}
+
+func (hc testHookConfig) Validate() error {
+	if len(hc.Replica) > 0 && len(hc.Primary) < 1 {
+		return errors.New("Primary is required with Replica")
+	}
+	return nil
+}
+
+type testConfig struct {
+	Name    string
+	Timeout time.Duration
+	SQL     testSQLConfig
+	Auth    domain.AuthConfig
+	Hook    testHookConfig
+	Tokens  []testSQLConfig
+	ignored string
+}
+
+func setenv(t *testing.T, key, value string) {
+	previous, existed := os.LookupEnv(key)
+	os.Setenv(key, value)
+	t.Cleanup(func() {
+		if existed {
+			os.Setenv(key, previous)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+}
+
+func TestKeys(t *testing.T) {
+	want := []string{
+		"Name",
+		"Timeout",
+		"SQL.Host",
+		"SQL.Port",
+		"SQL.Password",
+		"Auth.Enabled",
+		"Auth.JWT.Secret",
+		"Auth.JWT.Issuer",
+		"Auth.JWT.Audience",
+		"Auth.JWT.Leeway",
+		"Auth.PublicPaths",
+		"Hook.Primary",
+		"Hook.Replica",
+		"Tokens",
+	}
+
+	if got := Keys(&testConfig{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("Keys = %v, want %v", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	if err := ioutil.WriteFile(secretFile, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    testConfig
+		wantErr bool
+	}{
+		{
+			name: "environment",
+			env: map[string]string{
+				"TEST_NAME":            "warehouse",
+				"TEST_TIMEOUT":         "5s",
+				"TEST_SQL_HOST":        "db",
+				"TEST_SQL_PORT":        "5432",
+				"TEST_AUTH_JWT_SECRET": "from-env",
+			},
+			want: testConfig{
+				Name:    "warehouse",
+				Timeout: 5 * time.Second,
+				SQL:     testSQLConfig{Host: "db", Port: 5432},
+				Auth:    domain.AuthConfig{JWT: domain.JWTConfig{Secret: "from-env"}},
+			},
+		},
+		{
+			name: "secret file wins over the variable",
+			env: map[string]string{
+				"TEST_SQL_PASSWORD":      "from-env",
+				"TEST_SQL_PASSWORD_FILE": secretFile,
+			},
+			want: testConfig{
+				SQL: testSQLConfig{Password: "from-file"},
+			},
+		},
+		{
+			name: "missing secret file",
+			env: map[string]string{
+				"TEST_SQL_PASSWORD_FILE": filepath.Join(t.TempDir(), "missing"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				setenv(t, key, value)
+			}
+
+			var got testConfig
+			err := Load(viper.New(), "test", &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("config = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := testConfig{SQL: testSQLConfig{Host: "db", Port: 5432}}
+
+	tests := []struct {
+		name   string
+		modify func(*testConfig)
+		want   Errors
+	}{
+		{"valid", func(*testConfig) {}, nil},
+		{"tags", func(c *testConfig) { c.SQL = testSQLConfig{} }, Errors{"SQL.Host required", "SQL.Port min=1"}},
+		{
+			name: "auth without secret",
+			modify: func(c *testConfig) {
+				c.Auth.Enabled = true
+			},
+			want: Errors{"Auth.JWT.Secret must be at least 32 bytes when Enabled"},
+		},
+		{
+			name: "auth with short secret",
+			modify: func(c *testConfig) {
+				c.Auth = domain.AuthConfig{Enabled: true, JWT: domain.JWTConfig{Secret: "short"}}
+			},
+			want: Errors{"Auth.JWT.Secret must be at least 32 bytes when Enabled"},
+		},
+		{
+			name: "auth with secret",
+			modify: func(c *testConfig) {
+				c.Auth = domain.AuthConfig{Enabled: true, JWT: domain.JWTConfig{Secret: "0123456789abcdef0123456789abcdef"}}
+			},
+		},
+		{
+			name: "every failure is reported",
+			modify: func(c *testConfig) {
+				c.SQL.Port = 0
+				c.Auth.Enabled = true
+				c.Hook.Replica = "replica"
+			},
+			want: Errors{
+				"SQL.Port min=1",
+				"Auth.JWT.Secret must be at least 32 bytes when Enabled",
+				"Hook.Primary is required with Replica",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := valid
+			tt.modify(&cfg)
+
+			err := Validate(&cfg)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("err = %v", err)
+				}
+				return
+			}
+
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("errors = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	cfg := testConfig{
+		Name: "warehouse",
+		SQL:  testSQLConfig{Host: "db", Password: "hunter2"},
+		Auth: domain.AuthConfig{JWT: domain.JWTConfig{Issuer: "auth"}},
+		Tokens: []testSQLConfig{
+			{Host: "a", Password: "one"},
+			{Host: "b"},
+		},
+	}
+
+	redacted, ok := Redact(&cfg).(testConfig)
+	if !ok {
+		t.Fatalf("Redact returned %T", Redact(&cfg))
+	}
+
+	want := testConfig{
+		Name: "warehouse",
+		SQL:  testSQLConfig{Host: "db", Password: secretMask},
+		// An empty secret stays empty so a missing one can be seen
+		Auth: domain.AuthConfig{JWT: domain.JWTConfig{Issuer: "auth"}},
+		Tokens: []testSQLConfig{
+			{Host: "a", Password: secretMask},
+			{Host: "b"},
+		},
+	}
+	if !reflect.DeepEqual(redacted, want) {
+		t.Errorf("redacted = %+v, want %+v", redacted, want)
+	}
+
+	// The original keeps its secrets, including those in slices
+	if cfg.SQL.Password != "hunter2" || cfg.Tokens[0].Password != "one" {
+		t.Errorf("original was changed: %+v", cfg)
+	}
+}
@@ -0,0 +1,78 @@
+package gs1
+
+type definition struct {
+	title string
+	// Digits of the AI itself, 31xx style AIs carry a decimal point position
+	aiLength   int
+	fixed      bool
+	length     int
+	numeric    bool
+	date       bool
+	checkDigit bool
+}
+
+// Application identifiers seen on logistics and retail labels, keyed by the
+// prefix that identifies them.
+var definitions = map[string]definition{
+	"00": {title: "SSCC", aiLength: 2, fixed: true, length: 18, numeric: true, checkDigit: true},
+	"01": {title: "GTIN", aiLength: 2, fixed: true, length: 14, numeric: true, checkDigit: true},
+	"02": {title: "CONTENT", aiLength: 2, fixed: true, length: 14, numeric: true, checkDigit: true},
+	"10": {title: "BATCH/LOT", aiLength: 2, length: 20},
+	"11": {title: "PROD DATE", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"12": {title: "DUE DATE", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"13": {title: "PACK DATE", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"15": {title: "BEST BEFORE", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"16": {title: "SELL BY", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"17": {title: "USE BY", aiLength: 2, fixed: true, length: 6, numeric: true, date: true},
+	"20": {title: "VARIANT", aiLength: 2, fixed: true, length: 2, numeric: true},
+	"21": {title: "SERIAL", aiLength: 2, length: 20},
+	"22": {title: "CPV", aiLength: 2, length: 20},
+	"30": {title: "VAR. COUNT", aiLength: 2, length: 8, numeric: true},
+	"37": {title: "COUNT", aiLength: 2, length: 8, numeric: true},
+	"90": {title: "INTERNAL", aiLength: 2, length: 30},
+	"91": {title: "INTERNAL", aiLength: 2, length: 90},
+	"92": {title: "INTERNAL", aiLength: 2, length: 90},
+	"93": {title: "INTERNAL", aiLength: 2, length: 90},
+	"94": {title: "INTERNAL", aiLength: 2, length: 90},
+	"95": {title: "INTERNAL", aiLength: 2, length: 90},
+	"96": {title: "INTERNAL", aiLength: 2, length: 90},
+	"97": {title: "INTERNAL", aiLength: 2, length: 90},
+	"98": {title: "INTERNAL", aiLength: 2, length: 90},
+	"99": {title: "INTERNAL", aiLength: 2, length: 90},
+
+	"240": {title: "ADDITIONAL ID", aiLength: 3, length: 30},
+	"241": {title: "CUST. PART No.", aiLength: 3, length: 30},
+	"250": {title: "SECONDARY SERIAL", aiLength: 3, length: 30},
+	"400": {title: "ORDER NUMBER", aiLength: 3, length: 30},
+	"410": {title: "SHIP TO LOC", aiLength: 3, fixed: true, length: 13, numeric: true, checkDigit: true},
+	"414": {title: "LOC No.", aiLength: 3, fixed: true, length: 13, numeric: true, checkDigit: true},
+	"420": {title: "SHIP TO POST", aiLength: 3, length: 20},
+
+	"310": {title: "NET WEIGHT (kg)", aiLength: 4, fixed: true, length: 6, numeric: true},
+	"330": {title: "GROSS WEIGHT (kg)", aiLength: 4, fixed: true, length: 6, numeric: true},
+	"311": {title: "LENGTH (m)", aiLength: 4, fixed: true, length: 6, numeric: true},
+	"312": {title: "WIDTH (m)", aiLength: 4, fixed: true, length: 6, numeric: true},
+	"313": {title: "HEIGHT (m)", aiLength: 4, fixed: true, length: 6, numeric: true},
+}
+
+// lookup finds the definition of the AI that data starts with and returns the
+// AI digits as they appear in data.
+func lookup(data string) (definition, string, bool) {
+	for _, prefixLength := range []int{2, 3} {
+		if len(data) < prefixLength {
+			break
+		}
+
+		def, ok := definitions[data[:prefixLength]]
+		if !ok {
+			continue
+		}
+		if len(data) < def.aiLength || !isDigits(data[:def.aiLength]) {
+			return definition{}, "", false
+		}
+
+		return def, data[:def.aiLength], true
+	}
+
+	return definition{}, "", false
+}
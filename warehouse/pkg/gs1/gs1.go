@@ -0,0 +1,279 @@
+package gs1
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// GroupSeparator ends variable length fields in raw scanner output, it is
+	// what FNC1 is transmitted as.
+	GroupSeparator = "\x1d"
+
+	AISSCC       = "00"
+	AIGTIN       = "01"
+	AIBatch      = "10"
+	AIProduction = "11"
+	AIBestBefore = "15"
+	AIExpiry     = "17"
+	AISerial     = "21"
+	AICount      = "37"
+)
+
+var (
+	ErrEmpty           = errors.New("gs1: empty data")
+	ErrUnknownAI       = errors.New("gs1: unknown application identifier")
+	ErrInvalidLength   = errors.New("gs1: invalid element length")
+	ErrInvalidValue    = errors.New("gs1: invalid element value")
+	ErrInvalidCheckSum = errors.New("gs1: invalid check digit")
+
+	// Symbology identifiers announcing GS1 content (ISO/IEC 15424)
+	symbologyIdentifiers = []string{"]C1", "]d2", "]Q3", "]e0", "]J1"}
+
+	bracketed = regexp.MustCompile(`^\((\d{2,4})\)`)
+	element   = regexp.MustCompile(`\((\d{2,4})\)([^(]*)`)
+)
+
+type Element struct {
+	AI    string
+	Title string
+	Value string
+}
+
+type Data struct {
+	Elements []Element
+}
+
+// Get returns the value of the first element with the given AI
+func (d Data) Get(ai string) (string, bool) {
+	for _, e := range d.Elements {
+		if e.AI == ai {
+			return e.Value, true
+		}
+	}
+	return "", false
+}
+
+func (d Data) GTIN() string {
+	v, _ := d.Get(AIGTIN)
+	return v
+}
+
+func (d Data) Lot() string {
+	v, _ := d.Get(AIBatch)
+	return v
+}
+
+func (d Data) Serial() string {
+	v, _ := d.Get(AISerial)
+	return v
+}
+
+// Expiry is the AI 17 date, falling back to the best before date
+func (d Data) Expiry() (time.Time, bool) {
+	for _, ai := range []string{AIExpiry, AIBestBefore} {
+		if v, ok := d.Get(ai); ok {
+			if t, err := ParseDate(v); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// IsGS1 tells whether scanned data should be read as GS1 element strings,
+// either because the decoder says so or because of the way it starts.
+func IsGS1(symbology, raw string) bool {
+	if strings.Contains(strings.ToUpper(symbology), "GS1") {
+		return true
+	}
+
+	for _, prefix := range symbologyIdentifiers {
+		if strings.HasPrefix(raw, prefix) {
+			return true
+		}
+	}
+
+	return strings.HasPrefix(raw, GroupSeparator) || bracketed.MatchString(raw)
+}
+
+// Parse accepts the human readable form "(01)09501101530003(10)LOT" as well as
+// raw scanner output with an optional symbology identifier and FNC1 separators.
+func Parse(raw string) (Data, error) {
+	var (
+		data Data
+	)
+
+	raw = strings.TrimSpace(raw)
+	if bracketed.MatchString(raw) {
+		return parseBracketed(raw)
+	}
+
+	for _, prefix := range symbologyIdentifiers {
+		raw = strings.TrimPrefix(raw, prefix)
+	}
+	raw = strings.TrimLeft(raw, GroupSeparator)
+	if len(raw) < 1 {
+		return data, ErrEmpty
+	}
+
+	for len(raw) > 0 {
+		def, ai, ok := lookup(raw)
+		if !ok {
+			return data, ErrUnknownAI
+		}
+		raw = raw[len(ai):]
+
+		var value string
+		if def.fixed {
+			if len(raw) < def.length {
+				return data, ErrInvalidLength
+			}
+			value, raw = raw[:def.length], raw[def.length:]
+			// Some encoders still put a separator after fixed fields
+			raw = strings.TrimPrefix(raw, GroupSeparator)
+		} else {
+			end := strings.Index(raw, GroupSeparator)
+			if end < 0 {
+				value, raw = raw, ""
+			} else {
+				value, raw = raw[:end], raw[end+1:]
+			}
+		}
+
+		e, err := newElement(def, ai, value)
+		if err != nil {
+			return data, err
+		}
+		data.Elements = append(data.Elements, e)
+	}
+
+	return data, nil
+}
+
+func parseBracketed(raw string) (Data, error) {
+	var (
+		data Data
+	)
+
+	matches := element.FindAllStringSubmatch(raw, -1)
+	if len(matches) < 1 {
+		return data, ErrEmpty
+	}
+
+	for _, m := range matches {
+		ai, value := m[1], strings.TrimSpace(m[2])
+
+		def, matched, ok := lookup(ai)
+		if !ok || matched != ai {
+			return data, ErrUnknownAI
+		}
+
+		e, err := newElement(def, ai, value)
+		if err != nil {
+			return data, err
+		}
+		data.Elements = append(data.Elements, e)
+	}
+
+	return data, nil
+}
+
+func newElement(def definition, ai, value string) (Element, error) {
+	if def.fixed && len(value) != def.length {
+		return Element{}, ErrInvalidLength
+	}
+	if !def.fixed && (len(value) < 1 || len(value) > def.length) {
+		return Element{}, ErrInvalidLength
+	}
+	if def.numeric && !isDigits(value) {
+		return Element{}, ErrInvalidValue
+	}
+	if def.date {
+		if _, err := ParseDate(value); err != nil {
+			return Element{}, ErrInvalidValue
+		}
+	}
+	if def.checkDigit && !ValidCheckDigit(value) {
+		return Element{}, ErrInvalidCheckSum
+	}
+
+	return Element{
+		AI:    ai,
+		Title: def.title,
+		Value: value,
+	}, nil
+}
+
+// ParseDate reads a YYMMDD date, a day of 00 means the end of the month and
+// the century follows the GS1 sliding window around the current year.
+func ParseDate(value string) (time.Time, error) {
+	if len(value) != 6 || !isDigits(value) {
+		return time.Time{}, ErrInvalidValue
+	}
+
+	yy := atoi(value[0:2])
+	month := atoi(value[2:4])
+	day := atoi(value[4:6])
+	if month < 1 || month > 12 {
+		return time.Time{}, ErrInvalidValue
+	}
+
+	currentYear := time.Now().Year()
+	year := currentYear/100*100 + yy
+	switch diff := yy - currentYear%100; {
+	case diff >= 51:
+		year -= 100
+	case diff <= -50:
+		year += 100
+	}
+
+	if day == 0 {
+		return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		return time.Time{}, ErrInvalidValue
+	}
+
+	return t, nil
+}
+
+// ValidCheckDigit verifies the mod 10 check digit shared by GTIN, SSCC and GLN
+func ValidCheckDigit(value string) bool {
+	if len(value) < 2 || !isDigits(value) {
+		return false
+	}
+
+	sum := 0
+	body := value[:len(value)-1]
+	for i := 0; i < len(body); i++ {
+		d := int(body[len(body)-1-i] - '0')
+		if i%2 == 0 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return (10-sum%10)%10 == int(value[len(value)-1]-'0')
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+func atoi(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		n = n*10 + int(s[i]-'0')
+	}
+	return n
+}
@@ -0,0 +1,163 @@
+package gs1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []Element
+		wantErr error
+	}{
+		{
+			name: "bracketed",
+			raw:  "(01)09501101530003(17)261231(10)LOT-7",
+			want: []Element{
+				{AI: "01", Title: "GTIN", Value: "09501101530003"},
+				{AI: "17", Title: "USE BY", Value: "261231"},
+				{AI: "10", Title: "BATCH/LOT", Value: "LOT-7"},
+			},
+		},
+		{
+			name: "raw with symbology identifier and separator",
+			raw:  "]C101095011015300031012AB\x1d21SN9",
+			want: []Element{
+				{AI: "01", Title: "GTIN", Value: "09501101530003"},
+				{AI: "10", Title: "BATCH/LOT", Value: "12AB"},
+				{AI: "21", Title: "SERIAL", Value: "SN9"},
+			},
+		},
+		{
+			name: "separator after fixed field",
+			raw:  "\x1d0109501101530003\x1d3103000125",
+			want: []Element{
+				{AI: "01", Title: "GTIN", Value: "09501101530003"},
+				{AI: "3103", Title: "NET WEIGHT (kg)", Value: "000125"},
+			},
+		},
+		{
+			name: "sscc",
+			raw:  "00106141411234567897",
+			want: []Element{
+				{AI: "00", Title: "SSCC", Value: "106141411234567897"},
+			},
+		},
+		{name: "empty", raw: "]C1", wantErr: ErrEmpty},
+		{name: "unknown ai", raw: "(88)123", wantErr: ErrUnknownAI},
+		{name: "truncated fixed field", raw: "01095011015300", wantErr: ErrInvalidLength},
+		{name: "bad check digit", raw: "(01)09501101530004", wantErr: ErrInvalidCheckSum},
+		{name: "bad date", raw: "(17)261332", wantErr: ErrInvalidValue},
+		{name: "non numeric count", raw: "(37)12A", wantErr: ErrInvalidValue},
+		{name: "lot too long", raw: "(10)ABCDEFGHIJKLMNOPQRSTU", wantErr: ErrInvalidLength},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Parse(tt.raw)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if len(data.Elements) != len(tt.want) {
+				t.Fatalf("elements = %+v, want %+v", data.Elements, tt.want)
+			}
+			for i := range tt.want {
+				if data.Elements[i] != tt.want[i] {
+					t.Errorf("element %d = %+v, want %+v", i, data.Elements[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDataAccessors(t *testing.T) {
+	data, err := Parse("(01)09501101530003(15)260300(10)LOT-7(21)SN9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := data.GTIN(); got != "09501101530003" {
+		t.Errorf("GTIN = %s", got)
+	}
+	if got := data.Lot(); got != "LOT-7" {
+		t.Errorf("Lot = %s", got)
+	}
+	if got := data.Serial(); got != "SN9" {
+		t.Errorf("Serial = %s", got)
+	}
+	// Best before is used when there is no expiry, day 00 is the end of month
+	expiry, ok := data.Expiry()
+	if want := time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC); !ok || !expiry.Equal(want) {
+		t.Errorf("Expiry = %v %v, want %v", expiry, ok, want)
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"261231", time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC), false},
+		{"240200", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), false},
+		{"251200", time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), false},
+		{"250230", time.Time{}, true},
+		{"251301", time.Time{}, true},
+		{"2512", time.Time{}, true},
+		{"25AB01", time.Time{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseDate(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseDate(%s) err = %v", tt.value, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%s) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestValidCheckDigit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"09501101530003", true},
+		{"4006381333931", true},
+		{"106141411234567897", true},
+		{"09501101530004", false},
+		{"7", false},
+		{"0950110153000A", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidCheckDigit(tt.value); got != tt.want {
+			t.Errorf("ValidCheckDigit(%s) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsGS1(t *testing.T) {
+	tests := []struct {
+		symbology string
+		raw       string
+		want      bool
+	}{
+		{"GS1-128", "0109501101530003", true},
+		{"CODE128", "]C10109501101530003", true},
+		{"DATAMATRIX", "\x1d0109501101530003", true},
+		{"", "(01)09501101530003", true},
+		{"CODE128", "SKU-0001", false},
+		{"EAN13", "4006381333931", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsGS1(tt.symbology, tt.raw); got != tt.want {
+			t.Errorf("IsGS1(%s, %q) = %v, want %v", tt.symbology, tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package httpcommon
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+	ErrPreconditionFailed   = errors.New("If-Match does not name a version")
+)
+
+// ETag quotes the version of an entity as a strong entity tag
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func SetETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", ETag(version))
+}
+
+// IfMatch returns the version named by the If-Match header. Weak tags, lists
+// and "*" never name a single version so they fail the precondition.
+func IfMatch(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(header) < 1 {
+		return 0, ErrPreconditionRequired
+	}
+
+	if len(header) < 3 || header[0] != '"' || header[len(header)-1] != '"' {
+		return 0, ErrPreconditionFailed
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, ErrPreconditionFailed
+	}
+
+	return version, nil
+}
@@ -0,0 +1,62 @@
+package httpcommon
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int64
+		wantErr error
+	}{
+		{"missing", "", 0, ErrPreconditionRequired},
+		{"blank", "   ", 0, ErrPreconditionRequired},
+		{"quoted version", `"7"`, 7, nil},
+		{"surrounding space", ` "12" `, 12, nil},
+		{"weak tag", `W/"7"`, 0, ErrPreconditionFailed},
+		{"list", `"7", "8"`, 0, ErrPreconditionFailed},
+		{"any", "*", 0, ErrPreconditionFailed},
+		{"unquoted", "7", 0, ErrPreconditionFailed},
+		{"empty tag", `""`, 0, ErrPreconditionFailed},
+		{"not a number", `"abc"`, 0, ErrPreconditionFailed},
+		{"zero", `"0"`, 0, ErrPreconditionFailed},
+		{"negative", `"-1"`, 0, ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/", nil)
+			if len(tt.header) > 0 {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := IfMatch(r)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("version = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetETag(w, 42)
+
+	tag := w.Header().Get("ETag")
+	if tag != `"42"` {
+		t.Fatalf("ETag = %s, want \"42\"", tag)
+	}
+
+	// A tag sent back as If-Match names the same version
+	r := httptest.NewRequest("PUT", "/", nil)
+	r.Header.Set("If-Match", tag)
+	if version, err := IfMatch(r); err != nil || version != 42 {
+		t.Errorf("IfMatch(%s) = %d, %v", tag, version, err)
+	}
+}
@@ -2,17 +2,24 @@ package httpcommon
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 )
 
+const (
+	// Error text of http.MaxBytesReader, it has no exported error value
+	requestTooLargeMessage = "http: request body too large"
+)
+
 type jsonErrorResponse struct {
 	Error jsonError `json:"error"`
 }
 
 type jsonError struct {
-	Code    int    `json:"code"`
-	Message string `json:"string"`
+	Code    int         `json:"code"`
+	Message string      `json:"string"`
+	Detail  interface{} `json:"detail,omitempty"`
 }
 
 func ResponseJSONError(w http.ResponseWriter, code int, message string) {
@@ -26,6 +33,20 @@ func ResponseJSONError(w http.ResponseWriter, code int, message string) {
 	ResponseJSON(w, code, err)
 }
 
+// ResponseJSONErrorDetail is ResponseJSONError with more about the error for
+// the client to act on
+func ResponseJSONErrorDetail(w http.ResponseWriter, code int, message string, detail interface{}) {
+	err := jsonErrorResponse{
+		Error: jsonError{
+			Code:    code,
+			Message: message,
+			Detail:  detail,
+		},
+	}
+
+	ResponseJSON(w, code, err)
+}
+
 func ResponseJSON(w http.ResponseWriter, code int, jsonData interface{}) {
 	jsonBit, err := json.Marshal(&jsonData)
 	if err != nil {
@@ -38,3 +59,20 @@ func ResponseJSON(w http.ResponseWriter, code int, jsonData interface{}) {
 	w.WriteHeader(code)
 	fmt.Fprintf(w, string(jsonBit))
 }
+
+func ResponseBytes(w http.ResponseWriter, code int, contentType string, data []byte) {
+	w.Header().Add("Content-Type", contentType)
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// IsRequestTooLarge reports whether err comes from reading a body past the
+// limit of http.MaxBytesReader
+func IsRequestTooLarge(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == requestTooLargeMessage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package httpcommon
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIsRequestTooLarge(t *testing.T) {
+	_, tooLarge := ioutil.ReadAll(http.MaxBytesReader(httptest.NewRecorder(), ioutil.NopCloser(strings.NewReader("abcd")), 2))
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"max bytes reader", tooLarge, true},
+		{"wrapped", fmt.Errorf("parse form: %w", tooLarge), true},
+		{"other error", errors.New("unexpected EOF"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRequestTooLarge(tt.err); got != tt.want {
+				t.Errorf("IsRequestTooLarge(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
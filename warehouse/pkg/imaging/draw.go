@@ -0,0 +1,141 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// DrawRect draws the outline of r with the given stroke thickness
+func DrawRect(dst draw.Image, r image.Rectangle, c color.Color, thickness int) {
+	if thickness < 1 {
+		thickness = 1
+	}
+
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness),
+		image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y),
+		image.Rect(r.Min.X, r.Min.Y, r.Min.X+thickness, r.Max.Y),
+		image.Rect(r.Max.X-thickness, r.Min.Y, r.Max.X, r.Max.Y),
+	}
+
+	for _, edge := range edges {
+		draw.Draw(dst, edge.Intersect(dst.Bounds()), src, image.Point{}, draw.Over)
+	}
+}
+
+// DrawLine draws a straight line between two points using square brush strokes
+func DrawLine(dst draw.Image, from, to image.Point, c color.Color, thickness int) {
+	if thickness < 1 {
+		thickness = 1
+	}
+
+	var (
+		src    = image.NewUniform(c)
+		dx     = abs(to.X - from.X)
+		dy     = -abs(to.Y - from.Y)
+		sx     = 1
+		sy     = 1
+		errAcc = dx + dy
+		half   = thickness / 2
+		x, y   = from.X, from.Y
+	)
+
+	if from.X > to.X {
+		sx = -1
+	}
+	if from.Y > to.Y {
+		sy = -1
+	}
+
+	// Bresenham, stamping a thickness sized square on every step
+	for {
+		brush := image.Rect(x-half, y-half, x-half+thickness, y-half+thickness)
+		draw.Draw(dst, brush.Intersect(dst.Bounds()), src, image.Point{}, draw.Over)
+
+		if x == to.X && y == to.Y {
+			return
+		}
+
+		e2 := 2 * errAcc
+		if e2 >= dy {
+			errAcc += dy
+			x += sx
+		}
+		if e2 <= dx {
+			errAcc += dx
+			y += sy
+		}
+	}
+}
+
+// DrawPolygon connects every point with the next one, closing the shape
+func DrawPolygon(dst draw.Image, points []image.Point, c color.Color, thickness int) {
+	for i := range points {
+		DrawLine(dst, points[i], points[(i+1)%len(points)], c, thickness)
+	}
+}
+
+// DrawLabel writes text on a filled background with its top-left corner at pt.
+// The built in bitmap font is tiny, so scale enlarges it for high resolution photos.
+func DrawLabel(dst draw.Image, pt image.Point, text string, fg, bg color.Color, scale int) image.Rectangle {
+	if scale < 1 {
+		scale = 1
+	}
+
+	var (
+		face    = basicfont.Face7x13
+		padding = 2
+		width   = font.MeasureString(face, text).Ceil() + padding*2
+		height  = face.Metrics().Height.Ceil() + padding*2
+		label   = image.NewRGBA(image.Rect(0, 0, width, height))
+	)
+
+	draw.Draw(label, label.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	drawer := font.Drawer{
+		Dst:  label,
+		Src:  image.NewUniform(fg),
+		Face: face,
+		Dot:  fixed.P(padding, padding+face.Metrics().Ascent.Ceil()),
+	}
+	drawer.DrawString(text)
+
+	// Keep the label inside the picture when the box touches an edge
+	target := image.Rect(0, 0, width*scale, height*scale).Add(pt)
+	bounds := dst.Bounds()
+	if target.Max.X > bounds.Max.X {
+		target = target.Sub(image.Pt(target.Max.X-bounds.Max.X, 0))
+	}
+	if target.Max.Y > bounds.Max.Y {
+		target = target.Sub(image.Pt(0, target.Max.Y-bounds.Max.Y))
+	}
+	if target.Min.X < bounds.Min.X {
+		target = target.Add(image.Pt(bounds.Min.X-target.Min.X, 0))
+	}
+	if target.Min.Y < bounds.Min.Y {
+		target = target.Add(image.Pt(0, bounds.Min.Y-target.Min.Y))
+	}
+
+	for y := target.Min.Y; y < target.Max.Y; y++ {
+		for x := target.Min.X; x < target.Max.X; x++ {
+			if !(image.Point{x, y}).In(bounds) {
+				continue
+			}
+			dst.Set(x, y, label.At((x-target.Min.X)/scale, (y-target.Min.Y)/scale))
+		}
+	}
+
+	return target
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
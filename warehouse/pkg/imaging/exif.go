@@ -0,0 +1,91 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	OrientationNormal = 1
+
+	exifOrientationTag = 0x0112
+)
+
+// ReadOrientation returns the EXIF orientation (1-8) of a JPEG, phones store
+// pictures as the sensor saw them and only flag how they should be rotated.
+// Anything that is not a JPEG with a readable orientation tag reports normal.
+func ReadOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return OrientationNormal
+	}
+
+	offset := 2
+	for offset+4 <= len(data) {
+		if data[offset] != 0xFF {
+			return OrientationNormal
+		}
+
+		marker := data[offset+1]
+		size := int(binary.BigEndian.Uint16(data[offset+2 : offset+4]))
+
+		// Start of scan, metadata is always before the image data
+		if marker == 0xDA || size < 2 {
+			return OrientationNormal
+		}
+
+		segmentStart := offset + 4
+		segmentEnd := offset + 2 + size
+		if segmentEnd > len(data) {
+			return OrientationNormal
+		}
+
+		if marker == 0xE1 && bytes.HasPrefix(data[segmentStart:segmentEnd], []byte("Exif\x00\x00")) {
+			return readTIFFOrientation(data[segmentStart+6 : segmentEnd])
+		}
+
+		offset = segmentEnd
+	}
+
+	return OrientationNormal
+}
+
+func readTIFFOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return OrientationNormal
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+
+	ifdOffset := int(order.Uint32(tiff[4:8]))
+	if ifdOffset+2 > len(tiff) {
+		return OrientationNormal
+	}
+
+	entries := int(order.Uint16(tiff[ifdOffset : ifdOffset+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifdOffset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return OrientationNormal
+		}
+
+		if order.Uint16(tiff[entry:entry+2]) != exifOrientationTag {
+			continue
+		}
+
+		orientation := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if orientation < 1 || orientation > 8 {
+			return OrientationNormal
+		}
+		return orientation
+	}
+
+	return OrientationNormal
+}
@@ -0,0 +1,89 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+type Format string
+
+const (
+	FormatPNG  Format = "png"
+	FormatJPEG Format = "jpeg"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+)
+
+// ParseFormat accepts the usual spellings of an output format ("png", "jpg", "jpeg")
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "png":
+		return FormatPNG, nil
+	case "jpg", "jpeg":
+		return FormatJPEG, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJPEG:
+		return "image/jpeg"
+	default:
+		return "image/png"
+	}
+}
+
+func Decode(r io.Reader) (image.Image, string, error) {
+	return image.Decode(r)
+}
+
+func Encode(w io.Writer, img image.Image, format Format) error {
+	switch format {
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatJPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 90})
+	}
+
+	return ErrUnsupportedFormat
+}
+
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if quality < 1 || quality > 100 {
+		quality = jpeg.DefaultQuality
+	}
+
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func EncodeToBytes(img image.Image, format Format) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ToRGBA returns a mutable copy of img, so drawing never touches the decoded source
+func ToRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
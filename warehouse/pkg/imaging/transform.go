@@ -0,0 +1,208 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ApplyOrientation rotates and flips img so that it is upright for the given
+// EXIF orientation value.
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= OrientationNormal || orientation > 8 {
+		return img
+	}
+
+	src := ToRGBA(img)
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+
+	// Orientations 5 to 8 swap width and height
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = w-1-x, y
+			case 3:
+				dx, dy = w-1-x, h-1-y
+			case 4:
+				dx, dy = x, h-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = h-1-y, x
+			case 7:
+				dx, dy = h-1-y, w-1-x
+			case 8:
+				dx, dy = y, w-1-x
+			}
+
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	return dst
+}
+
+// Resize shrinks img so its longest side is at most maxDimension, smaller
+// images are returned untouched.
+func Resize(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if maxDimension < 1 || (w <= maxDimension && h <= maxDimension) {
+		return img
+	}
+
+	var dw, dh int
+	if w >= h {
+		dw = maxDimension
+		dh = h * maxDimension / w
+	} else {
+		dh = maxDimension
+		dw = w * maxDimension / h
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), img, bounds, xdraw.Src, nil)
+	return dst
+}
+
+func Grayscale(img image.Image) *image.Gray {
+	if gray, ok := img.(*image.Gray); ok {
+		return gray
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewGray(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
+
+// NormalizeContrast stretches the histogram so that the darkest and brightest
+// percent of pixels become black and white, helping dim warehouse photos.
+// Only the pixels inside img.Rect count, a sub-image shares Pix with its parent.
+func NormalizeContrast(img *image.Gray) *image.Gray {
+	var (
+		histogram [256]int
+		bounds    = img.Rect
+		width     = bounds.Dx()
+		total     = width * bounds.Dy()
+	)
+
+	if total == 0 {
+		return img
+	}
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		offset := img.PixOffset(bounds.Min.X, y)
+		for _, p := range img.Pix[offset : offset+width] {
+			histogram[p]++
+		}
+	}
+
+	clip := total / 100
+	low, high := 0, 255
+	for count := 0; low < 255; low++ {
+		count += histogram[low]
+		if count > clip {
+			break
+		}
+	}
+	for count := 0; high > 0; high-- {
+		count += histogram[high]
+		if count > clip {
+			break
+		}
+	}
+
+	if high <= low {
+		return img
+	}
+
+	var lookup [256]uint8
+	for i := range lookup {
+		switch {
+		case i <= low:
+			lookup[i] = 0
+		case i >= high:
+			lookup[i] = 255
+		default:
+			lookup[i] = uint8((i - low) * 255 / (high - low))
+		}
+	}
+
+	dst := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		src := img.Pix[img.PixOffset(bounds.Min.X, y):]
+		row := dst.Pix[dst.PixOffset(bounds.Min.X, y):]
+		for x := 0; x < width; x++ {
+			row[x] = lookup[src[x]]
+		}
+	}
+
+	return dst
+}
+
+// Tiles splits bounds into size x size rectangles overlapping by overlap pixels,
+// the last row and column are pulled back so every tile stays full sized.
+func Tiles(bounds image.Rectangle, size, overlap int) []image.Rectangle {
+	var tiles []image.Rectangle
+
+	if size < 1 || overlap >= size {
+		return []image.Rectangle{bounds}
+	}
+
+	step := size - overlap
+	for _, y := range tileStarts(bounds.Min.Y, bounds.Max.Y, size, step) {
+		for _, x := range tileStarts(bounds.Min.X, bounds.Max.X, size, step) {
+			tiles = append(tiles, image.Rect(x, y, x+size, y+size).Intersect(bounds))
+		}
+	}
+
+	return tiles
+}
+
+func tileStarts(min, max, size, step int) []int {
+	if max-min <= size {
+		return []int{min}
+	}
+
+	var starts []int
+	for start := min; ; start += step {
+		if start+size >= max {
+			starts = append(starts, max-size)
+			break
+		}
+		starts = append(starts, start)
+	}
+
+	return starts
+}
+
+// Crop returns the part of img inside r, without copying when possible
+func Crop(img image.Image, r image.Rectangle) image.Image {
+	if sub, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
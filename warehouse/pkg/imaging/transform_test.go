@@ -0,0 +1,81 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient returns a w x h image whose pixels brighten from left to right
+// between 64 and 191
+func gradient(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(64 + x*127/(w-1))})
+		}
+	}
+	return img
+}
+
+func TestNormalizeContrast(t *testing.T) {
+	source := gradient(100, 40)
+
+	tests := []struct {
+		name   string
+		img    *image.Gray
+		bounds image.Rectangle
+	}{
+		{
+			name:   "whole image",
+			img:    source,
+			bounds: source.Rect,
+		},
+		{
+			name:   "cropped middle",
+			img:    Crop(source, image.Rect(20, 10, 80, 30)).(*image.Gray),
+			bounds: image.Rect(20, 10, 80, 30),
+		},
+		{
+			name:   "cropped corner",
+			img:    Crop(source, image.Rect(60, 25, 100, 40)).(*image.Gray),
+			bounds: image.Rect(60, 25, 100, 40),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeContrast(tt.img)
+
+			if got.Rect != tt.bounds {
+				t.Fatalf("bounds = %v, want %v", got.Rect, tt.bounds)
+			}
+
+			left := got.GrayAt(tt.bounds.Min.X, tt.bounds.Min.Y).Y
+			right := got.GrayAt(tt.bounds.Max.X-1, tt.bounds.Max.Y-1).Y
+			if left != 0 || right != 255 {
+				t.Errorf("edges = %d..%d, want 0..255", left, right)
+			}
+
+			for y := tt.bounds.Min.Y; y < tt.bounds.Max.Y; y++ {
+				for x := tt.bounds.Min.X + 1; x < tt.bounds.Max.X; x++ {
+					if got.GrayAt(x, y).Y < got.GrayAt(x-1, y).Y {
+						t.Fatalf("pixel (%d,%d) is darker than its left neighbour", x, y)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNormalizeContrastFlat(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 10, 10))
+	for i := range img.Pix {
+		img.Pix[i] = 128
+	}
+
+	sub := Crop(img, image.Rect(2, 2, 6, 6)).(*image.Gray)
+	if got := NormalizeContrast(sub); got != sub {
+		t.Errorf("flat image was changed")
+	}
+}
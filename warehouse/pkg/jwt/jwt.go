@@ -0,0 +1,190 @@
+package jwt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+const (
+	// The only algorithm accepted, tokens cannot downgrade to "none" or swap
+	// to an asymmetric algorithm keyed with the shared secret
+	AlgorithmHS256 = "HS256"
+)
+
+var (
+	ErrMalformed        = errors.New("jwt: malformed token")
+	ErrAlgorithm        = errors.New("jwt: unsupported algorithm")
+	ErrSignature        = errors.New("jwt: invalid signature")
+	ErrExpired          = errors.New("jwt: token expired")
+	ErrNotYetValid      = errors.New("jwt: token not valid yet")
+	ErrInvalidIssuer    = errors.New("jwt: invalid issuer")
+	ErrInvalidAudience  = errors.New("jwt: invalid audience")
+	ErrMissingExpiry    = errors.New("jwt: token has no expiry")
+	ErrMissingSecretKey = errors.New("jwt: no secret configured")
+
+	encoding = base64.RawURLEncoding
+)
+
+type header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Claims are the registered claims together with the ones this service reads
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	Admin     bool     `json:"admin,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+}
+
+// Audience is the aud claim, RFC 7519 allows a single string or an array of
+// them. A single audience is written back as a string.
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var (
+		single string
+		list   []string
+	)
+
+	if string(data) == "null" {
+		*a = nil
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = Audience(list)
+	return nil
+}
+
+// Contains tells whether the token was issued for audience
+func (a Audience) Contains(audience string) bool {
+	for _, aud := range a {
+		if aud == audience {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator checks the time based claims with some leeway for clock skew,
+// Issuer and Audience are only checked when set.
+type Validator struct {
+	Secret   []byte
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+// Sign returns the compact serialization of the claims
+func Sign(claims Claims, secret []byte) (string, error) {
+	if len(secret) < 1 {
+		return "", ErrMissingSecretKey
+	}
+
+	headerBytes, err := json.Marshal(header{Algorithm: AlgorithmHS256, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := encoding.EncodeToString(headerBytes) + "." + encoding.EncodeToString(claimsBytes)
+	return unsigned + "." + encoding.EncodeToString(signature(unsigned, secret)), nil
+}
+
+// Verify checks the signature and claims of a token at the given time
+func (v Validator) Verify(token string, now time.Time) (Claims, error) {
+	var (
+		h      header
+		claims Claims
+	)
+
+	if len(v.Secret) < 1 {
+		return claims, ErrMissingSecretKey
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrMalformed
+	}
+
+	headerBytes, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+	if err := json.Unmarshal(headerBytes, &h); err != nil {
+		return claims, ErrMalformed
+	}
+	if h.Algorithm != AlgorithmHS256 {
+		return claims, ErrAlgorithm
+	}
+
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+	if !hmac.Equal(sig, signature(parts[0]+"."+parts[1], v.Secret)) {
+		return claims, ErrSignature
+	}
+
+	claimsBytes, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrMalformed
+	}
+	if err := json.Unmarshal(claimsBytes, &claims); err != nil {
+		return claims, ErrMalformed
+	}
+
+	if claims.ExpiresAt == 0 {
+		return claims, ErrMissingExpiry
+	}
+	if now.Add(-v.Leeway).Unix() >= claims.ExpiresAt {
+		return claims, ErrExpired
+	}
+	if claims.NotBefore > 0 && now.Add(v.Leeway).Unix() < claims.NotBefore {
+		return claims, ErrNotYetValid
+	}
+	if len(v.Issuer) > 0 && claims.Issuer != v.Issuer {
+		return claims, ErrInvalidIssuer
+	}
+	if len(v.Audience) > 0 && !claims.Audience.Contains(v.Audience) {
+		return claims, ErrInvalidAudience
+	}
+
+	return claims, nil
+}
+
+func signature(unsigned string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,106 @@
+package jwt
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+// signRaw signs claims exactly as given, the way another issuer would
+func signRaw(headerJSON, claimsJSON string, secret []byte) string {
+	unsigned := encoding.EncodeToString([]byte(headerJSON)) + "." + encoding.EncodeToString([]byte(claimsJSON))
+	return unsigned + "." + encoding.EncodeToString(signature(unsigned, secret))
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	validHeader := `{"alg":"HS256","typ":"JWT"}`
+	validator := Validator{Secret: testSecret, Issuer: "auth", Audience: "warehouse", Leeway: time.Minute}
+
+	signed, err := Sign(Claims{Subject: "u1", Issuer: "auth", Audience: Audience{"warehouse"}, ExpiresAt: now.Unix() + 60}, testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		validator Validator
+		wantErr   error
+	}{
+		{"signed here", signed, validator, nil},
+		{"string audience", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":"warehouse","exp":1700000060}`, testSecret), validator, nil},
+		{"array audience", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":["billing","warehouse"],"exp":1700000060}`, testSecret), validator, nil},
+		{"other audience", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":["billing"],"exp":1700000060}`, testSecret), validator, ErrInvalidAudience},
+		{"audience not checked", signRaw(validHeader, `{"sub":"u1","exp":1700000060}`, testSecret), Validator{Secret: testSecret}, nil},
+		{"other issuer", signRaw(validHeader, `{"sub":"u1","iss":"other","aud":"warehouse","exp":1700000060}`, testSecret), validator, ErrInvalidIssuer},
+		{"expired", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":"warehouse","exp":1699999900}`, testSecret), validator, ErrExpired},
+		{"expired within leeway", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":"warehouse","exp":1699999990}`, testSecret), validator, nil},
+		{"not yet valid", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":"warehouse","exp":1700000600,"nbf":1700000300}`, testSecret), validator, ErrNotYetValid},
+		{"no expiry", signRaw(validHeader, `{"sub":"u1","iss":"auth","aud":"warehouse"}`, testSecret), validator, ErrMissingExpiry},
+		{"none algorithm", signRaw(`{"alg":"none"}`, `{"sub":"u1","exp":1700000060}`, testSecret), validator, ErrAlgorithm},
+		{"other secret", signRaw(validHeader, `{"sub":"u1","exp":1700000060}`, []byte("another secret")), validator, ErrSignature},
+		{"malformed", "abc.def", validator, ErrMalformed},
+		{"no secret", signed, Validator{}, ErrMissingSecretKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.validator.Verify(tt.token, now)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Subject != "u1" {
+				t.Errorf("subject = %s, want u1", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		want     Audience
+		wantJSON string
+	}{
+		{"string", `"warehouse"`, Audience{"warehouse"}, `"warehouse"`},
+		{"single element array", `["warehouse"]`, Audience{"warehouse"}, `"warehouse"`},
+		{"array", `["billing","warehouse"]`, Audience{"billing", "warehouse"}, `["billing","warehouse"]`},
+		{"null", `null`, nil, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Audience
+			if err := json.Unmarshal([]byte(tt.json), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("audience = %q, want %q", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("audience = %q, want %q", got, tt.want)
+				}
+			}
+
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(encoded) != tt.wantJSON {
+				t.Errorf("json = %s, want %s", encoded, tt.wantJSON)
+			}
+		})
+	}
+}
+
+func TestAudienceRejectsOtherTypes(t *testing.T) {
+	var got Audience
+	if err := json.Unmarshal([]byte(`42`), &got); err == nil {
+		t.Errorf("audience = %q, want an error", got)
+	}
+}
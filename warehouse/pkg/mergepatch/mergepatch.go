@@ -0,0 +1,205 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"mime"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+const (
+	ContentType = "application/merge-patch+json"
+)
+
+var (
+	ErrNotObject = errors.New("merge patch must be a JSON object")
+)
+
+// Apply merges patch into doc as RFC 7396 describes: objects merge key by
+// key, null removes a key and any other value replaces it
+func Apply(doc, patch []byte) ([]byte, error) {
+	var (
+		target interface{}
+		p      interface{}
+	)
+
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := json.Unmarshal(doc, &target); err != nil {
+			return nil, err
+		}
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+
+	return t
+}
+
+// Result tells which top-level JSON fields a patch named and which of them
+// ended up with a different value
+type Result struct {
+	Supplied []string
+	Changed  []string
+}
+
+// Merge applies patch to the JSON form of current and decodes the merged
+// document into out, fields out does not know are rejected
+func Merge(current interface{}, patch []byte, out interface{}) (Result, error) {
+	var (
+		result   Result
+		supplied map[string]json.RawMessage
+	)
+
+	if err := json.Unmarshal(patch, &supplied); err != nil || supplied == nil {
+		return result, ErrNotObject
+	}
+
+	original, err := json.Marshal(current)
+	if err != nil {
+		return result, err
+	}
+
+	merged, err := Apply(original, patch)
+	if err != nil {
+		return result, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(out); err != nil {
+		return result, err
+	}
+
+	// Compare what out holds now, so values the decoder normalizes such as
+	// 1.0 and 1 are not taken for changes
+	updated, err := json.Marshal(out)
+	if err != nil {
+		return result, err
+	}
+
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return result, err
+	}
+	if err := json.Unmarshal(updated, &after); err != nil {
+		return result, err
+	}
+
+	for field := range supplied {
+		result.Supplied = append(result.Supplied, field)
+		if !reflect.DeepEqual(before[field], after[field]) {
+			result.Changed = append(result.Changed, field)
+		}
+	}
+	sort.Strings(result.Supplied)
+	sort.Strings(result.Changed)
+
+	return result, nil
+}
+
+// Validate runs the validations of s but only reports the failures of the
+// given top-level JSON fields and whatever they contain, fields the patch
+// left alone are not checked again
+func Validate(v *validator.Validate, s interface{}, fields []string) error {
+	err := v.Struct(s)
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	names := structFields(s)
+	checked := make(map[string]bool)
+	for _, field := range fields {
+		checked[names[field]] = true
+	}
+
+	var kept validator.ValidationErrors
+	for _, fe := range errs {
+		// StructNamespace reads as Type.Field[0].Inner
+		parts := strings.SplitN(fe.StructNamespace(), ".", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		top := parts[1]
+		if i := strings.Index(top, "["); i >= 0 {
+			top = top[:i]
+		}
+		if checked[top] {
+			kept = append(kept, fe)
+		}
+	}
+
+	if len(kept) < 1 {
+		return nil
+	}
+	return kept
+}
+
+// structFields maps the JSON names of the fields of s to their Go names
+func structFields(s interface{}) map[string]string {
+	names := make(map[string]string)
+
+	t := reflect.TypeOf(s)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return names
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) < 1 {
+			name = f.Name
+		}
+		names[name] = f.Name
+	}
+
+	return names
+}
+
+// Accepts tells whether a request body of the given Content-Type can be read
+// as a merge patch, plain JSON and a missing type are taken as one too
+func Accepts(contentType string) bool {
+	if len(strings.TrimSpace(contentType)) < 1 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == ContentType || mediaType == "application/json"
+}
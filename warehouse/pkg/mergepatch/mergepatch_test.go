@@ -0,0 +1,224 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator"
+)
+
+type testDimensions struct {
+	Width  float64 `json:"width" validate:"gt=0"`
+	Height float64 `json:"height" validate:"gt=0"`
+}
+
+type testItem struct {
+	Name       string         `json:"name" validate:"required"`
+	Code       string         `json:"code" validate:"required"`
+	Quantity   int            `json:"quantity" validate:"min=0"`
+	Dimensions testDimensions `json:"dimensions"`
+	Tags       []string       `json:"tags,omitempty" validate:"dive,required"`
+	Note       *string        `json:"note"`
+}
+
+// Examples from appendix A of RFC 7396
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":1}`, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+		if err != nil {
+			t.Errorf("Apply(%s, %s) err = %v", tt.doc, tt.patch, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("Apply(%s, %s) = %s, want %s", tt.doc, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestApplyInvalidJSON(t *testing.T) {
+	if _, err := Apply([]byte(`{"a":1}`), []byte(`{"a":`)); err == nil {
+		t.Error("invalid patch was applied")
+	}
+	if _, err := Apply([]byte(`{"a":`), []byte(`{"a":1}`)); err == nil {
+		t.Error("patch was applied to an invalid document")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	note := "fragile"
+	current := testItem{
+		Name:       "Box",
+		Code:       "BX-1",
+		Quantity:   3,
+		Dimensions: testDimensions{Width: 10, Height: 20},
+		Tags:       []string{"a"},
+		Note:       &note,
+	}
+
+	tests := []struct {
+		name         string
+		patch        string
+		want         testItem
+		wantSupplied []string
+		wantChanged  []string
+		wantErr      bool
+	}{
+		{
+			name:         "replace a field",
+			patch:        `{"name":"Crate"}`,
+			want:         testItem{Name: "Crate", Code: "BX-1", Quantity: 3, Dimensions: testDimensions{Width: 10, Height: 20}, Tags: []string{"a"}, Note: &note},
+			wantSupplied: []string{"name"},
+			wantChanged:  []string{"name"},
+		},
+		{
+			name:         "same value is not a change",
+			patch:        `{"quantity":3.0,"code":"BX-1"}`,
+			want:         current,
+			wantSupplied: []string{"code", "quantity"},
+		},
+		{
+			name:         "nested object merges",
+			patch:        `{"dimensions":{"height":25}}`,
+			want:         testItem{Name: "Box", Code: "BX-1", Quantity: 3, Dimensions: testDimensions{Width: 10, Height: 25}, Tags: []string{"a"}, Note: &note},
+			wantSupplied: []string{"dimensions"},
+			wantChanged:  []string{"dimensions"},
+		},
+		{
+			name:         "null clears a field",
+			patch:        `{"note":null,"tags":null}`,
+			want:         testItem{Name: "Box", Code: "BX-1", Quantity: 3, Dimensions: testDimensions{Width: 10, Height: 20}},
+			wantSupplied: []string{"note", "tags"},
+			wantChanged:  []string{"note", "tags"},
+		},
+		{name: "unknown field", patch: `{"colour":"red"}`, wantErr: true},
+		{name: "wrong type", patch: `{"quantity":"many"}`, wantErr: true},
+		{name: "not an object", patch: `["name"]`, wantErr: true},
+		{name: "null patch", patch: `null`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testItem
+			result, err := Merge(current, []byte(tt.patch), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(result.Supplied, tt.wantSupplied) {
+				t.Errorf("supplied = %v, want %v", result.Supplied, tt.wantSupplied)
+			}
+			if !reflect.DeepEqual(result.Changed, tt.wantChanged) {
+				t.Errorf("changed = %v, want %v", result.Changed, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	// Code is missing, which a patch leaving it alone must not be blamed for
+	invalid := testItem{
+		Name:       "",
+		Quantity:   -1,
+		Dimensions: testDimensions{Width: 0, Height: 5},
+		Tags:       []string{""},
+	}
+
+	tests := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{"nothing supplied", nil, nil},
+		{"valid field", []string{"note"}, nil},
+		{"top level field", []string{"name", "quantity"}, []string{"testItem.Name", "testItem.Quantity"}},
+		{"nested field", []string{"dimensions"}, []string{"testItem.Dimensions.Width"}},
+		{"slice element", []string{"tags"}, []string{"testItem.Tags[0]"}},
+		{"unknown json name", []string{"colour"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(validator.New(), invalid, tt.fields)
+
+			var got []string
+			if err != nil {
+				errs, ok := err.(validator.ValidationErrors)
+				if !ok {
+					t.Fatalf("err = %v", err)
+				}
+				for _, fe := range errs {
+					got = append(got, fe.StructNamespace())
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("failures = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"", true},
+		{ContentType, true},
+		{"application/merge-patch+json; charset=utf-8", true},
+		{"application/json", true},
+		{"APPLICATION/JSON", true},
+		{"application/json-patch+json", false},
+		{"text/plain", false},
+		{"application/json; charset=\"", false},
+	}
+
+	for _, tt := range tests {
+		if got := Accepts(tt.contentType); got != tt.want {
+			t.Errorf("Accepts(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestMergeKeepsCurrent(t *testing.T) {
+	current := testItem{Name: "Box", Tags: []string{"a"}}
+	before, _ := json.Marshal(current)
+
+	var out testItem
+	if _, err := Merge(current, []byte(`{"tags":["b"],"name":"Crate"}`), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	after, _ := json.Marshal(current)
+	if string(before) != string(after) {
+		t.Errorf("current changed from %s to %s", before, after)
+	}
+}
@@ -0,0 +1,144 @@
+// Package ocrmatch compares text read by OCR with known codes, substitutions
+// OCR commonly makes are cheaper than any other edit.
+package ocrmatch
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// Cost of replacing a character with one OCR mistakes it for
+	ConfusionCost = 0.25
+	// Cost of any other insertion, deletion or substitution
+	EditCost = 1.0
+)
+
+// Characters OCR engines mix up, each group is confusable with itself
+var confusionGroups = []string{
+	"O0DQ",
+	"I1L",
+	"S5",
+	"B8",
+	"Z2",
+	"G6",
+}
+
+var confusable = buildConfusable()
+
+func buildConfusable() map[[2]rune]bool {
+	pairs := make(map[[2]rune]bool)
+	for _, group := range confusionGroups {
+		for _, a := range group {
+			for _, b := range group {
+				if a != b {
+					pairs[[2]rune{a, b}] = true
+				}
+			}
+		}
+	}
+	return pairs
+}
+
+type Candidate struct {
+	Code     string
+	Distance float64
+	// Score is 1 for an exact match and drops towards 0 as the distance grows
+	// relative to the length of the longer string
+	Score float64
+}
+
+// Distance is the weighted Levenshtein distance between a and b, compared
+// without regard to case.
+func Distance(a, b string) float64 {
+	ra := []rune(strings.ToUpper(a))
+	rb := []rune(strings.ToUpper(b))
+
+	previous := make([]float64, len(rb)+1)
+	current := make([]float64, len(rb)+1)
+	for j := range previous {
+		previous[j] = float64(j) * EditCost
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = float64(i) * EditCost
+		for j := 1; j <= len(rb); j++ {
+			substitution := previous[j-1] + substitutionCost(ra[i-1], rb[j-1])
+			deletion := previous[j] + EditCost
+			insertion := current[j-1] + EditCost
+			current[j] = min3(substitution, deletion, insertion)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(rb)]
+}
+
+// Score turns the distance between a and b into a value between 0 and 1
+func Score(a, b string, distance float64) float64 {
+	longest := utf8.RuneCountInString(a)
+	if n := utf8.RuneCountInString(b); n > longest {
+		longest = n
+	}
+	if longest == 0 {
+		return 1
+	}
+
+	score := 1 - distance/float64(longest)
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// Closest returns at most limit codes within maxDistance of text, best first
+func Closest(text string, codes []string, maxDistance float64, limit int) []Candidate {
+	var candidates []Candidate
+
+	for _, code := range codes {
+		distance := Distance(text, code)
+		if distance > maxDistance {
+			continue
+		}
+
+		candidates = append(candidates, Candidate{
+			Code:     code,
+			Distance: distance,
+			Score:    Score(text, code, distance),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Distance != candidates[j].Distance {
+			return candidates[i].Distance < candidates[j].Distance
+		}
+		return candidates[i].Score > candidates[j].Score
+	})
+
+	if limit > 0 && len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	return candidates
+}
+
+func substitutionCost(a, b rune) float64 {
+	if a == b {
+		return 0
+	}
+	if confusable[[2]rune{a, b}] {
+		return ConfusionCost
+	}
+	return EditCost
+}
+
+func min3(a, b, c float64) float64 {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,86 @@
+package ocrmatch
+
+import (
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want float64
+	}{
+		{"equal", "SKU-001", "SKU-001", 0},
+		{"case is ignored", "sku-001", "SKU-001", 0},
+		{"confusable digit", "SKU-0O1", "SKU-001", ConfusionCost},
+		{"two confusions", "5KU-00I", "SKU-001", 2 * ConfusionCost},
+		{"other substitution", "SKU-X01", "SKU-001", EditCost},
+		{"insertion", "SKU-0001", "SKU-001", EditCost},
+		{"deletion", "SKU01", "SKU-001", 2 * EditCost},
+		{"empty", "", "ABC", 3 * EditCost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("Distance(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := Distance(tt.b, tt.a); got != tt.want {
+				t.Errorf("Distance(%s, %s) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		a        string
+		b        string
+		distance float64
+		want     float64
+	}{
+		{"ABCD", "ABCD", 0, 1},
+		{"ABCD", "ABC", 1, 0.75},
+		{"AB", "XYZW", 5, 0},
+		{"", "", 0, 1},
+	}
+
+	for _, tt := range tests {
+		if got := Score(tt.a, tt.b, tt.distance); got != tt.want {
+			t.Errorf("Score(%s, %s, %v) = %v, want %v", tt.a, tt.b, tt.distance, got, tt.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	codes := []string{"SKU-001", "SKU-007", "SKU-010", "BIN-001"}
+
+	tests := []struct {
+		name        string
+		text        string
+		maxDistance float64
+		limit       int
+		want        []string
+	}{
+		{"exact match first", "SKU-001", 1, 0, []string{"SKU-001", "SKU-007"}},
+		{"confusion beats edit", "SKU-0O7", 1.25, 0, []string{"SKU-007", "SKU-001"}},
+		{"limit", "SKU-001", 1, 1, []string{"SKU-001"}},
+		{"max distance", "SKU-0O1", 0.5, 0, []string{"SKU-001"}},
+		{"nothing close", "PALLET", 1, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Closest(tt.text, codes, tt.maxDistance, tt.limit)
+			if len(got) != len(tt.want) {
+				t.Fatalf("candidates = %+v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].Code != tt.want[i] {
+					t.Fatalf("candidates = %+v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package sqlerr
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	// ER_DUP_ENTRY, a write would repeat the value of a unique index
+	mysqlDuplicateEntry = 1062
+)
+
+// IsDuplicateKey tells whether err comes from a write that broke a unique
+// index or primary key
+func IsDuplicateKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
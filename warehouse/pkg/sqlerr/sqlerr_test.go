@@ -0,0 +1,33 @@
+package sqlerr
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+func TestIsDuplicateKey(t *testing.T) {
+	duplicate := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'A1' for key 'name'"}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"duplicate entry", duplicate, true},
+		{"wrapped duplicate entry", fmt.Errorf("create bin: %w", duplicate), true},
+		{"other mysql error", &mysql.MySQLError{Number: 1452}, false},
+		{"not a mysql error", sql.ErrNoRows, false},
+		{"no error", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDuplicateKey(tt.err); got != tt.want {
+				t.Errorf("IsDuplicateKey(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Package sqltest is a database/sql driver for tests of code that runs
+// statements through sqlx, no database server is needed
+package sqltest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Driver answers every query with Rows and records the statements and
+// transactions it sees
+type Driver struct {
+	Columns []string
+	Rows    [][]driver.Value
+	// Err fails every query and exec
+	Err error
+	// RowsAffected is what every exec reports
+	RowsAffected int64
+
+	Statements []Statement
+	// Events are begin, commit and rollback in the order they happened
+	Events []string
+}
+
+type Statement struct {
+	Query string
+	Args  []driver.Value
+}
+
+// DB opens a database on the driver, placeholders are bound the MySQL way
+func (d *Driver) DB() *sqlx.DB {
+	return sqlx.NewDb(sql.OpenDB(d), "mysql")
+}
+
+func (d *Driver) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{driver: d}, nil
+}
+
+func (d *Driver) Driver() driver.Driver {
+	return nil
+}
+
+func (d *Driver) record(query string, args []driver.NamedValue) {
+	values := make([]driver.Value, 0, len(args))
+	for _, arg := range args {
+		values = append(values, arg.Value)
+	}
+	d.Statements = append(d.Statements, Statement{Query: query, Args: values})
+}
+
+type conn struct {
+	driver *Driver
+}
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("sqltest: prepared statements are not supported")
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	c.driver.Events = append(c.driver.Events, "begin")
+	return c, nil
+}
+
+func (c *conn) Commit() error {
+	c.driver.Events = append(c.driver.Events, "commit")
+	return nil
+}
+
+func (c *conn) Rollback() error {
+	c.driver.Events = append(c.driver.Events, "rollback")
+	return nil
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.record(query, args)
+	if c.driver.Err != nil {
+		return nil, c.driver.Err
+	}
+	return &rows{columns: c.driver.Columns, values: c.driver.Rows}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.driver.record(query, args)
+	if c.driver.Err != nil {
+		return nil, c.driver.Err
+	}
+	return driver.RowsAffected(c.driver.RowsAffected), nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) < 1 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,62 @@
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type txKey struct{}
+
+// Executor runs statements, it is the database itself or the transaction a
+// context carries
+type Executor interface {
+	Rebind(query string) string
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// From returns the transaction carried by ctx, or db outside of one
+func From(ctx context.Context, db *sqlx.DB) Executor {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// Run calls fn with a context carrying a transaction, committed once fn
+// succeeds. When ctx already carries one fn joins it and the outermost Run
+// commits, so repositories can group their own statements without breaking
+// a transaction started by a usecase.
+func Run(ctx context.Context, db *sqlx.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Transactor lets usecases run several repository calls in one transaction
+type Transactor struct {
+	db *sqlx.DB
+}
+
+func NewTransactor(db *sqlx.DB) *Transactor {
+	return &Transactor{db: db}
+}
+
+func (t *Transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return Run(ctx, t.db, fn)
+}
@@ -0,0 +1,86 @@
+package sqltx
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/sqltest"
+	"github.com/jmoiron/sqlx"
+)
+
+func TestRun(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	tests := []struct {
+		name       string
+		fn         func(ctx context.Context, db *sqlx.DB) error
+		wantErr    error
+		wantEvents []string
+	}{
+		{
+			name: "commits",
+			fn: func(ctx context.Context, db *sqlx.DB) error {
+				return nil
+			},
+			wantEvents: []string{"begin", "commit"},
+		},
+		{
+			name: "error rolls back",
+			fn: func(ctx context.Context, db *sqlx.DB) error {
+				return errFailed
+			},
+			wantErr:    errFailed,
+			wantEvents: []string{"begin", "rollback"},
+		},
+		{
+			name: "nested run joins the outer transaction",
+			fn: func(ctx context.Context, db *sqlx.DB) error {
+				outer := From(ctx, db)
+				return Run(ctx, db, func(ctx context.Context) error {
+					if From(ctx, db) != outer {
+						return errors.New("nested run started another transaction")
+					}
+					return nil
+				})
+			},
+			wantEvents: []string{"begin", "commit"},
+		},
+		{
+			name: "nested error rolls back the outer transaction",
+			fn: func(ctx context.Context, db *sqlx.DB) error {
+				return Run(ctx, db, func(ctx context.Context) error {
+					return errFailed
+				})
+			},
+			wantErr:    errFailed,
+			wantEvents: []string{"begin", "rollback"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &sqltest.Driver{}
+			db := fake.DB()
+			defer db.Close()
+
+			if From(context.Background(), db) != Executor(db) {
+				t.Fatal("From outside of Run is not the database")
+			}
+
+			err := Run(context.Background(), db, func(ctx context.Context) error {
+				if _, ok := From(ctx, db).(*sqlx.Tx); !ok {
+					t.Error("From inside of Run is not the transaction")
+				}
+				return tt.fn(ctx, db)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(fake.Events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", fake.Events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+
+	// Register decoders so DecodeConfig understands every accepted type
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+type ImageType string
+
+const (
+	TypePNG  ImageType = "png"
+	TypeJPEG ImageType = "jpeg"
+	TypeWebP ImageType = "webp"
+	TypeTIFF ImageType = "tiff"
+	TypeHEIC ImageType = "heic"
+
+	// Enough bytes for every signature below, same as http.DetectContentType
+	sniffLength = 512
+
+	// HEIC keeps its size in an ispe box inside the meta box near the start
+	heicScanLength = 256 * 1024
+)
+
+var (
+	// Types DetectImageType recognises, all of them are accepted by default
+	SupportedTypes = []ImageType{TypePNG, TypeJPEG, TypeWebP, TypeTIFF, TypeHEIC}
+	// Types with a registered image decoder
+	DecodableTypes = []ImageType{TypePNG, TypeJPEG, TypeWebP, TypeTIFF}
+
+	heicBrands = []string{"heic", "heix", "hevc", "hevx", "heim", "heis", "mif1", "msf1", "avif"}
+)
+
+func (t ImageType) ContentType() string {
+	switch t {
+	case TypePNG:
+		return "image/png"
+	case TypeJPEG:
+		return "image/jpeg"
+	case TypeWebP:
+		return "image/webp"
+	case TypeTIFF:
+		return "image/tiff"
+	case TypeHEIC:
+		return "image/heic"
+	}
+
+	return "application/octet-stream"
+}
+
+// Decodable tells whether the pixels of the type can be read here, other types
+// can only be passed on as uploaded
+func (t ImageType) Decodable() bool {
+	for _, decodable := range DecodableTypes {
+		if t == decodable {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectImageType looks at the magic bytes only, the client supplied file name
+// and content type are never trusted.
+func DetectImageType(header []byte) (ImageType, error) {
+	switch {
+	case bytes.HasPrefix(header, []byte("\x89PNG\r\n\x1a\n")):
+		return TypePNG, nil
+	case bytes.HasPrefix(header, []byte{0xFF, 0xD8, 0xFF}):
+		return TypeJPEG, nil
+	case len(header) >= 12 && string(header[:4]) == "RIFF" && string(header[8:12]) == "WEBP":
+		return TypeWebP, nil
+	case bytes.HasPrefix(header, []byte("II*\x00")), bytes.HasPrefix(header, []byte("MM\x00*")):
+		return TypeTIFF, nil
+	case len(header) >= 12 && string(header[4:8]) == "ftyp":
+		brand := string(header[8:12])
+		for _, b := range heicBrands {
+			if brand == b {
+				return TypeHEIC, nil
+			}
+		}
+	}
+
+	return "", ErrUnsupportedType
+}
+
+// ImageDimensions reads only the image header, never the pixels, so it is safe
+// to call before deciding whether a picture is too large to decode. ok is false
+// when the size cannot be determined without a full decoder.
+func ImageDimensions(r io.ReadSeeker, imageType ImageType) (width, height int, ok bool, err error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, false, err
+	}
+
+	if imageType == TypeHEIC {
+		header := make([]byte, heicScanLength)
+		n, err := io.ReadFull(r, header)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return 0, 0, false, err
+		}
+
+		width, height, ok = heicDimensions(header[:n])
+		return width, height, ok, nil
+	}
+
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	return cfg.Width, cfg.Height, true, nil
+}
+
+// heicDimensions returns the largest ispe box, smaller ones describe thumbnails
+func heicDimensions(data []byte) (width, height int, ok bool) {
+	marker := []byte("ispe")
+
+	for offset := 0; ; {
+		i := bytes.Index(data[offset:], marker)
+		if i < 0 {
+			return width, height, ok
+		}
+
+		// ispe: version and flags (4), width (4), height (4)
+		start := offset + i + len(marker) + 4
+		if start+8 > len(data) {
+			return width, height, ok
+		}
+
+		w := int(binary.BigEndian.Uint32(data[start : start+4]))
+		h := int(binary.BigEndian.Uint32(data[start+4 : start+8]))
+		if w*h > width*height {
+			width, height, ok = w, h, true
+		}
+
+		offset = start + 8
+	}
+}
@@ -0,0 +1,152 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func heicHeader(brand string, boxes ...[2]uint32) []byte {
+	header := []byte("\x00\x00\x00\x18ftyp" + brand + "\x00\x00\x00\x00mif1heic")
+	for _, box := range boxes {
+		ispe := make([]byte, 4+4+8)
+		copy(ispe, "ispe")
+		binary.BigEndian.PutUint32(ispe[8:], box[0])
+		binary.BigEndian.PutUint32(ispe[12:], box[1])
+		header = append(header, ispe...)
+	}
+	return header
+}
+
+func pngBytes(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectImageType(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  []byte
+		want    ImageType
+		wantErr error
+	}{
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00"), TypePNG, nil},
+		{"jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, TypeJPEG, nil},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), TypeWebP, nil},
+		{"tiff little endian", []byte("II*\x00\x08\x00"), TypeTIFF, nil},
+		{"tiff big endian", []byte("MM\x00*\x00\x08"), TypeTIFF, nil},
+		{"heic", heicHeader("heic"), TypeHEIC, nil},
+		{"avif", heicHeader("avif"), TypeHEIC, nil},
+		{"mp4 ftyp", heicHeader("isom"), "", ErrUnsupportedType},
+		{"gif", []byte("GIF89a"), "", ErrUnsupportedType},
+		{"short riff", []byte("RIFF"), "", ErrUnsupportedType},
+		{"empty", nil, "", ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectImageType(tt.header)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("type = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImageDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       []byte
+		imageType  ImageType
+		wantWidth  int
+		wantHeight int
+		wantOK     bool
+	}{
+		{"png", pngBytes(t, 30, 20), TypePNG, 30, 20, true},
+		{"heic keeps largest ispe", heicHeader("heic", [2]uint32{160, 120}, [2]uint32{4032, 3024}), TypeHEIC, 4032, 3024, true},
+		{"heic without ispe", heicHeader("heic"), TypeHEIC, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, ok, err := ImageDimensions(bytes.NewReader(tt.data), tt.imageType)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width != tt.wantWidth || height != tt.wantHeight || ok != tt.wantOK {
+				t.Errorf("got %dx%d ok=%v, want %dx%d ok=%v", width, height, ok, tt.wantWidth, tt.wantHeight, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSaveImage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		cfg     Config
+		wantErr error
+	}{
+		{"png", pngBytes(t, 10, 10), Config{TempDir: t.TempDir()}, nil},
+		{"heic accepted by default", heicHeader("heic", [2]uint32{10, 10}), Config{TempDir: t.TempDir()}, nil},
+		{"type not allowed", heicHeader("heic", [2]uint32{10, 10}), Config{TempDir: t.TempDir(), AllowedTypes: DecodableTypes}, ErrUnsupportedType},
+		{"too many pixels", pngBytes(t, 100, 100), Config{TempDir: t.TempDir(), MaxPixels: 99}, ErrImageTooLarge},
+		{"file too large", pngBytes(t, 10, 10), Config{TempDir: t.TempDir(), MaxFileBytes: 10}, ErrFileTooLarge},
+		{"not an image", []byte("hello world"), Config{TempDir: t.TempDir()}, ErrUnsupportedType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body bytes.Buffer
+			form := multipart.NewWriter(&body)
+			part, err := form.CreateFormFile("image", "upload")
+			if err != nil {
+				t.Fatal(err)
+			}
+			part.Write(tt.data)
+			form.Close()
+
+			r := httptest.NewRequest(http.MethodPost, "/", &body)
+			r.Header.Set("Content-Type", form.FormDataContentType())
+
+			uploaded, err := SaveImage(httptest.NewRecorder(), r, "image", tt.cfg)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if uploaded != nil {
+				uploaded.Close()
+			}
+		})
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{ErrRequestTooLarge, http.StatusRequestEntityTooLarge},
+		{ErrFileTooLarge, http.StatusRequestEntityTooLarge},
+		{ErrImageTooLarge, http.StatusRequestEntityTooLarge},
+		{ErrUnsupportedType, http.StatusUnsupportedMediaType},
+		{ErrMissingFile, http.StatusBadRequest},
+		{ErrUnreadableUpload, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		if got := StatusCode(tt.err); got != tt.want {
+			t.Errorf("StatusCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
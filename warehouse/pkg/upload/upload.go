@@ -0,0 +1,213 @@
+package upload
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"github.com/alvinradeka/jamblang-hakenton/warehouse/pkg/httpcommon"
+)
+
+const (
+	defaultMaxRequestBytes = 25 << 20
+	defaultMaxFileBytes    = 20 << 20
+	defaultMaxPixels       = 50000000
+	defaultMemoryBytes     = 8 << 20
+)
+
+var (
+	ErrMissingFile      = errors.New("missing uploaded file")
+	ErrRequestTooLarge  = errors.New("request body too large")
+	ErrFileTooLarge     = errors.New("uploaded file too large")
+	ErrImageTooLarge    = errors.New("image dimensions too large")
+	ErrUnsupportedType  = errors.New("unsupported image type")
+	ErrUnreadableUpload = errors.New("cannot read uploaded file")
+)
+
+type Config struct {
+	// MaxRequestBytes caps the whole multipart body, MaxFileBytes a single part
+	MaxRequestBytes int64
+	MaxFileBytes    int64
+	// MaxPixels guards against decode bombs, small files claiming huge sizes
+	MaxPixels int64
+	// MemoryBytes of the form are kept in memory, the rest spills to disk
+	MemoryBytes int64
+	TempDir     string
+	// AllowedTypes are the image types accepted, by default every supported
+	// one. HEIC has no decoder here, it is passed on to the barcode decoder
+	// as uploaded and cannot be annotated or preprocessed.
+	AllowedTypes []ImageType
+}
+
+type Image struct {
+	File   *os.File
+	Type   ImageType
+	Size   int64
+	Width  int
+	Height int
+}
+
+// Close closes and removes the temporary copy of the upload
+func (i *Image) Close() error {
+	err := i.File.Close()
+	os.Remove(i.File.Name())
+	return err
+}
+
+// RequestLimit is the largest request body accepted, other handlers reading a
+// whole body stay within it too
+func (c Config) RequestLimit() int64 {
+	return c.withDefaults().MaxRequestBytes
+}
+
+func (c Config) withDefaults() Config {
+	if c.MaxRequestBytes < 1 {
+		c.MaxRequestBytes = defaultMaxRequestBytes
+	}
+	if c.MaxFileBytes < 1 {
+		c.MaxFileBytes = defaultMaxFileBytes
+	}
+	if c.MaxPixels < 1 {
+		c.MaxPixels = defaultMaxPixels
+	}
+	if c.MemoryBytes < 1 {
+		c.MemoryBytes = defaultMemoryBytes
+	}
+	if c.TempDir == "" {
+		c.TempDir = os.TempDir()
+	}
+	if len(c.AllowedTypes) < 1 {
+		c.AllowedTypes = SupportedTypes
+	}
+
+	return c
+}
+
+// SaveImage enforces the size limits on the request, verifies that the given
+// form field really holds a supported image and copies it to a temporary file.
+// The caller must Close the returned Image.
+func SaveImage(w http.ResponseWriter, r *http.Request, field string, cfg Config) (*Image, error) {
+	cfg = cfg.withDefaults()
+
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxRequestBytes)
+	if err := r.ParseMultipartForm(cfg.MemoryBytes); err != nil {
+		if httpcommon.IsRequestTooLarge(err) {
+			return nil, ErrRequestTooLarge
+		}
+		return nil, ErrUnreadableUpload
+	}
+
+	file, header, err := r.FormFile(field)
+	if err != nil {
+		return nil, ErrMissingFile
+	}
+	defer file.Close()
+
+	if header.Size > cfg.MaxFileBytes {
+		return nil, ErrFileTooLarge
+	}
+
+	sniff := make([]byte, sniffLength)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, ErrUnreadableUpload
+	}
+
+	imageType, err := DetectImageType(sniff[:n])
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.allows(imageType) {
+		return nil, ErrUnsupportedType
+	}
+
+	tempFile, err := ioutil.TempFile(cfg.TempDir, "upload")
+	if err != nil {
+		return nil, err
+	}
+	uploaded := &Image{
+		File: tempFile,
+		Type: imageType,
+	}
+
+	if _, err := tempFile.Write(sniff[:n]); err != nil {
+		uploaded.Close()
+		return nil, err
+	}
+
+	// Never trust the declared size, stop copying one byte past the limit
+	copied, err := io.Copy(tempFile, io.LimitReader(file, cfg.MaxFileBytes-int64(n)+1))
+	if err != nil {
+		uploaded.Close()
+		return nil, ErrUnreadableUpload
+	}
+	uploaded.Size = int64(n) + copied
+	if uploaded.Size > cfg.MaxFileBytes {
+		uploaded.Close()
+		return nil, ErrFileTooLarge
+	}
+
+	width, height, ok, err := ImageDimensions(tempFile, imageType)
+	if err != nil {
+		uploaded.Close()
+		return nil, ErrUnsupportedType
+	}
+	if ok && int64(width)*int64(height) > cfg.MaxPixels {
+		uploaded.Close()
+		return nil, ErrImageTooLarge
+	}
+	uploaded.Width = width
+	uploaded.Height = height
+
+	if _, err := tempFile.Seek(0, io.SeekStart); err != nil {
+		uploaded.Close()
+		return nil, err
+	}
+
+	return uploaded, nil
+}
+
+func (c Config) allows(imageType ImageType) bool {
+	for _, allowed := range c.AllowedTypes {
+		if allowed == imageType {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusCode maps upload errors to the HTTP status a handler should answer with
+func StatusCode(err error) int {
+	switch err {
+	case ErrRequestTooLarge, ErrFileTooLarge, ErrImageTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrUnsupportedType:
+		return http.StatusUnsupportedMediaType
+	case ErrMissingFile, ErrUnreadableUpload:
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Message is a client friendly description of an upload error
+func Message(err error) string {
+	switch err {
+	case ErrRequestTooLarge:
+		return "Request Too Large"
+	case ErrFileTooLarge:
+		return "Uploaded Image Too Large"
+	case ErrImageTooLarge:
+		return "Image Dimensions Too Large"
+	case ErrUnsupportedType:
+		return "Unsupported Image Type, Use PNG, JPEG, WebP, TIFF or HEIC"
+	case ErrMissingFile:
+		return "Cannot Read Uploaded Image"
+	case ErrUnreadableUpload:
+		return "Cannot Read Upload"
+	}
+
+	return "Cannot Store Uploaded Image"
+}